@@ -0,0 +1,91 @@
+// Package asm assembles class files from a Jasmin-like text format and disassembles them back to it.
+//
+// A source file is a sequence of directives and, inside methods, labels and instructions.
+// A ';' at the start of a token starts a comment. Strings are quoted with Go syntax and
+// stored in Modified UTF-8. Disassembled Utf8 constants are decoded, replacing unpaired
+// surrogates and malformed bytes with U+FFFD.
+//
+//	.version 52 0
+//	.source Foo.java
+//	.class public super com/acme/Foo
+//	.super java/lang/Object
+//	.implements java/lang/Runnable
+//	.signature "Ljava/lang/Object;Ljava/lang/Runnable;"
+//	.bootstrap invokestatic java/lang/invoke/StringConcatFactory/makeConcatWithConstants(...)Ljava/lang/invoke/CallSite; "\u0001!"
+//
+//	.field public static final MAX I = 10
+//	.field private items Ljava/util/List; signature "Ljava/util/List<Ljava/lang/String;>;"
+//
+//	.method public run()V
+//	    .limit stack 2
+//	    .limit locals 1
+//	    .throws java/io/IOException
+//	    .catch java/lang/Exception from L0 to L1 using L1
+//	    .var 0 is this Lcom/acme/Foo; from L0 to L2
+//	L0:
+//	    .line 5
+//	    getstatic java/lang/System/out Ljava/io/PrintStream;
+//	    ldc "hello"
+//	    invokevirtual java/io/PrintStream/println(Ljava/lang/String;)V
+//	    return
+//	L1:
+//	    .stack
+//	        locals Object com/acme/Foo
+//	        stack Object java/lang/Exception
+//	    .end stack
+//	    athrow
+//	L2:
+//	.end method
+//
+// Constants of ldc and field values are written as 10 (int), 10L (long), 1.5F (float),
+// 1.5D (double), "text" (String), class java/lang/String, methodtype (I)V,
+// methodhandle invokestatic com/acme/Foo/bar()V and dynamic <bootstrap index> name descriptor
+// for a dynamically-computed constant. The keyword interface marks
+// a reference to an interface method. The ldc2_w instruction and fields of type long
+// and double read numbers without a suffix as long and double.
+//
+// Instructions use their javap mnemonics. Fields are referenced as owner/name descriptor,
+// methods as owner/name(descriptor), classes by their internal names, branch targets by
+// labels, and invokedynamic as invokedynamic <bootstrap index> name(descriptor).
+// tableswitch and lookupswitch are followed by key : label lines, with consecutive keys
+// for tableswitch, and end with a default : label line.
+// The wide prefix and ldc_w are chosen automatically when an index does not fit.
+// A method with code needs .limit stack. Without .limit locals, max_locals is the size of the arguments.
+// A class without .super has no super class, which is only valid for java/lang/Object.
+//
+// Attributes without a directive are written raw as .attribute <name> "<hex of the bytes>",
+// after .field lines and closed by .end field for fields, and as .codeattribute for attributes
+// of the code. As their bytes have constant pool indexes, the class starts with its constant pool
+// written as .const <index> <kind> <value or indexes> lines, which are added at the same indexes:
+//
+//	.const 1 Methodref 2 3
+//	.const 2 Class 4
+//	.const 3 NameAndType 5 6
+//	.const 4 Utf8 "java/lang/Object"
+//	.const 5 Utf8 "<init>"
+//	.const 6 Utf8 "()V"
+//	.const 7 Long 10
+//	.const 9 MethodHandle invokespecial 1
+//
+// A Long or Double takes two indexes. The other directives reuse these entries for equal constants.
+//
+// Stack map frames are written as full frames with .stack blocks at the current offset.
+// Their verification types are Top, Integer, Float, Long, Double, Null, UninitializedThis,
+// Object <class> and Uninitialized <label of the new instruction>.
+package asm
+
+import "fmt"
+
+// Error is an assembly error at a line of the source.
+type Error struct {
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,281 @@
+package asm
+
+import (
+	"bytes"
+	"encoding"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-javap/parser"
+)
+
+const source = `.version 55 0
+.source Counter.java
+.class public super com/acme/Counter
+.super java/lang/Object
+.implements java/lang/Runnable
+.signature "Ljava/lang/Object;Ljava/lang/Runnable;"
+.bootstrap invokestatic java/lang/invoke/StringConcatFactory/makeConcatWithConstants(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/invoke/CallSite; "count \u0001"
+
+.field public static final MAX J = 10
+.field public static final RATIO F = 1.5
+.field private names Ljava/util/List; signature "Ljava/util/List<Ljava/lang/String;>;"
+
+.method public <init>()V
+    .limit stack 1
+    aload_0
+    invokespecial java/lang/Object/<init>()V
+    return
+.end method
+
+.method public run()V
+    .limit stack 3
+    .limit locals 300
+    .catch java/lang/RuntimeException from L0 to L1 using L1
+    .var 0 is this Lcom/acme/Counter; from L0 to L2
+L0:
+    .line 10
+    aload_0
+    getfield com/acme/Counter/names Ljava/util/List;
+    invokeinterface java/util/List/size()I
+    istore 299
+    iinc 299 1000
+    iload 299
+    lookupswitch
+        10 : L2
+        -1 : L1
+        default : L2
+L1:
+    .stack
+        locals Object com/acme/Counter
+        stack Object java/lang/Throwable
+    .end stack
+    athrow
+L2:
+    .stack
+        locals Object com/acme/Counter
+    .end stack
+    iload 299
+    invokedynamic 0 makeConcatWithConstants(I)Ljava/lang/String;
+    ldc2_w 5
+    ldc 2.5
+    ldc class [I
+    ldc dynamic 0 ZERO I
+    return
+.end method
+
+.method public abstract close()V
+    .throws java/io/IOException
+.end method
+`
+
+func TestAssemble(t *testing.T) {
+	c, err := Assemble(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := parser.Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	class, err := parser.ReadClass(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := class.Name(); name != "com/acme/Counter" {
+		t.Errorf("Name() = %q", name)
+	}
+	if v := class.FieldByName("MAX").ConstantValue; v != int64(10) {
+		t.Errorf("MAX = %#v, want int64(10)", v)
+	}
+	if v := class.FieldByName("RATIO").ConstantValue; v != float32(1.5) {
+		t.Errorf("RATIO = %#v, want float32(1.5)", v)
+	}
+	if e := class.Method("close", "()V").Exceptions; len(e) != 1 || e[0] != "java/io/IOException" {
+		t.Errorf("close() throws %v", e)
+	}
+
+	run := class.Method("run", "()V")
+	if run.Code.MaxStack != 3 || run.Code.MaxLocals != 300 {
+		t.Errorf("run() stack=%d, locals=%d", run.Code.MaxStack, run.Code.MaxLocals)
+	}
+	instructions, err := run.Code.Instructions(c.ConstantPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, inst := range instructions {
+		got = append(got, inst.String())
+	}
+	want := []string{
+		"0: aload_0",
+		"1: getfield #34",
+		"4: invokeinterface #40, 1",
+		"9: istore 299",
+		"13: iinc 299, 1000",
+		"19: iload 299",
+		"23: lookupswitch { -1: 48, 10: 49, default: 49 }",
+		"48: athrow",
+		"49: iload 299",
+		"53: invokedynamic #43",
+		"58: ldc2_w #44",
+		"61: ldc #46",
+		"63: ldc #48",
+		"65: ldc #52",
+		"67: return",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("instructions =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if e := run.Code.ExceptionTable; len(e) != 1 || e[0].StartPC != 0 || e[0].EndPC != 48 || e[0].HandlerPC != 48 {
+		t.Errorf("exception table = %v", e)
+	}
+}
+
+func TestDisassemble_RoundTrip(t *testing.T) {
+	c, err := Assemble(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text bytes.Buffer
+	if err := Disassemble(&text, c); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Assemble(bytes.NewReader(text.Bytes()))
+	if err != nil {
+		t.Fatalf("%v in\n%s", err, text.String())
+	}
+	var want, got bytes.Buffer
+	if err := parser.Write(&want, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Write(&got, again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("reassembled class differs from the original, disassembled as\n%s", text.String())
+	}
+}
+
+func TestAssemble_Error(t *testing.T) {
+	tests := []struct {
+		source string
+		line   int
+		msg    string
+	}{
+		{".super java/lang/Object", 1, ".super before .class"},
+		{".class Foo\n.method m()V\n    goto L9\n.end method", 3, "undefined label L9"},
+		{".class Foo\n.method m()V\n    bipush 200\n.end method", 3, `invalid byte "200"`},
+		{".class Foo\n.method m()V\n    frobnicate\n.end method", 3, "unknown instruction frobnicate"},
+		{".class Foo\n.method m()V\n    ldc \"unterminated\n.end method", 3, "unterminated string"},
+		{".class Foo\n.method m()V\n    tableswitch\n        1 : L0\n        3 : L0\n        default : L0\nL0:\n    return\n.end method", 6, "consecutive"},
+		{".class Foo\n.method m()V\n    .limit stack 1\n    invokedynamic 0 run()V\n    return\n.end method", 4, "undefined bootstrap method 0"},
+		{".class Foo\n.method m()V\n    return\n.end method", 4, "missing .limit stack of m"},
+		{".const 1 Utf8 Foo\n.const 3 Class 1", 2, "constant #3 is not at the next index #2"},
+		{".const 1 Class 2\n.class Foo", 1, "constant #2 is not defined"},
+		{".class Foo\n.const 1 Utf8 Foo", 2, ".const after .class"},
+		{".class Foo\n.attribute Deprecated 0g", 2, `invalid attribute bytes "0g"`},
+		{".class Foo\n.method m()V\n    return", 3, "missing .end method"},
+	}
+	for _, tt := range tests {
+		_, err := Assemble(strings.NewReader(tt.source))
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Assemble(%q) error = %v, want *Error", tt.source, err)
+			continue
+		}
+		if e.Line != tt.line || !strings.Contains(e.Error(), tt.msg) {
+			t.Errorf("Assemble(%q) error = %v, want line %d: %s", tt.source, err, tt.line, tt.msg)
+		}
+	}
+}
+
+func TestDisassemble_RoundTripJavac(t *testing.T) {
+	// The classes have annotations, inner classes, nest members and an enclosing method,
+	// which are written raw with the constant pool.
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "*.class"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no class files in testdata")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := parser.Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var text bytes.Buffer
+			if err := Disassemble(&text, c); err != nil {
+				t.Fatal(err)
+			}
+			again, err := Assemble(bytes.NewReader(text.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(c.ConstantPool); len(again.ConstantPool) < n || !reflect.DeepEqual(again.ConstantPool[:n], c.ConstantPool) {
+				t.Fatal("constant pool is not kept")
+			}
+			compareAttributes(t, c, "class", c.Attributes, again.Attributes)
+			for i, f := range c.Fields {
+				compareAttributes(t, c, "field "+c.ConstantPool.GetUTF8(f.NameIndex), f.Attributes, again.Fields[i].Attributes)
+			}
+			for i, m := range c.Methods {
+				name := "method " + c.ConstantPool.GetUTF8(m.NameIndex)
+				compareAttributes(t, c, name, m.Attributes, again.Methods[i].Attributes)
+				if code := codeOf(m); code != nil {
+					compareAttributes(t, c, name+" code", code.Attributes, codeOf(again.Methods[i]).Attributes)
+				}
+			}
+		})
+	}
+}
+
+// compareAttributes compares the bytes of the attributes by name, except Code and the frames,
+// which are compared by TestDisassemble_RoundTrip.
+func compareAttributes(t *testing.T, c *parser.ClassFile, what string, want, got []parser.Attribute) {
+	t.Helper()
+	encode := func(attributes []parser.Attribute) map[string][]byte {
+		m := map[string][]byte{}
+		for _, a := range attributes {
+			name := c.ConstantPool.AttributeName(a)
+			if name == "Code" || name == "StackMapTable" {
+				continue
+			}
+			data, err := a.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			m[name] = append(m[name], data...)
+		}
+		return m
+	}
+	w, g := encode(want), encode(got)
+	for name, data := range w {
+		if !bytes.Equal(g[name], data) {
+			t.Errorf("%s: %s attribute differs", what, name)
+		}
+	}
+	for name := range g {
+		if _, ok := w[name]; !ok {
+			t.Errorf("%s: unexpected %s attribute", what, name)
+		}
+	}
+}
+
+func codeOf(m parser.MethodInfo) *parser.CodeAttribute {
+	for _, a := range m.Attributes {
+		if code, ok := a.(*parser.CodeAttribute); ok {
+			return code
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1364 @@
+package asm
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-javap/descriptor"
+	"go-javap/parser"
+)
+
+var (
+	handleKinds = map[string]parser.MethodHandleRef{
+		"getfield":         parser.MethodHandleRefGetField,
+		"getstatic":        parser.MethodHandleRefGetStatic,
+		"putfield":         parser.MethodHandleRefPutField,
+		"putstatic":        parser.MethodHandleRefPutStatic,
+		"invokevirtual":    parser.MethodHandleRefInvokeVirtual,
+		"invokestatic":     parser.MethodHandleRefInvokeStatic,
+		"invokespecial":    parser.MethodHandleRefInvokeSpecial,
+		"newinvokespecial": parser.MethodHandleRefNewInvokeSpecial,
+		"invokeinterface":  parser.MethodHandleRefInvokeInterface,
+	}
+	verificationTypes = map[string]parser.VerificationType{
+		"Top":               parser.VerificationTop,
+		"Integer":           parser.VerificationInteger,
+		"Float":             parser.VerificationFloat,
+		"Double":            parser.VerificationDouble,
+		"Long":              parser.VerificationLong,
+		"Null":              parser.VerificationNull,
+		"UninitializedThis": parser.VerificationUninitializedThis,
+		"Object":            parser.VerificationObject,
+		"Uninitialized":     parser.VerificationUninitialized,
+	}
+)
+
+// Assemble reads a class in the text format and builds the class file.
+// Errors in the source are reported as *Error.
+func Assemble(r io.Reader) (*parser.ClassFile, error) {
+	a := &assembler{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		a.line++
+		tokens, err := tokenize(scanner.Text())
+		if err == nil {
+			err = a.statement(&tokenReader{tokens: tokens})
+		}
+		if err != nil {
+			return nil, a.error(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	c, err := a.finish()
+	if err != nil {
+		return nil, a.error(err)
+	}
+	return c, nil
+}
+
+// error positions err at the current line unless it is already positioned.
+func (a *assembler) error(err error) error {
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	return &Error{a.line, err}
+}
+
+type assembler struct {
+	line int
+
+	version      bool
+	major, minor uint16
+	source       string
+	signature    string
+
+	b    *parser.ClassBuilder
+	pool *parser.ConstantPoolBuilder
+	// constantRefs are the indexes .const entries refer to, checked once all are known.
+	constantRefs []constantRef
+	// bootstrap are the bootstrap methods in the order of their .bootstrap directives.
+	bootstrap []parser.BootstrapMethod
+	// bootstrapUse is one more than the highest bootstrap method index used, at bootstrapUseLine.
+	bootstrapUse     int
+	bootstrapUseLine int
+
+	f *field
+	m *method
+}
+
+type (
+	constantRef struct {
+		line  int
+		index uint16
+	}
+
+	// field is a field whose .attribute lines are read until .end field or another directive.
+	field struct {
+		flags      parser.FieldAccessFlags
+		name       string
+		descriptor string
+		attributes []parser.Attribute
+	}
+
+	method struct {
+		flags      parser.MethodAccessFlags
+		name       string
+		descriptor string
+		signature  string
+		throws     []string
+		attributes []parser.Attribute
+		// codeAttributes are the raw attributes of the Code attribute.
+		codeAttributes []parser.Attribute
+		// maxStack and maxLocals are -1 until set by .limit.
+		maxStack  int
+		maxLocals int
+
+		code    []byte
+		labels  map[string]int
+		fixups  []fixup
+		catches []catch
+		lines   []parser.LineNumberTableEntry
+		vars    []localVar
+		frames  []frame
+
+		// sw and stack are the open tableswitch/lookupswitch and .stack blocks.
+		sw    *switchBlock
+		stack *frame
+	}
+
+	// fixup is a branch offset at code[at:at+size] to the label, relative to the instruction at base.
+	fixup struct {
+		line     int
+		at, base int
+		size     int
+		label    string
+	}
+
+	catch struct {
+		line                int
+		class               string
+		start, end, handler string
+	}
+
+	localVar struct {
+		line       int
+		index      uint16
+		name       string
+		descriptor string
+		signature  string
+		start, end string
+	}
+
+	frame struct {
+		line          int
+		offset        int
+		locals, stack []verification
+	}
+
+	verification struct {
+		tag   parser.VerificationType
+		class string
+		label string
+	}
+
+	switchBlock struct {
+		opcode parser.Opcode
+		offset int
+		keys   []int32
+		labels []string
+	}
+)
+
+func (a *assembler) statement(t *tokenReader) error {
+	if t.done() {
+		return nil
+	}
+	if a.m != nil {
+		return a.methodStatement(t)
+	}
+	directive, _ := t.next("directive")
+	if a.f != nil {
+		switch directive {
+		case ".attribute":
+			attribute, err := a.rawAttribute(t)
+			if err != nil {
+				return err
+			}
+			a.f.attributes = append(a.f.attributes, attribute)
+			return t.end()
+		case ".end":
+			if err := t.expect("field"); err != nil {
+				return err
+			}
+			a.endField()
+			return t.end()
+		}
+		a.endField()
+	}
+	switch directive {
+	case ".version":
+		major, err := t.int("major version", 16)
+		if err != nil {
+			return err
+		}
+		var minor int64
+		if !t.done() {
+			if minor, err = t.int("minor version", 16); err != nil {
+				return err
+			}
+		}
+		a.version, a.major, a.minor = true, uint16(major), uint16(minor)
+		return t.end()
+	case ".source":
+		source, err := t.next("source file")
+		if err != nil {
+			return err
+		}
+		a.source = source
+		return t.end()
+	case ".const":
+		if a.b != nil {
+			return fmt.Errorf(".const after .class")
+		}
+		return a.constantEntry(t)
+	case ".class":
+		if a.b != nil {
+			return fmt.Errorf("duplicate .class")
+		}
+		var flags parser.AccessFlags
+		for f, ok := parser.ClassAccessFlag(t.peek()); ok; f, ok = parser.ClassAccessFlag(t.peek()) {
+			flags |= f
+			t.pos++
+		}
+		name, err := t.next("class name")
+		if err != nil {
+			return err
+		}
+		if a.pool == nil {
+			a.pool = parser.NewConstantPoolBuilder()
+		}
+		for _, ref := range a.constantRefs {
+			if int(ref.index) > len(a.pool.ConstantPool()) {
+				return &Error{ref.line, fmt.Errorf("constant #%d is not defined", ref.index)}
+			}
+		}
+		a.b = parser.NewClassBuilderWithPool(name, a.pool).Access(flags).Super("")
+		return t.end()
+	}
+	if a.b == nil {
+		return fmt.Errorf("%s before .class", directive)
+	}
+	switch directive {
+	case ".super":
+		name, err := t.next("super class")
+		if err != nil {
+			return err
+		}
+		a.b.Super(name)
+	case ".implements":
+		for !t.done() {
+			name, _ := t.next("interface")
+			a.b.Implements(name)
+		}
+	case ".signature":
+		signature, err := t.next("signature")
+		if err != nil {
+			return err
+		}
+		a.signature = signature
+	case ".bootstrap":
+		handle, err := a.methodHandle(t)
+		if err != nil {
+			return err
+		}
+		m := parser.BootstrapMethod{BootstrapMethodRef: handle, BootstrapArguments: []uint16{}}
+		for !t.done() {
+			arg, err := a.constant(t, 'I')
+			if err != nil {
+				return err
+			}
+			m.BootstrapArguments = append(m.BootstrapArguments, arg)
+		}
+		a.bootstrap = append(a.bootstrap, m)
+	case ".attribute":
+		attribute, err := a.rawAttribute(t)
+		if err != nil {
+			return err
+		}
+		a.b.AddAttribute(attribute)
+	case ".field":
+		return a.field(t)
+	case ".method":
+		var flags parser.MethodAccessFlags
+		for f, ok := parser.MethodAccessFlag(t.peek()); ok; f, ok = parser.MethodAccessFlag(t.peek()) {
+			flags |= f
+			t.pos++
+		}
+		s, err := t.next("method name and descriptor")
+		if err != nil {
+			return err
+		}
+		i := strings.IndexByte(s, '(')
+		if i <= 0 {
+			return fmt.Errorf("invalid method %q", s)
+		}
+		a.m = &method{flags: flags, name: s[:i], descriptor: s[i:], maxStack: -1, maxLocals: -1, labels: map[string]int{}}
+	default:
+		return fmt.Errorf("unknown directive %s", directive)
+	}
+	return t.end()
+}
+
+func (a *assembler) field(t *tokenReader) error {
+	var flags parser.FieldAccessFlags
+	for f, ok := parser.FieldAccessFlag(t.peek()); ok; f, ok = parser.FieldAccessFlag(t.peek()) {
+		flags |= f
+		t.pos++
+	}
+	name, err := t.next("field name")
+	if err != nil {
+		return err
+	}
+	desc, err := t.next("field descriptor")
+	if err != nil {
+		return err
+	}
+	attributes := []parser.Attribute{}
+	if t.keyword("signature") {
+		signature, err := t.next("signature")
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, a.b.Signature(signature))
+	}
+	if t.keyword("=") {
+		index, err := a.constant(t, desc[0])
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, &parser.ConstantValueAttribute{AttributeHeader: a.header("ConstantValue"), ConstantValueIndex: index})
+	}
+	a.f = &field{flags, name, desc, attributes}
+	return t.end()
+}
+
+func (a *assembler) endField() {
+	a.b.AddField(a.f.flags, a.f.name, a.f.descriptor, a.f.attributes...)
+	a.f = nil
+}
+
+// rawAttribute reads an attribute as its name and the hex of its bytes.
+func (a *assembler) rawAttribute(t *tokenReader) (parser.Attribute, error) {
+	name, err := t.next("attribute name")
+	if err != nil {
+		return nil, err
+	}
+	s, err := t.next("attribute bytes")
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attribute bytes %q", s)
+	}
+	return &parser.AttributeInfo{NameIndex: a.pool.Utf8(name), Attribute: data}, nil
+}
+
+// constantEntry reads a .const directive, which adds a constant pool entry at the next index as is.
+func (a *assembler) constantEntry(t *tokenReader) error {
+	if a.pool == nil {
+		a.pool = parser.NewConstantPoolBuilder()
+	}
+	index, err := t.int("constant index", 17)
+	if err != nil {
+		return err
+	}
+	if next := len(a.pool.ConstantPool()) + 1; index != int64(next) {
+		return fmt.Errorf("constant #%d is not at the next index #%d", index, next)
+	}
+	kind, err := t.next("constant kind")
+	if err != nil {
+		return err
+	}
+	// refs reads the indexes of the entries the constant refers to.
+	refs := func(n int) ([]uint16, error) {
+		indexes := make([]uint16, n)
+		for i := range indexes {
+			v, err := t.int("constant index", 17)
+			if err != nil {
+				return nil, err
+			}
+			if v <= 0 || v > math.MaxUint16 {
+				return nil, fmt.Errorf("invalid constant index %d", v)
+			}
+			indexes[i] = uint16(v)
+			a.constantRefs = append(a.constantRefs, constantRef{a.line, uint16(v)})
+		}
+		return indexes, nil
+	}
+	var info parser.ConstantInfo
+	switch kind {
+	case "Utf8":
+		s, err := t.next("string")
+		if err != nil {
+			return err
+		}
+		info = parser.ConstantUtf8Info{Bytes: parser.EncodeModifiedUTF8(s)}
+	case "Integer":
+		v, err := t.int("int", 32)
+		if err != nil {
+			return err
+		}
+		info = parser.ConstantIntegerInfo{Value: int32(v)}
+	case "Long":
+		v, err := t.int("long", 64)
+		if err != nil {
+			return err
+		}
+		info = parser.ConstantLongInfo{Value: v}
+	case "Float":
+		s, _ := t.next("float")
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return fmt.Errorf("invalid float %q", s)
+		}
+		info = parser.ConstantFloatInfo{Value: float32(v)}
+	case "Double":
+		s, _ := t.next("double")
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid double %q", s)
+		}
+		info = parser.ConstantDoubleInfo{Value: v}
+	case "MethodHandle":
+		s, err := t.next("method handle kind")
+		if err != nil {
+			return err
+		}
+		handleKind, ok := handleKinds[s]
+		if !ok {
+			return fmt.Errorf("unknown method handle kind %q", s)
+		}
+		r, err := refs(1)
+		if err != nil {
+			return err
+		}
+		info = parser.ConstantMethodHandleInfo{ReferenceKind: handleKind, ReferenceIndex: r[0]}
+	case "Class", "String", "MethodType", "Module", "Package":
+		r, err := refs(1)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case "Class":
+			info = parser.ConstantClassInfo{NameIndex: r[0]}
+		case "String":
+			info = parser.ConstantStringInfo{StringIndex: r[0]}
+		case "MethodType":
+			info = parser.ConstantMethodTypeInfo{DescriptorIndex: r[0]}
+		case "Module":
+			info = parser.ConstantModuleInfo{NameIndex: r[0]}
+		default:
+			info = parser.ConstantPackageInfo{NameIndex: r[0]}
+		}
+	case "Fieldref", "Methodref", "InterfaceMethodref", "NameAndType":
+		r, err := refs(2)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case "Fieldref":
+			info = parser.ConstantFieldrefInfo{ClassIndex: r[0], NameAndTypeIndex: r[1]}
+		case "Methodref":
+			info = parser.ConstantMethodrefInfo{ClassIndex: r[0], NameAndTypeIndex: r[1]}
+		case "InterfaceMethodref":
+			info = parser.ConstantInterfaceMethodrefInfo{ClassIndex: r[0], NameAndTypeIndex: r[1]}
+		default:
+			info = parser.ConstantNameAndTypeInfo{NameIndex: r[0], DescriptorIndex: r[1]}
+		}
+	case "Dynamic", "InvokeDynamic":
+		// The bootstrap method index is not a constant pool index.
+		bootstrap, err := a.bootstrapIndex(t)
+		if err != nil {
+			return err
+		}
+		r, err := refs(1)
+		if err != nil {
+			return err
+		}
+		if kind == "Dynamic" {
+			info = parser.ConstantDynamicInfo{BootstrapMethodAttrIndex: bootstrap, NameAndTypeIndex: r[0]}
+		} else {
+			info = parser.ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: bootstrap, NameAndTypeIndex: r[0]}
+		}
+	default:
+		return fmt.Errorf("unknown constant kind %q", kind)
+	}
+	a.pool.Add(info)
+	if err := a.pool.Err(); err != nil {
+		return err
+	}
+	return t.end()
+}
+
+func (a *assembler) header(name string) parser.AttributeHeader {
+	return parser.AttributeHeader{NameIndex: a.pool.Utf8(name)}
+}
+
+// constant reads a constant literal and returns its constant pool index.
+// hint is the descriptor of a field, or 'W' for ldc2_w, and tells the type of numbers without a suffix.
+func (a *assembler) constant(t *tokenReader, hint byte) (uint16, error) {
+	if !t.done() && t.tokens[t.pos].quoted {
+		s, _ := t.next("constant")
+		return a.pool.String(s), nil
+	}
+	s, err := t.next("constant")
+	if err != nil {
+		return 0, err
+	}
+	switch s {
+	case "class":
+		name, err := t.next("class name")
+		if err != nil {
+			return 0, err
+		}
+		return a.pool.Class(name), nil
+	case "methodtype":
+		desc, err := t.next("method descriptor")
+		if err != nil {
+			return 0, err
+		}
+		return a.pool.MethodType(desc), nil
+	case "methodhandle":
+		return a.methodHandle(t)
+	case "dynamic":
+		bootstrap, err := a.bootstrapIndex(t)
+		if err != nil {
+			return 0, err
+		}
+		name, err := t.next("name")
+		if err != nil {
+			return 0, err
+		}
+		desc, err := t.next("descriptor")
+		if err != nil {
+			return 0, err
+		}
+		return a.pool.Dynamic(bootstrap, name, desc), nil
+	}
+	return a.number(s, hint)
+}
+
+func (a *assembler) number(s string, hint byte) (uint16, error) {
+	body, kind := s, hint
+	switch s[len(s)-1] {
+	case 'L', 'l':
+		body, kind = s[:len(s)-1], 'J'
+	case 'F', 'f':
+		body, kind = s[:len(s)-1], 'F'
+	case 'D', 'd':
+		body, kind = s[:len(s)-1], 'D'
+	default:
+		isFloat := strings.ContainsAny(s, ".eEnN")
+		switch {
+		case hint == 'W' && isFloat:
+			kind = 'D'
+		case hint == 'W':
+			kind = 'J'
+		case hint == 'J' || hint == 'F' || hint == 'D':
+		case isFloat:
+			kind = 'F'
+		default:
+			kind = 'I'
+		}
+	}
+	switch kind {
+	case 'J':
+		v, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid long %q", s)
+		}
+		return a.pool.Long(v), nil
+	case 'F':
+		v, err := strconv.ParseFloat(body, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid float %q", s)
+		}
+		f := float32(v)
+		if math.IsNaN(v) {
+			f = math.Float32frombits(0x7fc00000)
+		}
+		return a.pool.Float(f), nil
+	case 'D':
+		v, err := strconv.ParseFloat(body, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid double %q", s)
+		}
+		if math.IsNaN(v) {
+			v = math.Float64frombits(0x7ff8000000000000)
+		}
+		return a.pool.Double(v), nil
+	}
+	v, err := strconv.ParseInt(body, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid constant %q", s)
+	}
+	return a.pool.Integer(int32(v)), nil
+}
+
+// bootstrapIndex reads the index of a bootstrap method, which is checked once all are known.
+func (a *assembler) bootstrapIndex(t *tokenReader) (uint16, error) {
+	bootstrap, err := t.int("bootstrap method index", 17)
+	if err != nil {
+		return 0, err
+	}
+	if bootstrap < 0 || bootstrap > math.MaxUint16 {
+		return 0, fmt.Errorf("invalid bootstrap method index %d", bootstrap)
+	}
+	if int(bootstrap) >= a.bootstrapUse {
+		a.bootstrapUse, a.bootstrapUseLine = int(bootstrap)+1, a.line
+	}
+	return uint16(bootstrap), nil
+}
+
+// methodHandle reads a method handle as its kind followed by the field or method reference.
+func (a *assembler) methodHandle(t *tokenReader) (uint16, error) {
+	s, err := t.next("method handle kind")
+	if err != nil {
+		return 0, err
+	}
+	kind, ok := handleKinds[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown method handle kind %q", s)
+	}
+	var ref uint16
+	if kind <= parser.MethodHandleRefPutStatic {
+		ref, err = a.fieldref(t)
+	} else {
+		ref, _, err = a.methodref(t, kind == parser.MethodHandleRefInvokeInterface)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return a.pool.MethodHandle(kind, ref), nil
+}
+
+func (a *assembler) fieldref(t *tokenReader) (uint16, error) {
+	s, err := t.next("field reference")
+	if err != nil {
+		return 0, err
+	}
+	owner, name, _, err := splitMember(s)
+	if err != nil {
+		return 0, err
+	}
+	desc, err := t.next("field descriptor")
+	if err != nil {
+		return 0, err
+	}
+	return a.pool.Fieldref(owner, name, desc), nil
+}
+
+// methodref reads an owner/name(descriptor) reference, of an interface method if preceded by interface.
+func (a *assembler) methodref(t *tokenReader, iface bool) (uint16, string, error) {
+	if t.keyword("interface") {
+		iface = true
+	}
+	s, err := t.next("method reference")
+	if err != nil {
+		return 0, "", err
+	}
+	owner, name, desc, err := splitMember(s)
+	if err != nil {
+		return 0, "", err
+	}
+	if desc == "" {
+		return 0, "", fmt.Errorf("missing method descriptor in %q", s)
+	}
+	if iface {
+		return a.pool.InterfaceMethodref(owner, name, desc), desc, nil
+	}
+	return a.pool.Methodref(owner, name, desc), desc, nil
+}
+
+func (a *assembler) methodStatement(t *tokenReader) error {
+	m := a.m
+	if m.sw != nil {
+		return a.switchCase(t)
+	}
+	if m.stack != nil {
+		return a.frameStatement(t)
+	}
+	if s := t.peek(); len(s) > 1 && strings.HasSuffix(s, ":") && !strings.HasPrefix(s, ".") {
+		label := s[:len(s)-1]
+		if _, ok := m.labels[label]; ok {
+			return fmt.Errorf("duplicate label %s", label)
+		}
+		m.labels[label] = len(m.code)
+		t.pos++
+		if t.done() {
+			return nil
+		}
+	}
+	s, err := t.next("instruction")
+	if err != nil {
+		return err
+	}
+	switch s {
+	case ".limit":
+		what, err := t.next("stack or locals")
+		if err != nil {
+			return err
+		}
+		n, err := t.int(what+" limit", 17)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > math.MaxUint16 {
+			return fmt.Errorf("invalid %s limit %d", what, n)
+		}
+		switch what {
+		case "stack":
+			m.maxStack = int(n)
+		case "locals":
+			m.maxLocals = int(n)
+		default:
+			return fmt.Errorf("unknown limit %q", what)
+		}
+	case ".throws":
+		for !t.done() {
+			name, _ := t.next("exception")
+			m.throws = append(m.throws, name)
+		}
+	case ".signature":
+		signature, err := t.next("signature")
+		if err != nil {
+			return err
+		}
+		m.signature = signature
+	case ".attribute", ".codeattribute":
+		attribute, err := a.rawAttribute(t)
+		if err != nil {
+			return err
+		}
+		if s == ".attribute" {
+			m.attributes = append(m.attributes, attribute)
+		} else {
+			m.codeAttributes = append(m.codeAttributes, attribute)
+		}
+	case ".catch":
+		c := catch{line: a.line}
+		if c.class, err = t.next("exception class"); err != nil {
+			return err
+		}
+		if c.class == "all" {
+			c.class = ""
+		}
+		if err := t.expect("from"); err != nil {
+			return err
+		}
+		c.start, _ = t.next("start label")
+		if err := t.expect("to"); err != nil {
+			return err
+		}
+		c.end, _ = t.next("end label")
+		if err := t.expect("using"); err != nil {
+			return err
+		}
+		if c.handler, err = t.next("handler label"); err != nil {
+			return err
+		}
+		m.catches = append(m.catches, c)
+	case ".line":
+		n, err := t.int("line number", 17)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > math.MaxUint16 {
+			return fmt.Errorf("invalid line number %d", n)
+		}
+		m.lines = append(m.lines, parser.LineNumberTableEntry{StartPC: uint16(len(m.code)), LineNumber: uint16(n)})
+	case ".var":
+		return a.localVar(t)
+	case ".stack":
+		m.stack = &frame{line: a.line, offset: len(m.code)}
+	case ".end":
+		if err := t.expect("method"); err != nil {
+			return err
+		}
+		if err := t.end(); err != nil {
+			return err
+		}
+		return a.endMethod()
+	default:
+		if strings.HasPrefix(s, ".") {
+			return fmt.Errorf("unknown directive %s in method", s)
+		}
+		return a.instruction(s, t)
+	}
+	return t.end()
+}
+
+func (a *assembler) localVar(t *tokenReader) error {
+	v := localVar{line: a.line}
+	index, err := t.int("local variable index", 17)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index > math.MaxUint16 {
+		return fmt.Errorf("invalid local variable index %d", index)
+	}
+	v.index = uint16(index)
+	if err := t.expect("is"); err != nil {
+		return err
+	}
+	if v.name, err = t.next("local variable name"); err != nil {
+		return err
+	}
+	if v.descriptor, err = t.next("local variable descriptor"); err != nil {
+		return err
+	}
+	if t.keyword("signature") {
+		if v.signature, err = t.next("signature"); err != nil {
+			return err
+		}
+	}
+	if err := t.expect("from"); err != nil {
+		return err
+	}
+	v.start, _ = t.next("start label")
+	if err := t.expect("to"); err != nil {
+		return err
+	}
+	if v.end, err = t.next("end label"); err != nil {
+		return err
+	}
+	a.m.vars = append(a.m.vars, v)
+	return t.end()
+}
+
+func (m *method) u1(v uint8) {
+	m.code = append(m.code, v)
+}
+
+func (m *method) u2(v uint16) {
+	m.code = append(m.code, byte(v>>8), byte(v))
+}
+
+func (m *method) u4(v uint32) {
+	m.code = append(m.code, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// branch adds a branch offset of size bytes to be resolved at the end of the method.
+func (a *assembler) branch(t *tokenReader, base, size int) error {
+	label, err := t.next("label")
+	if err != nil {
+		return err
+	}
+	m := a.m
+	m.fixups = append(m.fixups, fixup{a.line, len(m.code), base, size, label})
+	m.code = append(m.code, make([]byte, size)...)
+	return nil
+}
+
+func (a *assembler) instruction(name string, t *tokenReader) error {
+	op, ok := parser.OpcodeByName(name)
+	if !ok {
+		return fmt.Errorf("unknown instruction %s", name)
+	}
+	m := a.m
+	start := len(m.code)
+	switch op.OperandKind() {
+	case parser.OperandNone:
+		m.u1(uint8(op))
+	case parser.OperandByte:
+		v, err := t.int("byte", 8)
+		if err != nil {
+			return err
+		}
+		m.u1(uint8(op))
+		m.u1(uint8(v))
+	case parser.OperandShort:
+		v, err := t.int("short", 16)
+		if err != nil {
+			return err
+		}
+		m.u1(uint8(op))
+		m.u2(uint16(v))
+	case parser.OperandLocal:
+		index, err := t.int("local variable index", 17)
+		if err != nil {
+			return err
+		}
+		switch {
+		case index < 0 || index > math.MaxUint16:
+			return fmt.Errorf("invalid local variable index %d", index)
+		case index > math.MaxUint8:
+			m.u1(uint8(parser.OpcodeWide))
+			m.u1(uint8(op))
+			m.u2(uint16(index))
+		default:
+			m.u1(uint8(op))
+			m.u1(uint8(index))
+		}
+	case parser.OperandConstant8:
+		index, err := a.constant(t, 'I')
+		if err != nil {
+			return err
+		}
+		if index > math.MaxUint8 {
+			m.u1(uint8(parser.OpcodeLdcW))
+			m.u2(index)
+		} else {
+			m.u1(uint8(op))
+			m.u1(uint8(index))
+		}
+	case parser.OperandConstant16:
+		var index uint16
+		var err error
+		switch op {
+		case parser.OpcodeLdcW:
+			index, err = a.constant(t, 'I')
+		case parser.OpcodeLdc2W:
+			index, err = a.constant(t, 'W')
+		case parser.OpcodeGetstatic, parser.OpcodePutstatic, parser.OpcodeGetfield, parser.OpcodePutfield:
+			index, err = a.fieldref(t)
+		case parser.OpcodeInvokevirtual, parser.OpcodeInvokespecial, parser.OpcodeInvokestatic:
+			index, _, err = a.methodref(t, false)
+		default:
+			var class string
+			if class, err = t.next("class name"); err == nil {
+				index = a.pool.Class(class)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		m.u1(uint8(op))
+		m.u2(index)
+	case parser.OperandBranch16:
+		m.u1(uint8(op))
+		if err := a.branch(t, start, 2); err != nil {
+			return err
+		}
+	case parser.OperandBranch32:
+		m.u1(uint8(op))
+		if err := a.branch(t, start, 4); err != nil {
+			return err
+		}
+	case parser.OperandIinc:
+		index, err := t.int("local variable index", 17)
+		if err != nil {
+			return err
+		}
+		if index < 0 || index > math.MaxUint16 {
+			return fmt.Errorf("invalid local variable index %d", index)
+		}
+		inc, err := t.int("increment", 16)
+		if err != nil {
+			return err
+		}
+		if index > math.MaxUint8 || inc < math.MinInt8 || inc > math.MaxInt8 {
+			m.u1(uint8(parser.OpcodeWide))
+			m.u1(uint8(op))
+			m.u2(uint16(index))
+			m.u2(uint16(inc))
+		} else {
+			m.u1(uint8(op))
+			m.u1(uint8(index))
+			m.u1(uint8(inc))
+		}
+	case parser.OperandInvokeInterface:
+		index, desc, err := a.methodref(t, true)
+		if err != nil {
+			return err
+		}
+		var count int64
+		if t.done() {
+			method, err := descriptor.ParseMethod(desc)
+			if err != nil {
+				return err
+			}
+			count = int64(method.ParameterSlots() + 1)
+		} else if count, err = t.int("count", 16); err != nil {
+			return err
+		}
+		if count <= 0 || count > math.MaxUint8 {
+			return fmt.Errorf("invalid invokeinterface count %d", count)
+		}
+		m.u1(uint8(op))
+		m.u2(index)
+		m.u1(uint8(count))
+		m.u1(0)
+	case parser.OperandInvokeDynamic:
+		bootstrap, err := a.bootstrapIndex(t)
+		if err != nil {
+			return err
+		}
+		s, err := t.next("name and descriptor")
+		if err != nil {
+			return err
+		}
+		i := strings.IndexByte(s, '(')
+		if i <= 0 {
+			return fmt.Errorf("invalid name and descriptor %q", s)
+		}
+		m.u1(uint8(op))
+		m.u2(a.pool.InvokeDynamic(bootstrap, s[:i], s[i:]))
+		m.u2(0)
+	case parser.OperandMultiANewArray:
+		class, err := t.next("class name")
+		if err != nil {
+			return err
+		}
+		dimensions, err := t.int("dimensions", 16)
+		if err != nil {
+			return err
+		}
+		if dimensions <= 0 || dimensions > math.MaxUint8 {
+			return fmt.Errorf("invalid dimensions %d", dimensions)
+		}
+		m.u1(uint8(op))
+		m.u2(a.pool.Class(class))
+		m.u1(uint8(dimensions))
+	case parser.OperandNewArray:
+		s, err := t.next("array type")
+		if err != nil {
+			return err
+		}
+		arrayType := parser.ArrayTypeBoolean
+		for arrayType <= parser.ArrayTypeLong && arrayType.String() != s {
+			arrayType++
+		}
+		if arrayType > parser.ArrayTypeLong {
+			return fmt.Errorf("unknown array type %q", s)
+		}
+		m.u1(uint8(op))
+		m.u1(uint8(arrayType))
+	case parser.OperandTableSwitch, parser.OperandLookupSwitch:
+		m.sw = &switchBlock{opcode: op, offset: start}
+		m.u1(uint8(op))
+	default:
+		return fmt.Errorf("%s is added automatically to instructions which need it", name)
+	}
+	return t.end()
+}
+
+// switchCase reads a key : label line of a switch, emitting the switch at its default : label line.
+func (a *assembler) switchCase(t *tokenReader) error {
+	texts := make([]string, len(t.tokens))
+	for i, token := range t.tokens {
+		texts[i] = token.text
+	}
+	parts := strings.SplitN(strings.Join(texts, ""), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return fmt.Errorf("expected key : label or default : label")
+	}
+	m := a.m
+	sw := m.sw
+	if parts[0] != "default" {
+		key, err := strconv.ParseInt(parts[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid switch key %q", parts[0])
+		}
+		sw.keys = append(sw.keys, int32(key))
+		sw.labels = append(sw.labels, parts[1])
+		return nil
+	}
+
+	m.sw = nil
+	for len(m.code)%4 != 0 {
+		m.u1(0)
+	}
+	m.fixups = append(m.fixups, fixup{a.line, len(m.code), sw.offset, 4, parts[1]})
+	m.u4(0)
+	addCase := func(label string) {
+		m.fixups = append(m.fixups, fixup{a.line, len(m.code), sw.offset, 4, label})
+		m.u4(0)
+	}
+	if sw.opcode == parser.OpcodeTableswitch {
+		if len(sw.keys) == 0 {
+			return fmt.Errorf("tableswitch without cases")
+		}
+		for i, key := range sw.keys {
+			if key != sw.keys[0]+int32(i) {
+				return fmt.Errorf("tableswitch keys must be consecutive, got %d after %d", key, sw.keys[i-1])
+			}
+		}
+		m.u4(uint32(sw.keys[0]))
+		m.u4(uint32(sw.keys[len(sw.keys)-1]))
+		for _, label := range sw.labels {
+			addCase(label)
+		}
+		return nil
+	}
+	order := make([]int, len(sw.keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return sw.keys[order[i]] < sw.keys[order[j]] })
+	m.u4(uint32(len(order)))
+	for i, j := range order {
+		if i > 0 && sw.keys[j] == sw.keys[order[i-1]] {
+			return fmt.Errorf("duplicate lookupswitch key %d", sw.keys[j])
+		}
+		m.u4(uint32(sw.keys[j]))
+		addCase(sw.labels[j])
+	}
+	return nil
+}
+
+func (a *assembler) frameStatement(t *tokenReader) error {
+	m := a.m
+	s, _ := t.next("locals or stack")
+	var types *[]verification
+	switch s {
+	case ".end":
+		if err := t.expect("stack"); err != nil {
+			return err
+		}
+		m.frames = append(m.frames, *m.stack)
+		m.stack = nil
+		return t.end()
+	case "locals":
+		types = &m.stack.locals
+	case "stack":
+		types = &m.stack.stack
+	default:
+		return fmt.Errorf("expected locals, stack or .end stack in .stack")
+	}
+	for !t.done() {
+		s, _ := t.next("verification type")
+		tag, ok := verificationTypes[s]
+		if !ok {
+			return fmt.Errorf("unknown verification type %q", s)
+		}
+		v := verification{tag: tag}
+		var err error
+		switch tag {
+		case parser.VerificationObject:
+			v.class, err = t.next("class name")
+		case parser.VerificationUninitialized:
+			v.label, err = t.next("label")
+		}
+		if err != nil {
+			return err
+		}
+		*types = append(*types, v)
+	}
+	return nil
+}
+
+func (a *assembler) label(name string, line int) (uint16, error) {
+	offset, ok := a.m.labels[name]
+	if !ok {
+		return 0, &Error{line, fmt.Errorf("undefined label %s", name)}
+	}
+	return uint16(offset), nil
+}
+
+func (a *assembler) endMethod() error {
+	m := a.m
+	if m.sw != nil {
+		return fmt.Errorf("switch without default")
+	}
+	if m.stack != nil {
+		return fmt.Errorf(".stack without .end stack")
+	}
+	attributes := []parser.Attribute{}
+	if len(m.code) > 0 || m.flags&(parser.MethodAccessAbstract|parser.MethodAccessNative) == 0 {
+		code, err := a.code(m)
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, code)
+	}
+	if len(m.throws) > 0 {
+		attributes = append(attributes, a.b.Exceptions(m.throws...))
+	}
+	if m.signature != "" {
+		attributes = append(attributes, a.b.Signature(m.signature))
+	}
+	attributes = append(attributes, m.attributes...)
+	a.b.AddMethod(m.flags, m.name, m.descriptor, attributes...)
+	a.m = nil
+	return nil
+}
+
+func (a *assembler) code(m *method) (*parser.CodeAttribute, error) {
+	if len(m.code) > math.MaxUint16 {
+		return nil, fmt.Errorf("code of %s is too large: %d bytes", m.name, len(m.code))
+	}
+	for _, f := range m.fixups {
+		target, err := a.label(f.label, f.line)
+		if err != nil {
+			return nil, err
+		}
+		offset := int(target) - f.base
+		if f.size == 2 {
+			if offset < math.MinInt16 || offset > math.MaxInt16 {
+				return nil, &Error{f.line, fmt.Errorf("branch to %s is out of range", f.label)}
+			}
+			m.code[f.at], m.code[f.at+1] = byte(offset>>8), byte(offset)
+		} else {
+			m.code[f.at], m.code[f.at+1], m.code[f.at+2], m.code[f.at+3] = byte(offset>>24), byte(offset>>16), byte(offset>>8), byte(offset)
+		}
+	}
+
+	maxLocals := m.maxLocals
+	if maxLocals < 0 {
+		method, err := descriptor.ParseMethod(m.descriptor)
+		if err != nil {
+			return nil, err
+		}
+		maxLocals = method.ParameterSlots()
+		if m.flags&parser.MethodAccessStatic == 0 {
+			maxLocals++
+		}
+	}
+	if m.maxStack < 0 {
+		return nil, fmt.Errorf("missing .limit stack of %s", m.name)
+	}
+
+	var attributes []parser.Attribute
+	if len(m.lines) > 0 {
+		attributes = append(attributes, &parser.LineNumberTableAttribute{AttributeHeader: a.header("LineNumberTable"), LineNumberTable: m.lines})
+	}
+	if len(m.vars) > 0 {
+		vars := &parser.LocalVariableTableAttribute{AttributeHeader: a.header("LocalVariableTable")}
+		var types []parser.LocalVariableTypeTableEntry
+		for _, v := range m.vars {
+			start, err := a.label(v.start, v.line)
+			if err != nil {
+				return nil, err
+			}
+			end, err := a.label(v.end, v.line)
+			if err != nil {
+				return nil, err
+			}
+			name := a.pool.Utf8(v.name)
+			vars.LocalVariableTable = append(vars.LocalVariableTable, parser.LocalVariableTableEntry{
+				StartPC: start, Length: end - start, NameIndex: name, DescriptorIndex: a.pool.Utf8(v.descriptor), Index: v.index,
+			})
+			if v.signature != "" {
+				types = append(types, parser.LocalVariableTypeTableEntry{
+					StartPC: start, Length: end - start, NameIndex: name, SignatureIndex: a.pool.Utf8(v.signature), Index: v.index,
+				})
+			}
+		}
+		attributes = append(attributes, vars)
+		if len(types) > 0 {
+			attributes = append(attributes, &parser.LocalVariableTypeTableAttribute{AttributeHeader: a.header("LocalVariableTypeTable"), LocalVariableTypeTable: types})
+		}
+	}
+	if len(m.frames) > 0 {
+		table, err := a.stackMapTable(m)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, table)
+	}
+
+	attributes = append(attributes, m.codeAttributes...)
+	code := a.b.Code(uint16(m.maxStack), uint16(maxLocals), m.code, attributes...)
+	for _, c := range m.catches {
+		var entry parser.ExceptionTableEntry
+		var err error
+		if entry.StartPC, err = a.label(c.start, c.line); err != nil {
+			return nil, err
+		}
+		if entry.EndPC, err = a.label(c.end, c.line); err != nil {
+			return nil, err
+		}
+		if entry.HandlerPC, err = a.label(c.handler, c.line); err != nil {
+			return nil, err
+		}
+		if c.class != "" {
+			entry.CatchType = a.pool.Class(c.class)
+		}
+		code.ExceptionTable = append(code.ExceptionTable, entry)
+	}
+	return code, nil
+}
+
+// stackMapTable encodes the frames as full frames.
+func (a *assembler) stackMapTable(m *method) (*parser.StackMapTableAttribute, error) {
+	table := &parser.StackMapTableAttribute{AttributeHeader: a.header("StackMapTable")}
+	previous := -1
+	for _, f := range m.frames {
+		if f.offset <= previous {
+			return nil, &Error{f.line, fmt.Errorf("stack map frame at offset %d is not after the previous frame", f.offset)}
+		}
+		locals, err := a.verificationTypes(f.locals, f.line)
+		if err != nil {
+			return nil, err
+		}
+		stack, err := a.verificationTypes(f.stack, f.line)
+		if err != nil {
+			return nil, err
+		}
+		table.Entries = append(table.Entries, parser.StackMapFrame{
+			FrameType:   parser.StackMapFullFrame,
+			OffsetDelta: uint16(f.offset - previous - 1),
+			Locals:      locals,
+			Stack:       stack,
+		})
+		previous = f.offset
+	}
+	return table, nil
+}
+
+func (a *assembler) verificationTypes(types []verification, line int) ([]parser.VerificationTypeInfo, error) {
+	infos := make([]parser.VerificationTypeInfo, len(types))
+	for i, v := range types {
+		infos[i].Tag = v.tag
+		switch v.tag {
+		case parser.VerificationObject:
+			infos[i].Index = a.pool.Class(v.class)
+		case parser.VerificationUninitialized:
+			offset, err := a.label(v.label, line)
+			if err != nil {
+				return nil, err
+			}
+			infos[i].Index = offset
+		}
+	}
+	return infos, nil
+}
+
+func (a *assembler) finish() (*parser.ClassFile, error) {
+	if a.b == nil {
+		return nil, fmt.Errorf("missing .class")
+	}
+	if a.m != nil {
+		return nil, fmt.Errorf("missing .end method of %s", a.m.name)
+	}
+	if a.f != nil {
+		a.endField()
+	}
+	if a.bootstrapUse > len(a.bootstrap) {
+		return nil, &Error{a.bootstrapUseLine, fmt.Errorf("undefined bootstrap method %d", a.bootstrapUse-1)}
+	}
+	if a.version {
+		a.b.Version(a.major, a.minor)
+	}
+	if a.source != "" {
+		a.b.AddAttribute(a.b.SourceFile(a.source))
+	}
+	if a.signature != "" {
+		a.b.AddAttribute(a.b.Signature(a.signature))
+	}
+	if len(a.bootstrap) > 0 {
+		a.b.AddAttribute(&parser.BootstrapMethodsAttribute{AttributeHeader: a.header("BootstrapMethods"), BootstrapMethods: a.bootstrap})
+	}
+	return a.b.Build()
+}
@@ -0,0 +1,588 @@
+package asm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"go-javap/descriptor"
+	"go-javap/parser"
+)
+
+// Disassemble writes the class in the text format read by Assemble.
+// Attributes the format has no directive for, like annotations and inner classes,
+// are written raw with their constant pool, so the indexes in them stay valid.
+// Stack map frames are written as full frames.
+func Disassemble(w io.Writer, c *parser.ClassFile) error {
+	var body bytes.Buffer
+	d := &disassembler{w: &body, class: c, pool: c.ConstantPool}
+	d.writeClass()
+	if d.err != nil {
+		return d.err
+	}
+	out := bufio.NewWriter(w)
+	if d.raw {
+		d.w = out
+		d.writeConstantPool()
+		if d.err != nil {
+			return d.err
+		}
+		d.printf("\n")
+	}
+	body.WriteTo(out)
+	return out.Flush()
+}
+
+type disassembler struct {
+	w     io.Writer
+	class *parser.ClassFile
+	pool  parser.ConstantPool
+	// raw is set when an attribute is written raw.
+	raw bool
+	err error
+}
+
+func (d *disassembler) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (d *disassembler) printf(format string, args ...interface{}) {
+	fmt.Fprintf(d.w, format, args...)
+}
+
+func (d *disassembler) entry(index uint16) parser.ConstantInfo {
+	if index == 0 || int(index) > len(d.pool) {
+		d.fail("invalid constant pool index #%d", index)
+		return nil
+	}
+	return d.pool[index-1]
+}
+
+func (d *disassembler) utf8(index uint16) string {
+	if e, ok := d.entry(index).(parser.ConstantUtf8Info); ok {
+		s, _ := e.Value()
+		return s
+	}
+	d.fail("constant #%d is not a Utf8", index)
+	return ""
+}
+
+func (d *disassembler) className(index uint16) string {
+	if e, ok := d.entry(index).(parser.ConstantClassInfo); ok {
+		return d.utf8(e.NameIndex)
+	}
+	d.fail("constant #%d is not a Class", index)
+	return ""
+}
+
+func (d *disassembler) nameAndType(index uint16) (string, string) {
+	if e, ok := d.entry(index).(parser.ConstantNameAndTypeInfo); ok {
+		return d.utf8(e.NameIndex), d.utf8(e.DescriptorIndex)
+	}
+	d.fail("constant #%d is not a NameAndType", index)
+	return "", ""
+}
+
+// member renders a field or method reference. The interface keyword is left out when implied.
+func (d *disassembler) member(index uint16, implied bool) string {
+	var classIndex, nameAndTypeIndex uint16
+	prefix := ""
+	switch e := d.entry(index).(type) {
+	case parser.ConstantFieldrefInfo:
+		classIndex, nameAndTypeIndex = e.ClassIndex, e.NameAndTypeIndex
+		name, desc := d.nameAndType(nameAndTypeIndex)
+		return word(d.className(classIndex)+"/"+name) + " " + word(desc)
+	case parser.ConstantMethodrefInfo:
+		classIndex, nameAndTypeIndex = e.ClassIndex, e.NameAndTypeIndex
+	case parser.ConstantInterfaceMethodrefInfo:
+		classIndex, nameAndTypeIndex = e.ClassIndex, e.NameAndTypeIndex
+		if !implied {
+			prefix = "interface "
+		}
+	default:
+		d.fail("constant #%d is not a member reference", index)
+		return ""
+	}
+	name, desc := d.nameAndType(nameAndTypeIndex)
+	return prefix + word(d.className(classIndex)+"/"+name+desc)
+}
+
+func (d *disassembler) methodHandle(index uint16) string {
+	e, ok := d.entry(index).(parser.ConstantMethodHandleInfo)
+	if !ok {
+		d.fail("constant #%d is not a MethodHandle", index)
+		return ""
+	}
+	kind := strings.ToLower(e.ReferenceKind.String())
+	return kind + " " + d.member(e.ReferenceIndex, e.ReferenceKind == parser.MethodHandleRefInvokeInterface)
+}
+
+// constant renders a loadable constant as a literal.
+func (d *disassembler) constant(index uint16) string {
+	switch e := d.entry(index).(type) {
+	case parser.ConstantIntegerInfo:
+		return strconv.FormatInt(int64(e.Value), 10)
+	case parser.ConstantLongInfo:
+		return strconv.FormatInt(e.Value, 10) + "L"
+	case parser.ConstantFloatInfo:
+		return floatLiteral(float64(e.Value), 32) + "F"
+	case parser.ConstantDoubleInfo:
+		return floatLiteral(e.Value, 64) + "D"
+	case parser.ConstantStringInfo:
+		if _, ok := d.entry(e.StringIndex).(parser.ConstantUtf8Info); ok {
+			return strconv.Quote(d.utf8(e.StringIndex))
+		}
+	case parser.ConstantClassInfo:
+		return "class " + word(d.utf8(e.NameIndex))
+	case parser.ConstantMethodTypeInfo:
+		return "methodtype " + word(d.utf8(e.DescriptorIndex))
+	case parser.ConstantMethodHandleInfo:
+		return "methodhandle " + d.methodHandle(index)
+	case parser.ConstantDynamicInfo:
+		name, desc := d.nameAndType(e.NameAndTypeIndex)
+		return fmt.Sprintf("dynamic %d %s %s", e.BootstrapMethodAttrIndex, word(name), word(desc))
+	}
+	d.fail("constant #%d is not loadable", index)
+	return ""
+}
+
+func floatLiteral(v float64, bitSize int) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	s := strconv.FormatFloat(v, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// word quotes s if it would not be read back as a single token.
+func word(s string) string {
+	if s == "" || s[0] == ';' || s[0] == '"' || strings.ContainsAny(s, " \t\r\n") || !strconv.CanBackquote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// flags renders the names of access flags, each followed by a space.
+func flags(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// rawAttribute writes an attribute as its name and the hex of its bytes with the directive.
+func (d *disassembler) rawAttribute(indent, directive string, a parser.Attribute) {
+	m, ok := a.(encoding.BinaryMarshaler)
+	if !ok {
+		d.fail("attribute %T cannot be encoded", a)
+		return
+	}
+	data, err := m.MarshalBinary()
+	if err != nil {
+		d.fail("attribute %s: %v", d.pool.AttributeName(a), err)
+		return
+	}
+	d.raw = true
+	d.printf("%s%s %s %q\n", indent, directive, word(d.pool.AttributeName(a)), hex.EncodeToString(data))
+}
+
+// writeConstantPool writes the entries of the constant pool in order with their indexes.
+func (d *disassembler) writeConstantPool() {
+	for i := 0; i < len(d.pool); i++ {
+		index := i + 1
+		switch e := d.pool[i].(type) {
+		case parser.ConstantUtf8Info:
+			s, _ := e.Value()
+			d.printf(".const %d Utf8 %s\n", index, strconv.Quote(s))
+		case parser.ConstantIntegerInfo:
+			d.printf(".const %d Integer %d\n", index, e.Value)
+		case parser.ConstantFloatInfo:
+			d.printf(".const %d Float %s\n", index, floatLiteral(float64(e.Value), 32))
+		case parser.ConstantLongInfo:
+			d.printf(".const %d Long %d\n", index, e.Value)
+			i++
+		case parser.ConstantDoubleInfo:
+			d.printf(".const %d Double %s\n", index, floatLiteral(e.Value, 64))
+			i++
+		case parser.ConstantClassInfo:
+			d.printf(".const %d Class %d\n", index, e.NameIndex)
+		case parser.ConstantStringInfo:
+			d.printf(".const %d String %d\n", index, e.StringIndex)
+		case parser.ConstantFieldrefInfo:
+			d.printf(".const %d Fieldref %d %d\n", index, e.ClassIndex, e.NameAndTypeIndex)
+		case parser.ConstantMethodrefInfo:
+			d.printf(".const %d Methodref %d %d\n", index, e.ClassIndex, e.NameAndTypeIndex)
+		case parser.ConstantInterfaceMethodrefInfo:
+			d.printf(".const %d InterfaceMethodref %d %d\n", index, e.ClassIndex, e.NameAndTypeIndex)
+		case parser.ConstantNameAndTypeInfo:
+			d.printf(".const %d NameAndType %d %d\n", index, e.NameIndex, e.DescriptorIndex)
+		case parser.ConstantMethodHandleInfo:
+			d.printf(".const %d MethodHandle %s %d\n", index, strings.ToLower(e.ReferenceKind.String()), e.ReferenceIndex)
+		case parser.ConstantMethodTypeInfo:
+			d.printf(".const %d MethodType %d\n", index, e.DescriptorIndex)
+		case parser.ConstantDynamicInfo:
+			d.printf(".const %d Dynamic %d %d\n", index, e.BootstrapMethodAttrIndex, e.NameAndTypeIndex)
+		case parser.ConstantInvokeDynamicInfo:
+			d.printf(".const %d InvokeDynamic %d %d\n", index, e.BootstrapMethodAttrIndex, e.NameAndTypeIndex)
+		case parser.ConstantModuleInfo:
+			d.printf(".const %d Module %d\n", index, e.NameIndex)
+		case parser.ConstantPackageInfo:
+			d.printf(".const %d Package %d\n", index, e.NameIndex)
+		default:
+			d.fail("constant #%d cannot be written: %v", index, e)
+		}
+	}
+}
+
+func (d *disassembler) writeClass() {
+	c := d.class
+	d.printf(".version %d %d\n", c.MajorVersion, c.MinorVersion)
+	for _, a := range c.Attributes {
+		if s, ok := a.(*parser.SourceFileAttribute); ok {
+			d.printf(".source %s\n", word(d.utf8(s.SourceFileIndex)))
+		}
+	}
+	d.printf(".class %s%s\n", flags(c.AccessFlags.Names()), word(d.className(c.ThisClass)))
+	if c.SuperClass != 0 {
+		d.printf(".super %s\n", word(d.className(c.SuperClass)))
+	}
+	for _, i := range c.Interfaces {
+		d.printf(".implements %s\n", word(d.className(i)))
+	}
+	for _, a := range c.Attributes {
+		switch a := a.(type) {
+		case *parser.SourceFileAttribute:
+		case *parser.SignatureAttribute:
+			d.printf(".signature %s\n", strconv.Quote(d.utf8(a.SignatureIndex)))
+		case *parser.BootstrapMethodsAttribute:
+			for _, m := range a.BootstrapMethods {
+				d.printf(".bootstrap %s", d.methodHandle(m.BootstrapMethodRef))
+				for _, arg := range m.BootstrapArguments {
+					d.printf(" %s", d.constant(arg))
+				}
+				d.printf("\n")
+			}
+		default:
+			d.rawAttribute("", ".attribute", a)
+		}
+	}
+	for _, f := range c.Fields {
+		d.printf("\n")
+		d.writeField(f)
+	}
+	for _, m := range c.Methods {
+		d.printf("\n")
+		d.writeMethod(m)
+	}
+}
+
+func (d *disassembler) writeField(f parser.FieldInfo) {
+	var signature, value string
+	var raw []parser.Attribute
+	for _, a := range f.Attributes {
+		switch a := a.(type) {
+		case *parser.SignatureAttribute:
+			signature = " signature " + strconv.Quote(d.utf8(a.SignatureIndex))
+		case *parser.ConstantValueAttribute:
+			value = " = " + d.constant(a.ConstantValueIndex)
+		default:
+			raw = append(raw, a)
+		}
+	}
+	d.printf(".field %s%s %s%s%s\n", flags(f.AccessFlags.Names()), word(d.utf8(f.NameIndex)), word(d.utf8(f.DescriptorIndex)), signature, value)
+	if len(raw) > 0 {
+		for _, a := range raw {
+			d.rawAttribute("    ", ".attribute", a)
+		}
+		d.printf(".end field\n")
+	}
+}
+
+func (d *disassembler) writeMethod(m parser.MethodInfo) {
+	name, desc := d.utf8(m.NameIndex), d.utf8(m.DescriptorIndex)
+	d.printf(".method %s%s\n", flags(m.AccessFlags.Names()), word(name+desc))
+	var code *parser.CodeAttribute
+	for _, a := range m.Attributes {
+		switch a := a.(type) {
+		case *parser.CodeAttribute:
+			code = a
+		case *parser.SignatureAttribute:
+			d.printf("    .signature %s\n", strconv.Quote(d.utf8(a.SignatureIndex)))
+		case *parser.ExceptionsAttribute:
+			d.printf("    .throws")
+			for _, e := range a.ExceptionIndexTable {
+				d.printf(" %s", word(d.className(e)))
+			}
+			d.printf("\n")
+		default:
+			d.rawAttribute("    ", ".attribute", a)
+		}
+	}
+	if code != nil {
+		d.writeCode(m, name, desc, code)
+	}
+	d.printf(".end method\n")
+}
+
+func (d *disassembler) writeCode(m parser.MethodInfo, name, desc string, code *parser.CodeAttribute) {
+	instructions, err := parser.DecodeInstructions(code.Code, nil)
+	if err != nil {
+		d.fail("method %s: %v", name, err)
+		return
+	}
+	labels := map[int]bool{}
+	label := func(offset int) string {
+		if offset < 0 || offset > len(code.Code) {
+			d.fail("method %s: offset %d out of code", name, offset)
+		}
+		labels[offset] = true
+		return fmt.Sprintf("L%d", offset)
+	}
+
+	d.printf("    .limit stack %d\n", code.MaxStack)
+	d.printf("    .limit locals %d\n", code.MaxLocals)
+	for _, e := range code.ExceptionTable {
+		class := "all"
+		if e.CatchType != 0 {
+			class = word(d.className(e.CatchType))
+		}
+		d.printf("    .catch %s from %s to %s using %s\n", class, label(int(e.StartPC)), label(int(e.EndPC)), label(int(e.HandlerPC)))
+	}
+
+	lines := map[int][]uint16{}
+	var frames map[int]*fullFrame
+	for _, a := range code.Attributes {
+		switch a := a.(type) {
+		case *parser.LineNumberTableAttribute:
+			for _, e := range a.LineNumberTable {
+				lines[int(e.StartPC)] = append(lines[int(e.StartPC)], e.LineNumber)
+			}
+		case *parser.LocalVariableTableAttribute:
+			d.writeLocalVariables(a, code.Attributes, label)
+		case *parser.LocalVariableTypeTableAttribute:
+		case *parser.StackMapTableAttribute:
+			frames = d.expandFrames(m, name, desc, a, label)
+		default:
+			d.rawAttribute("    ", ".codeattribute", a)
+		}
+	}
+
+	// Render the instructions first, so all labels are known when they are written.
+	body := make([]string, len(instructions))
+	for i, inst := range instructions {
+		body[i] = d.instruction(inst, label)
+	}
+	starts := map[int]bool{len(code.Code): true}
+	for _, inst := range instructions {
+		starts[inst.Offset] = true
+	}
+	for offset := range labels {
+		if !starts[offset] {
+			d.fail("method %s: offset %d is not the start of an instruction", name, offset)
+		}
+	}
+	for i, inst := range instructions {
+		if labels[inst.Offset] {
+			d.printf("L%d:\n", inst.Offset)
+		}
+		for _, line := range lines[inst.Offset] {
+			d.printf("    .line %d\n", line)
+		}
+		if f, ok := frames[inst.Offset]; ok {
+			d.writeFrame(f)
+		}
+		d.printf("    %s\n", body[i])
+	}
+	if labels[len(code.Code)] {
+		d.printf("L%d:\n", len(code.Code))
+	}
+}
+
+func (d *disassembler) writeLocalVariables(a *parser.LocalVariableTableAttribute, attributes []parser.Attribute, label func(int) string) {
+	signatures := map[parser.LocalVariableTableEntry]uint16{}
+	for _, a := range attributes {
+		if t, ok := a.(*parser.LocalVariableTypeTableAttribute); ok {
+			for _, e := range t.LocalVariableTypeTable {
+				signatures[parser.LocalVariableTableEntry{StartPC: e.StartPC, Length: e.Length, NameIndex: e.NameIndex, Index: e.Index}] = e.SignatureIndex
+			}
+		}
+	}
+	for _, e := range a.LocalVariableTable {
+		d.printf("    .var %d is %s %s", e.Index, word(d.utf8(e.NameIndex)), word(d.utf8(e.DescriptorIndex)))
+		key := e
+		key.DescriptorIndex = 0
+		if s, ok := signatures[key]; ok {
+			d.printf(" signature %s", strconv.Quote(d.utf8(s)))
+		}
+		d.printf(" from %s to %s\n", label(int(e.StartPC)), label(int(e.StartPC)+int(e.Length)))
+	}
+}
+
+func (d *disassembler) instruction(inst parser.Instruction, label func(int) string) string {
+	name := inst.Opcode.String()
+	switch inst.Opcode.OperandKind() {
+	case parser.OperandByte, parser.OperandShort:
+		return fmt.Sprintf("%s %d", name, inst.Value)
+	case parser.OperandLocal:
+		return fmt.Sprintf("%s %d", name, inst.Index)
+	case parser.OperandConstant8, parser.OperandConstant16:
+		switch inst.Opcode {
+		case parser.OpcodeLdc, parser.OpcodeLdcW, parser.OpcodeLdc2W:
+			return name + " " + d.constant(inst.Index)
+		case parser.OpcodeGetstatic, parser.OpcodePutstatic, parser.OpcodeGetfield, parser.OpcodePutfield,
+			parser.OpcodeInvokevirtual, parser.OpcodeInvokespecial, parser.OpcodeInvokestatic:
+			return name + " " + d.member(inst.Index, false)
+		}
+		return name + " " + word(d.className(inst.Index))
+	case parser.OperandBranch16, parser.OperandBranch32:
+		return name + " " + label(inst.Offset+int(inst.Branch))
+	case parser.OperandIinc:
+		return fmt.Sprintf("%s %d %d", name, inst.Index, inst.Value)
+	case parser.OperandInvokeInterface:
+		return fmt.Sprintf("%s %s %d", name, d.member(inst.Index, true), inst.Value)
+	case parser.OperandInvokeDynamic:
+		e, ok := d.entry(inst.Index).(parser.ConstantInvokeDynamicInfo)
+		if !ok {
+			d.fail("constant #%d is not an InvokeDynamic", inst.Index)
+			return name
+		}
+		n, desc := d.nameAndType(e.NameAndTypeIndex)
+		return fmt.Sprintf("%s %d %s", name, e.BootstrapMethodAttrIndex, word(n+desc))
+	case parser.OperandMultiANewArray:
+		return fmt.Sprintf("%s %s %d", name, word(d.className(inst.Index)), inst.Value)
+	case parser.OperandNewArray:
+		return name + " " + parser.ArrayType(inst.Value).String()
+	case parser.OperandTableSwitch, parser.OperandLookupSwitch:
+		var b strings.Builder
+		b.WriteString(name)
+		for i, offset := range inst.Switch.Offsets {
+			key := inst.Switch.Low + int32(i)
+			if inst.Opcode == parser.OpcodeLookupswitch {
+				key = inst.Switch.Keys[i]
+			}
+			fmt.Fprintf(&b, "\n        %d : %s", key, label(inst.Offset+int(offset)))
+		}
+		fmt.Fprintf(&b, "\n        default : %s", label(inst.Offset+int(inst.Switch.Default)))
+		return b.String()
+	}
+	return name
+}
+
+type fullFrame struct {
+	locals, stack []string
+}
+
+// expandFrames computes the full frames of a StackMapTable, keyed by code offset.
+// The verification types are rendered, marking the labels of uninitialized types.
+func (d *disassembler) expandFrames(m parser.MethodInfo, name, desc string, table *parser.StackMapTableAttribute, label func(int) string) map[int]*fullFrame {
+	method, err := descriptor.ParseMethod(desc)
+	if err != nil {
+		d.fail("method %s: %v", name, err)
+		return nil
+	}
+	var locals []string
+	if m.AccessFlags&parser.MethodAccessStatic == 0 {
+		if this := d.className(d.class.ThisClass); name == "<init>" && this != "java/lang/Object" {
+			locals = append(locals, "UninitializedThis")
+		} else {
+			locals = append(locals, "Object "+word(this))
+		}
+	}
+	for _, p := range method.Parameters {
+		locals = append(locals, parameterType(p))
+	}
+
+	frames := map[int]*fullFrame{}
+	offset := -1
+	for _, f := range table.Entries {
+		offset += int(f.OffsetDelta) + 1
+		var stack []string
+		switch {
+		case f.FrameType < parser.StackMapSameLocals1StackItemFrame:
+		case f.FrameType < 128, f.FrameType == parser.StackMapSameLocals1StackItemFrameExtended:
+			stack = d.verificationTypes(f.Stack, label)
+		case f.FrameType < parser.StackMapSameFrameExtended:
+			chop := parser.StackMapSameFrameExtended - int(f.FrameType)
+			if chop > len(locals) {
+				d.fail("method %s: stack map frame at offset %d chops %d of %d locals", name, offset, chop, len(locals))
+				return nil
+			}
+			locals = locals[:len(locals)-chop]
+		case f.FrameType == parser.StackMapSameFrameExtended:
+		case f.FrameType < parser.StackMapFullFrame:
+			locals = append(locals[:len(locals):len(locals)], d.verificationTypes(f.Locals, label)...)
+		default:
+			locals, stack = d.verificationTypes(f.Locals, label), d.verificationTypes(f.Stack, label)
+		}
+		frames[offset] = &fullFrame{locals, stack}
+	}
+	return frames
+}
+
+// parameterType returns the verification type of a parameter in the initial frame of a method.
+func parameterType(t descriptor.Type) string {
+	switch {
+	case t.Dimensions > 0:
+		return "Object " + word(t.Descriptor())
+	case t.Kind == descriptor.Object:
+		return "Object " + word(t.ClassName)
+	case t.Kind == descriptor.Float:
+		return "Float"
+	case t.Kind == descriptor.Long:
+		return "Long"
+	case t.Kind == descriptor.Double:
+		return "Double"
+	}
+	return "Integer"
+}
+
+func (d *disassembler) verificationTypes(types []parser.VerificationTypeInfo, label func(int) string) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		switch t.Tag {
+		case parser.VerificationObject:
+			names[i] = "Object " + word(d.className(t.Index))
+		case parser.VerificationUninitialized:
+			names[i] = "Uninitialized " + label(int(t.Index))
+		default:
+			for name, tag := range verificationTypes {
+				if tag == t.Tag && tag != parser.VerificationObject && tag != parser.VerificationUninitialized {
+					names[i] = name
+				}
+			}
+			if names[i] == "" {
+				d.fail("unknown verification type %d", t.Tag)
+			}
+		}
+	}
+	return names
+}
+
+func (d *disassembler) writeFrame(f *fullFrame) {
+	d.printf("    .stack\n")
+	if len(f.locals) > 0 {
+		d.printf("        locals %s\n", strings.Join(f.locals, " "))
+	}
+	if len(f.stack) > 0 {
+		d.printf("        stack %s\n", strings.Join(f.stack, " "))
+	}
+	d.printf("    .end stack\n")
+}
@@ -0,0 +1,127 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits a line into whitespace separated tokens, unquoting quoted strings and dropping comments.
+func tokenize(line string) ([]token, error) {
+	tokens := make([]token, 0)
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) || line[i] == ';' {
+			return tokens, nil
+		}
+		start := i
+		if line[i] == '"' {
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			s, err := strconv.Unquote(line[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s: %v", line[start:i], err)
+			}
+			tokens = append(tokens, token{s, true})
+			continue
+		}
+		for i < len(line) && !isSpace(line[i]) {
+			i++
+		}
+		tokens = append(tokens, token{line[start:i], false})
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// tokenReader reads the tokens of a line.
+type tokenReader struct {
+	tokens []token
+	pos    int
+}
+
+func (r *tokenReader) done() bool {
+	return r.pos >= len(r.tokens)
+}
+
+func (r *tokenReader) peek() string {
+	if r.done() || r.tokens[r.pos].quoted {
+		return ""
+	}
+	return r.tokens[r.pos].text
+}
+
+// next returns the next token, failing at the end of the line.
+func (r *tokenReader) next(what string) (string, error) {
+	if r.done() {
+		return "", fmt.Errorf("missing %s", what)
+	}
+	r.pos++
+	return r.tokens[r.pos-1].text, nil
+}
+
+// keyword consumes the next token if it is the unquoted keyword.
+func (r *tokenReader) keyword(k string) bool {
+	if r.peek() == k {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *tokenReader) expect(k string) error {
+	if !r.keyword(k) {
+		return fmt.Errorf("expected %s", k)
+	}
+	return nil
+}
+
+func (r *tokenReader) int(what string, bitSize int) (int64, error) {
+	s, err := r.next(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(s, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", what, s)
+	}
+	return v, nil
+}
+
+func (r *tokenReader) end() error {
+	if !r.done() {
+		return fmt.Errorf("unexpected %q", r.tokens[r.pos].text)
+	}
+	return nil
+}
+
+// splitMember splits owner/name(descriptor) or owner/name into its parts.
+func splitMember(s string) (owner, name, descriptor string, err error) {
+	if i := strings.IndexByte(s, '('); i >= 0 {
+		s, descriptor = s[:i], s[i:]
+	}
+	i := strings.LastIndexByte(s, '/')
+	if i <= 0 || i == len(s)-1 {
+		return "", "", "", fmt.Errorf("invalid member reference %q", s)
+	}
+	return s[:i], s[i+1:], descriptor, nil
+}
@@ -0,0 +1,377 @@
+// Package classpath finds class files in directories, archives like jar, war and ear,
+// JDK jmod files, jimage runtime images like lib/modules, and archives nested in them.
+//
+// A class file in an archive has a path like app.war!/WEB-INF/lib/x.jar!/com/Foo.class,
+// with !/ separating the archive from the entry name. Class files in a jimage are named
+// by their module like lib/modules!/java.base/java/lang/Object.class.
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-javap/parser"
+)
+
+// Separator separates an archive and the name of an entry in a path.
+const Separator = "!/"
+
+// Entry is a class file found by Walk. It can only be opened during the call to the WalkFunc.
+type Entry struct {
+	// Path is the path of the class file, like app.jar!/com/Foo.class for one in an archive.
+	Path string
+	// Release is the release of a versioned class file in a multi-release jar,
+	// like 11 for META-INF/versions/11/com/Foo.class, or 0 for others.
+	Release int
+	// Modified is the modification time of the class file, or the zero time if it is unknown
+	// like for the class files in a jimage.
+	Modified time.Time
+	open     func() (io.ReadCloser, error)
+	read     func(max int64, limit parser.LimitKind) ([]byte, error)
+}
+
+// Open opens the class file for reading.
+func (e Entry) Open() (io.ReadCloser, error) {
+	return e.open()
+}
+
+// ReadAll reads the class file. If it has more than max bytes, a *parser.LimitError
+// is returned without reading further. Zero is no limit.
+func (e Entry) ReadAll(max int64) ([]byte, error) {
+	if e.read != nil {
+		return e.read(max, parser.LimitTotalBytes)
+	}
+	r, err := e.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readAll(r, max, parser.LimitTotalBytes)
+}
+
+// readAll reads r up to max bytes, returning a *parser.LimitError of the limit beyond them.
+func readAll(r io.Reader, max int64, limit parser.LimitKind) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, &parser.LimitError{Limit: limit, Max: max}
+	}
+	return data, nil
+}
+
+// WalkFunc is called by Walk for each class file. If an archive in the input cannot be read,
+// it is called with the path of the archive and the error, and the archive is skipped.
+// Returning an error stops the walk, which then returns the error.
+type WalkFunc func(entry Entry, err error) error
+
+// Options are the options of WalkWithOptions.
+type Options struct {
+	// Release is the Java release to read multi-release jars for. A class file in
+	// META-INF/versions/N is read instead of the base one for the highest N up to Release.
+	// With 0, only the base class files are read like Java 8 does.
+	Release int
+	// AllReleases reads all the variants of each class file in multi-release jars.
+	// Release is ignored.
+	AllReleases bool
+	// MaxArchiveDepth is the largest number of archives an archive is nested in, like 1 for a jar
+	// in a war, or 0 for no limit. Deeper archives are reported to the WalkFunc with a
+	// *parser.LimitError and skipped.
+	MaxArchiveDepth int
+	// MaxArchiveSize is the largest size of a nested archive, which is read into memory,
+	// or 0 for no limit. Larger archives are reported like deeper ones.
+	MaxArchiveSize int64
+}
+
+// archive is a container of files, like a zip archive or a jimage.
+type archive interface {
+	files() []archiveFile
+}
+
+type archiveFile struct {
+	name     string
+	open     func() (io.ReadCloser, error)
+	modified time.Time
+	// read reads the file failing like readAll before reading more than max bytes.
+	// It is nil if the file has no better way than reading from open.
+	read func(max int64, limit parser.LimitKind) ([]byte, error)
+}
+
+// readAll reads the file like readAll reads the result of open.
+func (f archiveFile) readAll(max int64, limit parser.LimitKind) ([]byte, error) {
+	if f.read != nil {
+		return f.read(max, limit)
+	}
+	rc, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return readAll(rc, max, limit)
+}
+
+// Walk calls fn for the class files in path, which is a directory walked recursively,
+// a class file or an archive. Archives are read with the archives nested in them.
+// In a directory, only class files and archives recognized by their name are read.
+//
+// path may continue into archives like app.war!/WEB-INF/lib/x.jar!/com/acme, which walks
+// the class files in the directory com/acme of the nested archive, or a single class file.
+// An error reading the file of path is returned without calling fn.
+//
+// Only the base class files of multi-release jars are read. Use WalkWithOptions
+// to read them for a Java release.
+func Walk(path string, fn WalkFunc) error {
+	return WalkWithOptions(path, Options{}, fn)
+}
+
+// WalkWithOptions is like Walk, reading multi-release jars as specified by opts.
+// In a multi-release jar, path may name a class file without META-INF/versions/N.
+func WalkWithOptions(path string, opts Options, fn WalkFunc) error {
+	file, inner := path, ""
+	if i := strings.Index(path, Separator); i >= 0 {
+		file, inner = path[:i], path[i+len(Separator):]
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if inner != "" {
+			return fmt.Errorf("%s: not an archive", file)
+		}
+		return walkDir(file, opts, fn)
+	}
+	if isClass(file) && inner == "" {
+		return fn(fileEntry(file, info), nil)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	a, err := openArchive(f, info.Size())
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	found := false
+	err = walkArchive(file, a, inner, opts, 0, func(entry Entry, err error) error {
+		found = true
+		return fn(entry, err)
+	})
+	if err == nil && !found && inner != "" {
+		return fmt.Errorf("%s: no class files in %s", path, inner)
+	}
+	return err
+}
+
+func walkDir(root string, opts Options, fn WalkFunc) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return fn(Entry{Path: path}, err)
+		case info.IsDir():
+			return nil
+		case isClass(path):
+			return fn(fileEntry(path, info), nil)
+		case isArchive(path) || isImage(path):
+			f, err := os.Open(path)
+			if err != nil {
+				return fn(Entry{Path: path}, err)
+			}
+			defer f.Close()
+			a, err := openArchive(f, info.Size())
+			if err != nil {
+				return fn(Entry{Path: path}, err)
+			}
+			return walkArchive(path, a, "", opts, 0, fn)
+		}
+		return nil
+	})
+}
+
+// walkArchive walks the archive at path, reading nested archives into memory.
+// If inner is not empty, only the class files and archives it names are walked.
+// depth is the number of archives the archive is nested in.
+func walkArchive(path string, a archive, inner string, opts Options, depth int, fn WalkFunc) error {
+	var nestedInner string
+	if i := strings.Index(inner, Separator); i >= 0 {
+		inner, nestedInner = inner[:i], inner[i+len(Separator):]
+	}
+	inner = strings.TrimSuffix(inner, "/")
+	files := a.files()
+	multiRelease := false
+	for _, f := range files {
+		if f.name != manifestName {
+			continue
+		}
+		var err error
+		if multiRelease, err = isMultiRelease(f); err != nil {
+			if err := fn(Entry{Path: path + Separator + f.name}, err); err != nil {
+				return err
+			}
+		}
+	}
+	var selected map[string]int
+	if multiRelease && !opts.AllReleases {
+		selected = selectReleases(files, opts.Release)
+	}
+	for _, f := range files {
+		entryPath := path + Separator + f.name
+		release, name := 0, f.name
+		if multiRelease {
+			release, name = versionedName(f.name)
+			if !opts.AllReleases && release != selected[name] {
+				continue
+			}
+		}
+		if nestedInner != "" {
+			// Only the archive named by inner is walked.
+			if (f.name != inner && name != inner) || !isNestedArchive(f.name) {
+				continue
+			}
+		} else if inner != "" && !inDir(f.name, inner) && !inDir(name, inner) {
+			continue
+		}
+		switch {
+		case isClass(f.name):
+			if err := fn(Entry{Path: entryPath, Release: release, Modified: f.modified, open: f.open, read: f.read}, nil); err != nil {
+				return err
+			}
+		case isNestedArchive(f.name):
+			if opts.MaxArchiveDepth > 0 && depth+1 > opts.MaxArchiveDepth {
+				err := &parser.LimitError{Limit: parser.LimitArchiveDepth, Value: int64(depth + 1), Max: int64(opts.MaxArchiveDepth)}
+				if err := fn(Entry{Path: entryPath}, err); err != nil {
+					return err
+				}
+				continue
+			}
+			nested, err := openNestedArchive(f, opts.MaxArchiveSize)
+			if err != nil {
+				if err := fn(Entry{Path: entryPath}, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := walkArchive(entryPath, nested, nestedInner, opts, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inDir reports whether name is dir or a file in it.
+func inDir(name, dir string) bool {
+	return name == dir || strings.HasPrefix(name, dir+"/")
+}
+
+// openNestedArchive reads the archive f into memory, failing if it has more than max bytes.
+func openNestedArchive(f archiveFile, max int64) (archive, error) {
+	data, err := f.readAll(max, parser.LimitArchiveSize)
+	if err != nil {
+		return nil, err
+	}
+	return openArchive(bytes.NewReader(data), int64(len(data)))
+}
+
+// openArchive opens a jimage, a jmod or a zip archive, told apart by their magic numbers.
+func openArchive(r io.ReaderAt, size int64) (archive, error) {
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case isImageMagic(magic[:]):
+		image, err := NewImage(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return image, nil
+	case bytes.Equal(magic[:], jmodMagic):
+		z, err := NewJmodReader(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return zipArchive{z}, nil
+	}
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return zipArchive{z}, nil
+}
+
+type zipArchive struct {
+	r *zip.Reader
+}
+
+func (z zipArchive) files() []archiveFile {
+	files := make([]archiveFile, 0, len(z.r.File))
+	for _, f := range z.r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, archiveFile{name: f.Name, open: f.Open, modified: f.Modified})
+	}
+	return files
+}
+
+func fileEntry(path string, info os.FileInfo) Entry {
+	return Entry{Path: path, Modified: info.ModTime(), open: func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}}
+}
+
+func isClass(name string) bool {
+	return strings.HasSuffix(name, ".class")
+}
+
+// archiveExtensions are the extensions of the archives read in directories and archives.
+var archiveExtensions = []string{".jar", ".war", ".ear", ".zip", ".jmod"}
+
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNestedArchive reports whether the file in an archive is an archive or a jimage,
+// which are read into memory.
+func isNestedArchive(name string) bool {
+	return isArchive(name) || isImageName(name)
+}
+
+// isImageName reports whether the file is named like a jimage, which is modules in a JDK.
+func isImageName(path string) bool {
+	return filepath.Base(path) == "modules" || strings.HasSuffix(path, ".jimage")
+}
+
+// isImage reports whether the file is a jimage, by its name and magic number.
+func isImage(path string) bool {
+	if !isImageName(path) {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	var magic [4]byte
+	_, err = io.ReadFull(f, magic[:])
+	return err == nil && isImageMagic(magic[:])
+}
@@ -0,0 +1,266 @@
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-javap/parser"
+)
+
+// zipBytes returns a zip archive with the files, in the order of the name/content pairs.
+func zipBytes(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		f, err := w.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[i+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	lib := zipBytes(t, "com/acme/Lib.class", "lib", "META-INF/MANIFEST.MF", "")
+	war := zipBytes(t,
+		"WEB-INF/classes/com/acme/App.class", "app",
+		"WEB-INF/lib/", "",
+		"WEB-INF/lib/lib.jar", string(lib),
+		"WEB-INF/lib/broken.jar", "not a zip",
+	)
+	files := map[string][]byte{
+		"app.war":               war,
+		"classes/com/Foo.class": []byte("foo"),
+		"classes/readme.txt":    []byte("skipped"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	contents := map[string]string{}
+	err := Walk(dir, func(entry Entry, err error) error {
+		if err != nil {
+			got = append(got, "error "+entry.Path)
+			return nil
+		}
+		got = append(got, entry.Path)
+		r, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		contents[entry.Path] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "app.war") + "!/WEB-INF/classes/com/acme/App.class",
+		filepath.Join(dir, "app.war") + "!/WEB-INF/lib/lib.jar!/com/acme/Lib.class",
+		"error " + filepath.Join(dir, "app.war") + "!/WEB-INF/lib/broken.jar",
+		filepath.Join(dir, "classes", "com", "Foo.class"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited\n%q\nwant\n%q", got, want)
+	}
+	if c := contents[want[1]]; c != "lib" {
+		t.Errorf("nested class file = %q, want lib", c)
+	}
+	if c := contents[want[3]]; c != "foo" {
+		t.Errorf("class file = %q, want foo", c)
+	}
+
+	var single []string
+	if err := Walk(filepath.Join(dir, "classes", "com", "Foo.class"), func(entry Entry, err error) error {
+		single = append(single, entry.Path)
+		return err
+	}); err != nil || len(single) != 1 {
+		t.Errorf("Walk(class file) visited %q, error = %v", single, err)
+	}
+	if err := Walk(filepath.Join(dir, "missing.jar"), func(Entry, error) error { return nil }); err == nil {
+		t.Error("Walk(missing.jar) error = nil")
+	}
+}
+
+func TestWalk_Jmod(t *testing.T) {
+	dir := t.TempDir()
+	jmod := append(append([]byte{}, jmodMagic...), zipBytes(t,
+		"classes/module-info.class", "module",
+		"classes/com/acme/Foo.class", "foo",
+		"bin/tool", "",
+	)...)
+	path := filepath.Join(dir, "acme.jmod")
+	if err := ioutil.WriteFile(path, jmod, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range []string{path, path + "!/classes/com/acme/Foo.class"} {
+		if err := Walk(p, func(entry Entry, err error) error {
+			got = append(got, entry.Path)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		path + "!/classes/module-info.class",
+		path + "!/classes/com/acme/Foo.class",
+		path + "!/classes/com/acme/Foo.class",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %q, want %q", got, want)
+	}
+	if err := Walk(path+"!/classes/org", func(Entry, error) error { return nil }); err == nil {
+		t.Error("Walk(no class files) error = nil")
+	}
+}
+
+func TestWalkWithOptions_MaxArchiveDepth(t *testing.T) {
+	inner := zipBytes(t, "Inner.class", "inner")
+	lib := zipBytes(t, "Lib.class", "lib", "inner.jar", string(inner))
+	war := zipBytes(t, "App.class", "app", "lib.jar", string(lib))
+	path := filepath.Join(t.TempDir(), "app.war")
+	if err := ioutil.WriteFile(path, war, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		maxDepth int
+		want     []string
+	}{
+		{0, []string{"App.class", "lib.jar!/Lib.class", "lib.jar!/inner.jar!/Inner.class"}},
+		{2, []string{"App.class", "lib.jar!/Lib.class", "lib.jar!/inner.jar!/Inner.class"}},
+		{1, []string{"App.class", "lib.jar!/Lib.class", "limit lib.jar!/inner.jar"}},
+		{-1, []string{"App.class", "lib.jar!/Lib.class", "lib.jar!/inner.jar!/Inner.class"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		err := WalkWithOptions(path, Options{MaxArchiveDepth: tt.maxDepth}, func(entry Entry, err error) error {
+			name := strings.TrimPrefix(entry.Path, path+Separator)
+			var limitErr *parser.LimitError
+			switch {
+			case errors.As(err, &limitErr) && limitErr.Limit == parser.LimitArchiveDepth:
+				name = "limit " + name
+			case err != nil:
+				return err
+			}
+			got = append(got, name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WalkWithOptions(MaxArchiveDepth %d) visited %q, want %q", tt.maxDepth, got, tt.want)
+		}
+	}
+}
+
+func TestWalkWithOptions_MaxArchiveSize(t *testing.T) {
+	lib := zipBytes(t, "Lib.class", "lib")
+	dir := t.TempDir()
+	war := filepath.Join(dir, "app.war")
+	if err := ioutil.WriteFile(war, zipBytes(t, "App.class", "app", "lib.jar", string(lib)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	image := string(imageBytes(t, map[string]string{"/m/Lib.class": strings.Repeat("lib", 100)}, nil))
+	jdk := filepath.Join(dir, "jdk.zip")
+	if err := ioutil.WriteFile(jdk, zipBytes(t, "App.class", "app", "lib/modules", image), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{war, []string{"App.class", "limit lib.jar"}},
+		{jdk, []string{"App.class", "limit lib/modules"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		err := WalkWithOptions(tt.path, Options{MaxArchiveSize: int64(len(lib)) - 1}, func(entry Entry, err error) error {
+			name := strings.TrimPrefix(entry.Path, tt.path+Separator)
+			var limitErr *parser.LimitError
+			switch {
+			case errors.As(err, &limitErr) && limitErr.Limit == parser.LimitArchiveSize:
+				name = "limit " + name
+			case err != nil:
+				return err
+			}
+			got = append(got, name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WalkWithOptions(%s, MaxArchiveSize) visited %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEntry_ReadAll(t *testing.T) {
+	dir := t.TempDir()
+	jar := filepath.Join(dir, "app.jar")
+	if err := ioutil.WriteFile(jar, zipBytes(t, "App.class", "app"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A jimage resource is read without allocating beyond the limit, compressed or not.
+	image := filepath.Join(dir, "modules")
+	data := imageBytes(t, map[string]string{"/m/App.class": "app", "/m/Zip.class": "app"}, map[string]bool{"/m/Zip.class": true})
+	if err := ioutil.WriteFile(image, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		max     int64
+		want    string
+		wantErr bool
+	}{
+		{0, "app", false},
+		{3, "app", false},
+		{2, "", true},
+	}
+	for _, path := range []string{jar, image} {
+		for _, tt := range tests {
+			err := Walk(path, func(entry Entry, err error) error {
+				if err != nil {
+					return err
+				}
+				data, err := entry.ReadAll(tt.max)
+				var limitErr *parser.LimitError
+				if tt.wantErr {
+					if !errors.As(err, &limitErr) || limitErr.Limit != parser.LimitTotalBytes || limitErr.Max != tt.max {
+						t.Errorf("%s: ReadAll(%d) error = %v, want a *parser.LimitError", entry.Path, tt.max, err)
+					}
+					return nil
+				}
+				if err != nil || string(data) != tt.want {
+					t.Errorf("%s: ReadAll(%d) = %q, %v, want %q", entry.Path, tt.max, data, err, tt.want)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package classpath
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"go-javap/parser"
+)
+
+// imageMagic starts a jimage in the byte order of the platform that wrote it.
+const imageMagic = 0xCAFEDADA
+
+// imageHeaderSize is the size of the header of a jimage: magic, version, flags,
+// resource count, table length, locations size and strings size, each a u4.
+const imageHeaderSize = 7 * 4
+
+// Attribute kinds of an image location.
+const (
+	locationEnd = iota
+	locationModule
+	locationParent
+	locationBase
+	locationExtension
+	locationOffset
+	locationCompressed
+	locationUncompressed
+	locationCount
+)
+
+// compressedResourceMagic starts the header of a compressed resource.
+const compressedResourceMagic = 0xCAFEFAFA
+
+// compressedHeaderSize is the size of the header of a compressed resource: magic u4,
+// compressed size u8, uncompressed size u8, decompressor name offset u4,
+// decompressor config offset u4 and is terminal u1.
+const compressedHeaderSize = 4 + 8 + 8 + 4 + 4 + 1
+
+// maxCompressions is the largest number of compressions of a resource. The JDK applies
+// at most two, sharing strings and then zip.
+const maxCompressions = 8
+
+func isImageMagic(b []byte) bool {
+	return len(b) >= 4 && (binary.BigEndian.Uint32(b) == imageMagic || binary.LittleEndian.Uint32(b) == imageMagic)
+}
+
+// Image is a jimage, the format of lib/modules in a JDK or a jlink image.
+// Resources are named by their module like java.base/java/lang/Object.class.
+type Image struct {
+	r         io.ReaderAt
+	size      int64
+	order     binary.ByteOrder
+	indexSize int64
+	strings   []byte
+	resources map[string]imageLocation
+	names     []string
+}
+
+// imageLocation is the location of a resource in the content of an image.
+type imageLocation struct {
+	offset       int64
+	compressed   int64
+	uncompressed int64
+}
+
+// NewImage reads the index of the jimage r of the given size.
+func NewImage(r io.ReaderAt, size int64) (*Image, error) {
+	header := make([]byte, imageHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("cannot read jimage header: %v", err)
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.BigEndian.Uint32(header) == imageMagic:
+		order = binary.BigEndian
+	case binary.LittleEndian.Uint32(header) == imageMagic:
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("not a jimage")
+	}
+	u4 := func(i int) int64 {
+		return int64(order.Uint32(header[i*4:]))
+	}
+	if major := u4(1) >> 16; major != 1 {
+		return nil, fmt.Errorf("unsupported jimage version %d.%d", major, u4(1)&0xFFFF)
+	}
+	tableLength, locationsSize, stringsSize := u4(4), u4(5), u4(6)
+	indexSize := imageHeaderSize + tableLength*4*2 + locationsSize + stringsSize
+	if indexSize > size {
+		return nil, fmt.Errorf("jimage index of %d bytes exceeds the file size %d", indexSize, size)
+	}
+	index := make([]byte, indexSize-imageHeaderSize)
+	if _, err := r.ReadAt(index, imageHeaderSize); err != nil {
+		return nil, fmt.Errorf("cannot read jimage index: %v", err)
+	}
+	// The redirect table for lookups by hash is not needed to list all resources.
+	offsets := index[tableLength*4 : tableLength*4*2]
+	locations := index[tableLength*4*2 : tableLength*4*2+locationsSize]
+	image := &Image{
+		r:         r,
+		size:      size,
+		order:     order,
+		indexSize: indexSize,
+		strings:   index[tableLength*4*2+locationsSize:],
+		resources: make(map[string]imageLocation, tableLength),
+	}
+	for i := int64(0); i < tableLength; i++ {
+		offset := int64(order.Uint32(offsets[i*4:]))
+		if offset >= int64(len(locations)) {
+			return nil, fmt.Errorf("jimage location offset %d out of range", offset)
+		}
+		attributes, err := decodeLocation(locations[offset:])
+		if err != nil {
+			return nil, err
+		}
+		name, err := image.locationName(attributes)
+		if err != nil {
+			return nil, err
+		}
+		image.resources[name] = imageLocation{
+			offset:       attributes[locationOffset],
+			compressed:   attributes[locationCompressed],
+			uncompressed: attributes[locationUncompressed],
+		}
+		image.names = append(image.names, name)
+	}
+	sort.Strings(image.names)
+	return image, nil
+}
+
+// decodeLocation decodes the attributes of a location, each a byte with the kind in
+// the upper 5 bits and the length - 1 in the lower 3 bits, followed by a big-endian value.
+func decodeLocation(b []byte) ([locationCount]int64, error) {
+	var attributes [locationCount]int64
+	for len(b) > 0 {
+		kind := b[0] >> 3
+		if kind == locationEnd {
+			return attributes, nil
+		}
+		if kind >= locationCount {
+			return attributes, fmt.Errorf("invalid jimage location attribute %d", kind)
+		}
+		length := int(b[0]&7) + 1
+		if len(b) < 1+length {
+			break
+		}
+		var value int64
+		for _, c := range b[1 : 1+length] {
+			value = value<<8 | int64(c)
+		}
+		attributes[kind] = value
+		b = b[1+length:]
+	}
+	return attributes, fmt.Errorf("truncated jimage location")
+}
+
+// locationName returns the name of a resource like /module/parent/base.extension
+// without the leading slash.
+func (image *Image) locationName(attributes [locationCount]int64) (string, error) {
+	var parts [locationCount]string
+	for _, kind := range []int{locationModule, locationParent, locationBase, locationExtension} {
+		s, err := image.string(attributes[kind])
+		if err != nil {
+			return "", err
+		}
+		parts[kind] = s
+	}
+	var b strings.Builder
+	if parts[locationModule] != "" {
+		b.WriteString(parts[locationModule])
+		b.WriteString("/")
+	}
+	if parts[locationParent] != "" {
+		b.WriteString(parts[locationParent])
+		b.WriteString("/")
+	}
+	b.WriteString(parts[locationBase])
+	if parts[locationExtension] != "" {
+		b.WriteString(".")
+		b.WriteString(parts[locationExtension])
+	}
+	return b.String(), nil
+}
+
+// string returns the NUL terminated string at offset of the strings table.
+func (image *Image) string(offset int64) (string, error) {
+	if offset < 0 || offset >= int64(len(image.strings)) {
+		return "", fmt.Errorf("jimage string offset %d out of range", offset)
+	}
+	b := image.strings[offset:]
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated jimage string at offset %d", offset)
+	}
+	return parser.DecodeModifiedUTF8(b[:end])
+}
+
+// Names returns the names of the resources in the image in sorted order.
+func (image *Image) Names() []string {
+	return image.names
+}
+
+// ReadFile returns the content of the resource name, decompressing it if needed.
+func (image *Image) ReadFile(name string) ([]byte, error) {
+	return image.readFile(name, 0, parser.LimitTotalBytes)
+}
+
+// readFile is ReadFile failing with a *parser.LimitError of limit before reading
+// a resource of more than max bytes. Zero is no limit.
+func (image *Image) readFile(name string, max int64, limit parser.LimitKind) ([]byte, error) {
+	loc, ok := image.resources[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in jimage", name)
+	}
+	size := loc.uncompressed
+	if loc.compressed != 0 {
+		size = loc.compressed
+	}
+	content := image.size - image.indexSize
+	if loc.offset < 0 || size < 0 || loc.uncompressed < 0 || loc.offset > content || size > content-loc.offset {
+		return nil, fmt.Errorf("%s of %d bytes at offset %d exceeds the jimage", name, size, loc.offset)
+	}
+	if max > 0 && loc.uncompressed > max {
+		return nil, &parser.LimitError{Limit: limit, Value: loc.uncompressed, Max: max}
+	}
+	data := make([]byte, size)
+	if _, err := image.r.ReadAt(data, image.indexSize+loc.offset); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", name, err)
+	}
+	if loc.compressed == 0 {
+		return data, nil
+	}
+	data, err := image.decompress(data, loc.uncompressed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress %s: %v", name, err)
+	}
+	if int64(len(data)) != loc.uncompressed {
+		return nil, fmt.Errorf("%s is %d bytes after decompression, want %d", name, len(data), loc.uncompressed)
+	}
+	return data, nil
+}
+
+// decompress undoes the compressions of a resource, each with a header in front of its data.
+// No compression may declare more than max bytes.
+func (image *Image) decompress(data []byte, max int64) ([]byte, error) {
+	for i := 0; len(data) >= compressedHeaderSize && image.order.Uint32(data) == compressedResourceMagic; i++ {
+		if i == maxCompressions {
+			return nil, fmt.Errorf("more than %d compressions", maxCompressions)
+		}
+		compressedSize := int64(image.order.Uint64(data[4:]))
+		uncompressedSize := int64(image.order.Uint64(data[12:]))
+		decompressor, err := image.string(int64(image.order.Uint32(data[20:])))
+		if err != nil {
+			return nil, err
+		}
+		content := data[compressedHeaderSize:]
+		if compressedSize < 0 || compressedSize > int64(len(content)) {
+			return nil, fmt.Errorf("compressed size %d exceeds the resource", compressedSize)
+		}
+		if uncompressedSize < 0 || uncompressedSize > max {
+			return nil, fmt.Errorf("uncompressed size %d exceeds the resource size %d", uncompressedSize, max)
+		}
+		switch decompressor {
+		case "zip":
+			zr, err := zlib.NewReader(bytes.NewReader(content[:compressedSize]))
+			if err != nil {
+				return nil, err
+			}
+			data, err = ioutil.ReadAll(io.LimitReader(zr, uncompressedSize))
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported decompressor %q", decompressor)
+		}
+		if int64(len(data)) != uncompressedSize {
+			return nil, fmt.Errorf("decompressed %d bytes, want %d", len(data), uncompressedSize)
+		}
+	}
+	return data, nil
+}
+
+// files returns the class files of the image.
+func (image *Image) files() []archiveFile {
+	var files []archiveFile
+	for _, name := range image.names {
+		if !isClass(name) {
+			continue
+		}
+		name := name
+		files = append(files, archiveFile{
+			name: name,
+			open: func() (io.ReadCloser, error) {
+				data, err := image.ReadFile(name)
+				if err != nil {
+					return nil, err
+				}
+				return ioutil.NopCloser(bytes.NewReader(data)), nil
+			},
+			read: func(max int64, limit parser.LimitKind) ([]byte, error) {
+				return image.readFile(name, max, limit)
+			},
+		})
+	}
+	return files
+}
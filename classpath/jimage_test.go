@@ -0,0 +1,229 @@
+package classpath
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// imageBytes returns a little-endian jimage with the resources named like /module/parent/base.ext.
+// Resources in compressed are compressed with the zip decompressor.
+func imageBytes(t *testing.T, resources map[string]string, compressed map[string]bool) []byte {
+	order := binary.LittleEndian
+	appendU4 := func(b []byte, v uint32) []byte {
+		var u4 [4]byte
+		order.PutUint32(u4[:], v)
+		return append(b, u4[:]...)
+	}
+	strs := []byte{0}
+	stringOffset := func(s string) int64 {
+		if s == "" {
+			return 0
+		}
+		offset := int64(len(strs))
+		strs = append(append(strs, s...), 0)
+		return offset
+	}
+	var names []string
+	for name := range resources {
+		names = append(names, name)
+	}
+	var locations, offsets, content []byte
+	for _, name := range names {
+		rest := strings.TrimPrefix(name, "/")
+		i := strings.Index(rest, "/")
+		module, rest := rest[:i], rest[i+1:]
+		var parent string
+		if i := strings.LastIndex(rest, "/"); i >= 0 {
+			parent, rest = rest[:i], rest[i+1:]
+		}
+		base, ext := rest, ""
+		if i := strings.LastIndex(rest, "."); i >= 0 {
+			base, ext = rest[:i], rest[i+1:]
+		}
+		data := []byte(resources[name])
+		attributes := map[int]int64{
+			locationModule:       stringOffset(module),
+			locationParent:       stringOffset(parent),
+			locationBase:         stringOffset(base),
+			locationExtension:    stringOffset(ext),
+			locationOffset:       int64(len(content)),
+			locationUncompressed: int64(len(data)),
+		}
+		if compressed[name] {
+			var z bytes.Buffer
+			zw := zlib.NewWriter(&z)
+			zw.Write(data)
+			zw.Close()
+			header := make([]byte, compressedHeaderSize)
+			order.PutUint32(header, compressedResourceMagic)
+			order.PutUint64(header[4:], uint64(z.Len()))
+			order.PutUint64(header[12:], uint64(len(data)))
+			order.PutUint32(header[20:], uint32(stringOffset("zip")))
+			header[28] = 1
+			data = append(header, z.Bytes()...)
+			attributes[locationCompressed] = int64(len(data))
+		}
+		content = append(content, data...)
+		offsets = appendU4(offsets, uint32(len(locations)))
+		for kind := locationModule; kind < locationCount; kind++ {
+			if v := attributes[kind]; v != 0 {
+				locations = append(locations, byte(kind<<3|7), 0, 0, 0, 0, 0, 0, 0, 0)
+				binary.BigEndian.PutUint64(locations[len(locations)-8:], uint64(v))
+			}
+		}
+		locations = append(locations, locationEnd)
+	}
+	var image []byte
+	for _, v := range []uint32{imageMagic, 1 << 16, 0, uint32(len(names)), uint32(len(names)), uint32(len(locations)), uint32(len(strs))} {
+		image = appendU4(image, v)
+	}
+	image = append(image, make([]byte, len(names)*4)...)
+	image = append(image, offsets...)
+	image = append(image, locations...)
+	image = append(image, strs...)
+	return append(image, content...)
+}
+
+func TestImage(t *testing.T) {
+	data := imageBytes(t, map[string]string{
+		"/java.base/java/lang/Object.class":  "object",
+		"/java.base/java/lang/String.class":  strings.Repeat("string", 100),
+		"/java.base/module-info.class":       "module",
+		"/java.base/java/lang/uniName.dat":   "data",
+		"/java.logging/java/util/Log.class":  "log",
+		"/java.logging/META-INF/services/xx": "service",
+	}, map[string]bool{"/java.base/java/lang/String.class": true})
+	image, err := NewImage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNames := []string{
+		"java.base/java/lang/Object.class",
+		"java.base/java/lang/String.class",
+		"java.base/java/lang/uniName.dat",
+		"java.base/module-info.class",
+		"java.logging/META-INF/services/xx",
+		"java.logging/java/util/Log.class",
+	}
+	if got := image.Names(); !reflect.DeepEqual(got, wantNames) {
+		t.Errorf("Names() = %q, want %q", got, wantNames)
+	}
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"java.base/java/lang/Object.class", "object", false},
+		{"java.base/java/lang/String.class", strings.Repeat("string", 100), false},
+		{"java.logging/META-INF/services/xx", "service", false},
+		{"java.base/java/lang/Missing.class", "", true},
+	}
+	for _, tt := range tests {
+		got, err := image.ReadFile(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReadFile(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("ReadFile(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lib", "modules")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range []string{dir, path + "!/java.logging"} {
+		if err := Walk(p, func(entry Entry, err error) error {
+			got = append(got, strings.TrimPrefix(entry.Path, path))
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"!/java.base/java/lang/Object.class",
+		"!/java.base/java/lang/String.class",
+		"!/java.base/module-info.class",
+		"!/java.logging/java/util/Log.class",
+		"!/java.logging/java/util/Log.class",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %q, want %q", got, want)
+	}
+}
+
+func TestNewImage_Error(t *testing.T) {
+	valid := imageBytes(t, map[string]string{"/m/A.class": "a"}, nil)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"magic", append([]byte{0xCA, 0xFE, 0xBA, 0xBE}, valid[4:]...)},
+		{"version", append(append(append([]byte{}, valid[:4]...), 0, 0, 2, 0), valid[8:]...)},
+		{"truncated", valid[:40]},
+	}
+	for _, tt := range tests {
+		if _, err := NewImage(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+			t.Errorf("NewImage(%s) error = nil", tt.name)
+		}
+	}
+}
+
+func TestImage_ReadFileMalformed(t *testing.T) {
+	order := binary.LittleEndian
+	// compress compresses data with zip, declaring it uncompressed bytes.
+	compress := func(data []byte, uncompressed int64) []byte {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(data)
+		zw.Close()
+		header := make([]byte, compressedHeaderSize)
+		order.PutUint32(header, compressedResourceMagic)
+		order.PutUint64(header[4:], uint64(z.Len()))
+		order.PutUint64(header[12:], uint64(uncompressed))
+		order.PutUint32(header[20:], 1)
+		return append(header, z.Bytes()...)
+	}
+	data := []byte(strings.Repeat("a", 1000))
+	bomb := compress(data, 1000)
+	nested := data
+	for i := 0; i <= maxCompressions; i++ {
+		nested = compress(nested, int64(len(nested)))
+	}
+	tests := []struct {
+		name    string
+		content []byte
+		loc     imageLocation
+	}{
+		{"huge size", []byte("abc"), imageLocation{uncompressed: 1 << 62}},
+		{"negative size", []byte("abc"), imageLocation{uncompressed: -1}},
+		{"negative offset", []byte("abc"), imageLocation{offset: -1, uncompressed: 1}},
+		{"beyond the end", []byte("abc"), imageLocation{offset: 2, uncompressed: 2}},
+		{"negative compressed size", bomb, imageLocation{compressed: -1, uncompressed: 1000}},
+		{"inflating beyond the size", bomb, imageLocation{compressed: int64(len(bomb)), uncompressed: 10}},
+		{"too many compressions", nested, imageLocation{compressed: int64(len(nested)), uncompressed: 1 << 20}},
+	}
+	for _, tt := range tests {
+		image := &Image{
+			r:         bytes.NewReader(tt.content),
+			size:      int64(len(tt.content)),
+			order:     order,
+			strings:   []byte("\x00zip\x00"),
+			resources: map[string]imageLocation{"m/A.class": tt.loc},
+		}
+		if _, err := image.ReadFile("m/A.class"); err == nil {
+			t.Errorf("%s: ReadFile() error = nil", tt.name)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// jmodMagic starts a jmod file, followed by a zip archive with the classes in classes/.
+var jmodMagic = []byte{'J', 'M', 0x01, 0x00}
+
+// NewJmodReader reads the zip archive of a jmod file of the given size.
+func NewJmodReader(r io.ReaderAt, size int64) (*zip.Reader, error) {
+	magic := make([]byte, len(jmodMagic))
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, jmodMagic) {
+		return nil, fmt.Errorf("not a jmod file")
+	}
+	offset := int64(len(jmodMagic))
+	return zip.NewReader(io.NewSectionReader(r, offset, size-offset), size-offset)
+}
@@ -0,0 +1,68 @@
+package classpath
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// versionsDir is the directory of the versioned entries of a multi-release jar.
+const versionsDir = "META-INF/versions/"
+
+// manifestName is the name of the manifest of a jar.
+const manifestName = "META-INF/MANIFEST.MF"
+
+// isMultiRelease reports whether the main section of a manifest has Multi-Release: true.
+func isMultiRelease(f archiveFile) (bool, error) {
+	r, err := f.open()
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		if line == "" {
+			// The main section ends at the first blank line.
+			break
+		}
+		i := strings.Index(line, ":")
+		if i < 0 || !strings.EqualFold(line[:i], "Multi-Release") {
+			continue
+		}
+		return strings.EqualFold(strings.TrimSpace(line[i+1:]), "true"), nil
+	}
+	return false, s.Err()
+}
+
+// versionedName splits the name of a versioned entry like META-INF/versions/11/com/Foo.class
+// into the release 11 and com/Foo.class. Other names are returned as they are with release 0.
+func versionedName(name string) (int, string) {
+	if !strings.HasPrefix(name, versionsDir) {
+		return 0, name
+	}
+	rest := name[len(versionsDir):]
+	i := strings.Index(rest, "/")
+	if i < 0 {
+		return 0, name
+	}
+	release, err := strconv.Atoi(rest[:i])
+	if err != nil || release < 9 {
+		// Versioned entries are only read by Java 9 and later.
+		return 0, name
+	}
+	return release, rest[i+1:]
+}
+
+// selectReleases returns the release of the entry read for each name of a multi-release jar
+// by Java release. Names without a versioned entry up to release are not in the map.
+func selectReleases(files []archiveFile, release int) map[string]int {
+	selected := map[string]int{}
+	for _, f := range files {
+		r, name := versionedName(f.name)
+		if r > 0 && r <= release && r > selected[name] {
+			selected[name] = r
+		}
+	}
+	return selected
+}
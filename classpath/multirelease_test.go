@@ -0,0 +1,84 @@
+package classpath
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWalkWithOptions_MultiRelease(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"com/acme/A.class", "a8",
+		"com/acme/B.class", "b8",
+		"META-INF/versions/9/com/acme/A.class", "a9",
+		"META-INF/versions/11/com/acme/A.class", "a11",
+		"META-INF/versions/11/com/acme/C.class", "c11",
+		"META-INF/versions/17/com/acme/B.class", "b17",
+	}
+	manifests := map[string]string{
+		"mr.jar":    "Manifest-Version: 1.0\r\nmulti-release: TRUE\r\n\r\n",
+		"plain.jar": "Manifest-Version: 1.0\nCreated-By: test\n\nName: x\nMulti-Release: true\n",
+	}
+	for name, manifest := range manifests {
+		jar := zipBytes(t, append([]string{manifestName, manifest}, files...)...)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), jar, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path string
+		opts Options
+		want []string
+	}{
+		{"mr.jar", Options{}, []string{"com/acme/A.class a8", "com/acme/B.class b8"}},
+		{"mr.jar", Options{Release: 8}, []string{"com/acme/A.class a8", "com/acme/B.class b8"}},
+		{"mr.jar", Options{Release: 10}, []string{"com/acme/B.class b8", "META-INF/versions/9/com/acme/A.class 9 a9"}},
+		{"mr.jar", Options{Release: 21}, []string{
+			"META-INF/versions/11/com/acme/A.class 11 a11",
+			"META-INF/versions/11/com/acme/C.class 11 c11",
+			"META-INF/versions/17/com/acme/B.class 17 b17",
+		}},
+		{"mr.jar", Options{AllReleases: true}, []string{
+			"com/acme/A.class a8",
+			"com/acme/B.class b8",
+			"META-INF/versions/9/com/acme/A.class 9 a9",
+			"META-INF/versions/11/com/acme/A.class 11 a11",
+			"META-INF/versions/11/com/acme/C.class 11 c11",
+			"META-INF/versions/17/com/acme/B.class 17 b17",
+		}},
+		{"mr.jar!/com/acme/A.class", Options{Release: 11}, []string{"META-INF/versions/11/com/acme/A.class 11 a11"}},
+		{"mr.jar!/META-INF/versions/9/com/acme/A.class", Options{AllReleases: true}, []string{"META-INF/versions/9/com/acme/A.class 9 a9"}},
+		{"plain.jar!/com", Options{Release: 21}, []string{"com/acme/A.class a8", "com/acme/B.class b8"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		err := WalkWithOptions(filepath.Join(dir, tt.path), tt.opts, func(entry Entry, err error) error {
+			if err != nil {
+				return err
+			}
+			r, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			data, err := ioutil.ReadAll(r)
+			s := entry.Path[strings.Index(entry.Path, Separator)+len(Separator):]
+			if entry.Release != 0 {
+				s += " " + strconv.Itoa(entry.Release)
+			}
+			got = append(got, s+" "+string(data))
+			return err
+		})
+		if err != nil {
+			t.Errorf("WalkWithOptions(%s, %+v) error = %v", tt.path, tt.opts, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WalkWithOptions(%s, %+v) visited %q, want %q", tt.path, tt.opts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-javap/asm"
+	"go-javap/parser"
+
+	"github.com/urfave/cli"
+)
+
+func assembleCommand() cli.Command {
+	return cli.Command{
+		Name:      "assemble",
+		Usage:     "assemble class files from the text format written by disasm --asm, or the JSON written by dump --json",
+		ArgsUsage: "<source file>...",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "d", Value: ".", Usage: "directory to write the class files to, in directories of their packages"},
+			cli.BoolFlag{Name: "json", Usage: "read the JSON written by dump --json"},
+		},
+		Action: func(c *cli.Context) error {
+			for _, file := range c.Args() {
+				if err := assembleFile(file, c.String("d"), c.Bool("json")); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func assembleFile(file string, dir string, fromJSON bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var classFile *parser.ClassFile
+	if fromJSON {
+		classFile = &parser.ClassFile{}
+		err = json.NewDecoder(f).Decode(classFile)
+	} else {
+		classFile, err = asm.Assemble(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to assemble %s: %v", file, err)
+	}
+	name := classFile.ConstantPool.GetClass(classFile.ThisClass)
+	out := filepath.Join(dir, filepath.FromSlash(name)+".class")
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := parser.Write(w, classFile); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write %s: %v", out, err)
+	}
+	return w.Close()
+}
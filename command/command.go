@@ -1,6 +1,11 @@
 package command
 
 import (
+	"log"
+
+	"go-javap/classpath"
+	"go-javap/parser"
+
 	"github.com/urfave/cli"
 )
 
@@ -12,6 +17,9 @@ func New() *CLI {
 	app := cli.NewApp()
 	app.Commands = []cli.Command{
 		listCommand(),
+		disasmCommand(),
+		dumpCommand(),
+		assembleCommand(),
 	}
 	return &CLI{app}
 }
@@ -19,3 +27,48 @@ func New() *CLI {
 func (c *CLI) Execute(args []string) error {
 	return c.app.Run(args)
 }
+
+// parseOptions returns the options to read the class file name. Unless strict, malformed
+// attributes and constants not allowed in the class file version are logged as warnings.
+func parseOptions(strict bool, limits parser.Limits, name string) parser.ParseOptions {
+	return parser.ParseOptions{
+		Strict: strict,
+		Warn: func(err *parser.ParseError) {
+			log.Printf("warning: %s: %v", name, err)
+		},
+		Limits: limits,
+	}
+}
+
+// limitFlags are the flags of the commands parsing class files, read by parseLimits.
+// The defaults are far above the sizes javac writes.
+var limitFlags = []cli.Flag{
+	cli.Int64Flag{Name: "max-class-size", Value: 64 << 20, Usage: "reject class files of more bytes, 0 for no limit"},
+	cli.Int64Flag{Name: "max-attribute-size", Value: 16 << 20, Usage: "reject class files with an attribute of more bytes, 0 for no limit"},
+	cli.IntFlag{Name: "max-constant-pool-entries", Value: 65535, Usage: "reject class files with more constant pool entries, 0 for no limit"},
+}
+
+func parseLimits(c *cli.Context) parser.Limits {
+	return parser.Limits{
+		MaxTotalBytes:          c.Int64("max-class-size"),
+		MaxAttributeSize:       c.Int64("max-attribute-size"),
+		MaxConstantPoolEntries: c.Int("max-constant-pool-entries"),
+	}
+}
+
+// walkFlags are the flags of the commands reading archives, read by walkOptions.
+var walkFlags = []cli.Flag{
+	cli.IntFlag{Name: "release", Usage: "read multi-release jars for the Java release (default: base versions only)"},
+	cli.BoolFlag{Name: "all-releases", Usage: "read all versions of the classes in multi-release jars"},
+	cli.IntFlag{Name: "max-archive-depth", Value: 8, Usage: "skip archives nested in more archives, 0 for no limit"},
+	cli.Int64Flag{Name: "max-archive-size", Value: 512 << 20, Usage: "skip nested archives of more bytes, which are read into memory, 0 for no limit"},
+}
+
+func walkOptions(c *cli.Context) classpath.Options {
+	return classpath.Options{
+		Release:         c.Int("release"),
+		AllReleases:     c.Bool("all-releases"),
+		MaxArchiveDepth: c.Int("max-archive-depth"),
+		MaxArchiveSize:  c.Int64("max-archive-size"),
+	}
+}
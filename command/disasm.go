@@ -0,0 +1,622 @@
+package command
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-javap/asm"
+	"go-javap/classpath"
+	"go-javap/descriptor"
+	"go-javap/parser"
+	"go-javap/signature"
+
+	"github.com/urfave/cli"
+)
+
+type accessLevel int
+
+const (
+	accessPackage accessLevel = iota
+	accessPublic
+	accessProtected
+	accessPrivate
+)
+
+type disasmOptions struct {
+	access     accessLevel
+	code       bool
+	signatures bool
+	lines      bool
+	verbose    bool
+	constants  bool
+	asm        bool
+	strict     bool
+	limits     parser.Limits
+}
+
+func disasmCommand() cli.Command {
+	return cli.Command{
+		Name:      "disasm",
+		Usage:     "disassemble class files in the javap output format",
+		ArgsUsage: "<directory or file>...",
+		Flags: append(append([]cli.Flag{
+			cli.BoolFlag{Name: "c", Usage: "disassemble the code"},
+			cli.BoolFlag{Name: "s", Usage: "print internal type signatures"},
+			cli.BoolFlag{Name: "l", Usage: "print line number and local variable tables"},
+			cli.BoolFlag{Name: "v, verbose", Usage: "print additional information"},
+			cli.BoolFlag{Name: "constants", Usage: "show final constants"},
+			cli.BoolFlag{Name: "public", Usage: "show only public classes and members"},
+			cli.BoolFlag{Name: "protected", Usage: "show protected/public classes and members"},
+			cli.BoolFlag{Name: "package", Usage: "show package/protected/public classes and members (default)"},
+			cli.BoolFlag{Name: "p, private", Usage: "show all classes and members"},
+			cli.BoolFlag{Name: "asm", Usage: "write the text format read by the assemble command"},
+			cli.BoolFlag{Name: "strict", Usage: "reject class files with malformed attributes or constants not allowed in their version"},
+		}, limitFlags...), walkFlags...),
+		Action: func(c *cli.Context) error {
+			opts := disasmOptions{
+				code:       c.Bool("c"),
+				signatures: c.Bool("s"),
+				lines:      c.Bool("l"),
+				verbose:    c.Bool("verbose"),
+				constants:  c.Bool("constants"),
+				asm:        c.Bool("asm"),
+				strict:     c.Bool("strict"),
+				limits:     parseLimits(c),
+			}
+			switch {
+			case c.Bool("private"):
+				opts.access = accessPrivate
+			case c.Bool("package"):
+				opts.access = accessPackage
+			case c.Bool("protected"):
+				opts.access = accessProtected
+			case c.Bool("public"):
+				opts.access = accessPublic
+			}
+			out := bufio.NewWriter(os.Stdout)
+			defer out.Flush()
+			for _, file := range c.Args() {
+				err := classpath.WalkWithOptions(file, walkOptions(c), func(entry classpath.Entry, err error) error {
+					if err != nil {
+						return fmt.Errorf("cannot read %s: %v", entry.Path, err)
+					}
+					return disassembleEntry(out, entry, opts)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// disassembleEntry disassembles a class file found by classpath.Walk.
+func disassembleEntry(w io.Writer, entry classpath.Entry, opts disasmOptions) error {
+	data, err := entry.ReadAll(opts.limits.MaxTotalBytes)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %v", entry.Path, err)
+	}
+	return disassembleFile(w, entry.Path, entry.Modified, data, opts)
+}
+
+// disassembleFile disassembles the class file data read from file, which was last modified
+// at modified, or at an unknown time if it is zero.
+func disassembleFile(w io.Writer, file string, modified time.Time, data []byte, opts disasmOptions) error {
+	classFile, err := parser.ReadBytesWithOptions(data, parseOptions(opts.strict, opts.limits, file))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	if opts.asm {
+		return asm.Disassemble(w, classFile)
+	}
+	d := &disassembler{
+		out:       &javapWriter{out: w},
+		classFile: classFile,
+		pool:      classFile.ConstantPool,
+		opts:      opts,
+	}
+	// Like javap, a class below the access level is skipped. Only a nested class may be
+	// protected or private, which its class file records as public and package private.
+	if !d.checkAccess(classFile.AccessFlags.Public(), false, false) {
+		return nil
+	}
+	if opts.verbose {
+		d.writeFileInfo(file, modified, data)
+	}
+	d.writeClass()
+	return d.out.err
+}
+
+type disassembler struct {
+	out       *javapWriter
+	classFile *parser.ClassFile
+	pool      parser.ConstantPool
+	opts      disasmOptions
+}
+
+func (d *disassembler) checkAccess(public, protected, private bool) bool {
+	switch d.opts.access {
+	case accessPublic:
+		return public
+	case accessProtected:
+		return public || protected
+	case accessPackage:
+		return !private
+	}
+	return true
+}
+
+func (d *disassembler) writeFileInfo(file string, modified time.Time, data []byte) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	d.out.println("Classfile ", file)
+	d.out.indent++
+	if !modified.IsZero() {
+		d.out.printf("Last modified %s; size %d bytes", modified.Format("Jan 2, 2006"), len(data))
+		d.out.println()
+	}
+	sum := sha256.Sum256(data)
+	d.out.println("SHA-256 checksum ", hex.EncodeToString(sum[:]))
+}
+
+func (d *disassembler) writeClass() {
+	c := d.classFile
+	w := d.out
+	if source := c.SourceFile(); source != "" {
+		w.println(`Compiled from "`, source, `"`)
+	}
+	if d.opts.verbose {
+		w.indent--
+	}
+
+	flags := c.AccessFlags
+	if flags.Public() {
+		w.print("public ")
+	}
+	if flags.Abstract() && !flags.Interface() {
+		w.print("abstract ")
+	}
+	if flags.Final() {
+		w.print("final ")
+	}
+	if flags.Interface() {
+		w.print("interface ")
+	} else {
+		w.print("class ")
+	}
+	w.print(descriptor.JavaName(c.ConstantPool.GetClass(c.ThisClass)))
+	if sig, err := signature.ParseClass(c.Signature()); err == nil {
+		d.writeClassSignature(sig)
+	} else {
+		d.writeSuperTypes()
+	}
+
+	if d.opts.verbose {
+		w.println()
+		w.indent++
+		w.println("minor version: ", fmt.Sprint(c.MinorVersion))
+		w.println("major version: ", fmt.Sprint(c.MajorVersion))
+		w.println(fmt.Sprintf("flags: (0x%04x) ", uint16(flags)), strings.Join(accessFlagNames(flags.Names()), ", "))
+		d.writeIndexWithComment("this_class: ", c.ThisClass)
+		d.writeIndexWithComment("super_class: ", c.SuperClass)
+		w.printf("interfaces: %d, fields: %d, methods: %d, attributes: %d", len(c.Interfaces), len(c.Fields), len(c.Methods), len(c.Attributes))
+		w.println()
+		w.indent--
+		d.writeConstantPool()
+	} else {
+		w.print(" ")
+	}
+
+	w.println("{")
+	w.indent++
+	for _, f := range c.Fields {
+		d.writeField(f)
+	}
+	for _, m := range c.Methods {
+		d.writeMethod(m)
+	}
+	w.pendingNewline = false
+	w.indent--
+	w.println("}")
+
+	if d.opts.verbose {
+		d.writeAttributes(c.Attributes, nil)
+	}
+}
+
+func (d *disassembler) writeIndexWithComment(label string, index uint16) {
+	d.out.printf("%s#%d", label, index)
+	if index != 0 {
+		d.out.tab()
+		d.out.print("// ", constantString(d.pool, index))
+	}
+	d.out.println()
+}
+
+func (d *disassembler) writeConstantPool() {
+	w := d.out
+	w.println("Constant pool:")
+	w.indent++
+	width := len(fmt.Sprint(len(d.pool)+1)) + 1
+	for i := 0; i < len(d.pool); i++ {
+		index := uint16(i + 1)
+		info := d.pool[i]
+		w.printf("%*s = %-18s ", width, fmt.Sprintf("#%d", index), constantTagName(info))
+		switch info := info.(type) {
+		case parser.ConstantUtf8Info:
+			w.print(constantString(d.pool, index))
+		case parser.ConstantIntegerInfo, parser.ConstantFloatInfo:
+			w.print(constantString(d.pool, index))
+		case parser.ConstantLongInfo, parser.ConstantDoubleInfo:
+			w.print(constantString(d.pool, index))
+			i++
+		case parser.ConstantClassInfo:
+			d.writeReference(fmt.Sprintf("#%d", info.NameIndex), index)
+		case parser.ConstantStringInfo:
+			d.writeReference(fmt.Sprintf("#%d", info.StringIndex), index)
+		case parser.ConstantFieldrefInfo:
+			d.writeReference(fmt.Sprintf("#%d.#%d", info.ClassIndex, info.NameAndTypeIndex), index)
+		case parser.ConstantMethodrefInfo:
+			d.writeReference(fmt.Sprintf("#%d.#%d", info.ClassIndex, info.NameAndTypeIndex), index)
+		case parser.ConstantInterfaceMethodrefInfo:
+			d.writeReference(fmt.Sprintf("#%d.#%d", info.ClassIndex, info.NameAndTypeIndex), index)
+		case parser.ConstantNameAndTypeInfo:
+			d.writeReference(fmt.Sprintf("#%d:#%d", info.NameIndex, info.DescriptorIndex), index)
+		case parser.ConstantMethodHandleInfo:
+			d.writeReference(fmt.Sprintf("%d:#%d", info.ReferenceKind, info.ReferenceIndex), index)
+		case parser.ConstantMethodTypeInfo:
+			d.writeReference(fmt.Sprintf("#%d", info.DescriptorIndex), index)
+		case parser.ConstantDynamicInfo:
+			d.writeReference(fmt.Sprintf("#%d:#%d", info.BootstrapMethodAttrIndex, info.NameAndTypeIndex), index)
+		case parser.ConstantInvokeDynamicInfo:
+			d.writeReference(fmt.Sprintf("#%d:#%d", info.BootstrapMethodAttrIndex, info.NameAndTypeIndex), index)
+		case parser.ConstantModuleInfo:
+			d.writeReference(fmt.Sprintf("#%d", info.NameIndex), index)
+		case parser.ConstantPackageInfo:
+			d.writeReference(fmt.Sprintf("#%d", info.NameIndex), index)
+		}
+		w.println()
+	}
+	w.indent--
+}
+
+func (d *disassembler) writeReference(operands string, index uint16) {
+	d.out.print(operands)
+	d.out.tab()
+	d.out.print("// ", constantString(d.pool, index))
+}
+
+// writeSuperTypes writes the erased super class and interfaces of the class.
+func (d *disassembler) writeSuperTypes() {
+	w := d.out
+	c := d.classFile
+	flags := c.AccessFlags
+	if !flags.Interface() && c.SuperClass != 0 {
+		if super := descriptor.JavaName(c.ConstantPool.GetClass(c.SuperClass)); super != "java.lang.Object" {
+			w.print(" extends ", super)
+		}
+	}
+	for i, intf := range c.Interfaces {
+		switch {
+		case i > 0:
+			w.print(",")
+		case flags.Interface():
+			w.print(" extends ")
+		default:
+			w.print(" implements ")
+		}
+		w.print(descriptor.JavaName(c.ConstantPool.GetClass(intf)))
+	}
+}
+
+// writeClassSignature writes the type parameters and generic super types of the class.
+func (d *disassembler) writeClassSignature(sig *signature.ClassSignature) {
+	w := d.out
+	w.print(signature.TypeParametersJava(sig.TypeParameters, false))
+	interfaces := make([]string, len(sig.Interfaces))
+	for i, intf := range sig.Interfaces {
+		interfaces[i] = intf.Java(false)
+	}
+	if d.classFile.AccessFlags.Interface() {
+		if len(interfaces) > 0 {
+			w.print(" extends ", strings.Join(interfaces, ", "))
+		}
+		return
+	}
+	if !sig.Superclass.IsObject() {
+		w.print(" extends ", sig.Superclass.Java(false))
+	}
+	if len(interfaces) > 0 {
+		w.print(" implements ", strings.Join(interfaces, ", "))
+	}
+}
+
+func (d *disassembler) writeField(f parser.FieldInfo) {
+	flags := f.AccessFlags
+	if !d.checkAccess(flags.Public(), flags.Protected(), flags.Private()) {
+		return
+	}
+	w := d.out
+	w.print(strings.Join(append(fieldModifiers(flags), ""), " "))
+	desc := d.pool.GetUTF8(f.DescriptorIndex)
+	typ := javaType(desc)
+	if sig, err := signature.ParseField(f.Signature(d.pool)); err == nil {
+		typ = sig.Java(false)
+	}
+	w.print(typ, " ", d.pool.GetUTF8(f.NameIndex))
+	if d.opts.constants {
+		if index, ok := f.ConstantValueIndex(); ok {
+			w.print(" = ", d.constantValue(desc, index))
+		}
+	}
+	w.println(";")
+	w.indent++
+	if d.opts.signatures || d.opts.verbose {
+		w.println("descriptor: ", desc)
+	}
+	if d.opts.verbose {
+		w.println(fmt.Sprintf("flags: (0x%04x) ", uint16(flags)), strings.Join(accessFlagNames(flags.Names()), ", "))
+		d.writeAttributes(f.Attributes, nil)
+	}
+	w.indent--
+	if d.opts.verbose || d.opts.code || d.opts.lines {
+		w.println()
+	}
+}
+
+func (d *disassembler) constantValue(desc string, index uint16) string {
+	if !(index > 0 && int(index) <= len(d.pool)) {
+		return fmt.Sprintf("#%d", index)
+	}
+	switch info := d.pool[index-1].(type) {
+	case parser.ConstantIntegerInfo:
+		switch desc {
+		case "C":
+			return "'" + escapeJavaString(string(rune(info.Value))) + "'"
+		case "Z":
+			return fmt.Sprint(info.Value == 1)
+		}
+	case parser.ConstantStringInfo:
+		return `"` + constantString(d.pool, index) + `"`
+	}
+	return constantString(d.pool, index)
+}
+
+func (d *disassembler) writeMethod(m parser.MethodInfo) {
+	flags := m.AccessFlags
+	if !d.checkAccess(flags.Public(), flags.Protected(), flags.Private()) {
+		return
+	}
+	w := d.out
+	modifiers := methodModifiers(flags)
+	if d.classFile.AccessFlags.Interface() && !flags.Abstract() && !flags.Static() && !flags.Private() && d.classFile.MajorVersion >= 52 {
+		modifiers = append(modifiers, "default")
+	}
+	w.print(strings.Join(append(modifiers, ""), " "))
+
+	name := d.pool.GetUTF8(m.NameIndex)
+	desc := d.pool.GetUTF8(m.DescriptorIndex)
+	params, ret := javaMethodType(desc)
+	typeParams := ""
+	var throws []string
+	if sig, err := signature.ParseMethod(m.Signature(d.pool)); err == nil {
+		params, ret = sig.JavaParameters(false), sig.Result.Java(false)
+		if len(sig.TypeParameters) > 0 {
+			typeParams = signature.TypeParametersJava(sig.TypeParameters, false) + " "
+		}
+		throws = sig.JavaThrows(false)
+	}
+	if flags.VarArgs() && len(params) > 0 && strings.HasSuffix(params[len(params)-1], "[]") {
+		last := params[len(params)-1]
+		params[len(params)-1] = last[:len(last)-2] + "..."
+	}
+	switch name {
+	case "<init>":
+		w.print(typeParams, descriptor.JavaName(d.pool.GetClass(d.classFile.ThisClass)), "(", strings.Join(params, ", "), ")")
+	case "<clinit>":
+		w.print("{}")
+	default:
+		w.print(typeParams, ret, " ", name, "(", strings.Join(params, ", "), ")")
+	}
+	if exceptions := m.Exceptions(); exceptions != nil {
+		if len(throws) == 0 {
+			throws = make([]string, len(exceptions))
+			for i, e := range exceptions {
+				throws[i] = descriptor.JavaName(d.pool.GetClass(e))
+			}
+		}
+		w.print(" throws ", strings.Join(throws, ", "))
+	}
+	w.println(";")
+
+	w.indent++
+	if d.opts.signatures || d.opts.verbose {
+		w.println("descriptor: ", desc)
+	}
+	if d.opts.verbose {
+		w.println(fmt.Sprintf("flags: (0x%04x) ", uint16(flags)), strings.Join(accessFlagNames(flags.Names()), ", "))
+		d.writeAttributes(m.Attributes, &m)
+	} else if code := m.Code(); code != nil {
+		if d.opts.code {
+			w.println("Code:")
+			d.writeInstructions(code)
+			d.writeExceptionTable(code)
+		}
+		if d.opts.lines {
+			for _, a := range code.Attributes {
+				switch a.(type) {
+				case *parser.LineNumberTableAttribute, *parser.LocalVariableTableAttribute:
+					d.writeAttribute(a, nil)
+				}
+			}
+		}
+	}
+	w.indent--
+	w.pendingNewline = d.opts.code || d.opts.signatures || d.opts.lines || d.opts.verbose
+}
+
+func (d *disassembler) writeInstructions(code *parser.CodeAttribute) {
+	w := d.out
+	instructions, err := code.Instructions(d.pool)
+	if err != nil {
+		w.println("Error: ", err.Error())
+		return
+	}
+	for _, inst := range instructions {
+		mnemonic := inst.Opcode.String()
+		if inst.Wide {
+			// javap names the wide forms like iload_w.
+			mnemonic += "_w"
+		}
+		w.printf("%4d: %-13s ", inst.Offset, mnemonic)
+		d.writeOperands(inst)
+		w.println()
+	}
+}
+
+func (d *disassembler) writeOperands(inst parser.Instruction) {
+	w := d.out
+	switch inst.Opcode {
+	case parser.OpcodeBipush, parser.OpcodeSipush:
+		w.print(fmt.Sprint(inst.Value))
+	case parser.OpcodeIinc:
+		w.printf("%d, %d", inst.Index, inst.Value)
+	case parser.OpcodeNewarray:
+		w.print(" ", parser.ArrayType(inst.Value).String())
+	case parser.OpcodeInvokeinterface, parser.OpcodeMultianewarray:
+		w.printf("#%d,  %d", inst.Index, inst.Value)
+		d.writeConstantComment(inst.Index)
+	case parser.OpcodeInvokedynamic:
+		w.printf("#%d,  0", inst.Index)
+		d.writeConstantComment(inst.Index)
+	case parser.OpcodeTableswitch, parser.OpcodeLookupswitch:
+		d.writeSwitch(inst)
+	default:
+		switch {
+		case inst.Constant != nil:
+			w.printf("#%d", inst.Index)
+			d.writeConstantComment(inst.Index)
+		case len(inst.Targets()) > 0:
+			w.print(fmt.Sprint(inst.Targets()[0]))
+		case isLocalVariableOpcode(inst.Opcode):
+			w.print(fmt.Sprint(inst.Index))
+		}
+	}
+}
+
+func isLocalVariableOpcode(op parser.Opcode) bool {
+	switch op {
+	case parser.OpcodeIload, parser.OpcodeLload, parser.OpcodeFload, parser.OpcodeDload, parser.OpcodeAload,
+		parser.OpcodeIstore, parser.OpcodeLstore, parser.OpcodeFstore, parser.OpcodeDstore, parser.OpcodeAstore,
+		parser.OpcodeRet:
+		return true
+	}
+	return false
+}
+
+func (d *disassembler) writeConstantComment(index uint16) {
+	w := d.out
+	w.tab()
+	w.print("// ")
+	if index == 0 || int(index) > len(d.pool) {
+		w.printf("#%d", index)
+		return
+	}
+	info := d.pool[index-1]
+	value := constantString(d.pool, index)
+	var classIndex uint16
+	switch info := info.(type) {
+	case parser.ConstantFieldrefInfo:
+		classIndex = info.ClassIndex
+	case parser.ConstantMethodrefInfo:
+		classIndex = info.ClassIndex
+	case parser.ConstantInterfaceMethodrefInfo:
+		classIndex = info.ClassIndex
+	}
+	if classIndex != 0 && classIndex == d.classFile.ThisClass {
+		value = value[strings.Index(value, ".")+1:]
+	}
+	w.print(constantKindName(info), " ", value)
+}
+
+func (d *disassembler) writeSwitch(inst parser.Instruction) {
+	w := d.out
+	s := inst.Switch
+	if inst.Opcode == parser.OpcodeTableswitch {
+		w.printf("{ // %d to %d", s.Low, s.High)
+	} else {
+		w.printf("{ // %d", len(s.Keys))
+	}
+	w.indent += 3
+	for i, offset := range s.Offsets {
+		key := s.Low + int32(i)
+		if inst.Opcode == parser.OpcodeLookupswitch {
+			key = s.Keys[i]
+		}
+		w.printf("\n%12d: %d", key, inst.Offset+int(offset))
+	}
+	w.printf("\n     default: %d\n}", inst.Offset+int(s.Default))
+	w.indent -= 3
+}
+
+func (d *disassembler) writeExceptionTable(code *parser.CodeAttribute) {
+	if len(code.ExceptionTable) == 0 {
+		return
+	}
+	w := d.out
+	w.println("Exception table:")
+	w.indent++
+	w.println(" from    to  target type")
+	for _, e := range code.ExceptionTable {
+		w.printf(" %5d %5d %5d   ", e.StartPC, e.EndPC, e.HandlerPC)
+		if e.CatchType == 0 {
+			w.println("any")
+		} else {
+			w.println("Class ", constantString(d.pool, e.CatchType))
+		}
+	}
+	w.indent--
+}
+
+// accessFlagNames converts the names returned by the Names methods of the access flags
+// to the names javap prints like ACC_PUBLIC.
+func accessFlagNames(names []string) []string {
+	for i, name := range names {
+		names[i] = "ACC_" + strings.ToUpper(name)
+	}
+	return names
+}
+
+// modifiers returns the names in keywords, which are Java modifiers but for strict.
+func modifiers(names []string, keywords ...string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, k := range keywords {
+			if name != k {
+				continue
+			}
+			if name == "strict" {
+				name = "strictfp"
+			}
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func fieldModifiers(a parser.FieldAccessFlags) []string {
+	return modifiers(a.Names(), "public", "private", "protected", "static", "final", "volatile", "transient")
+}
+
+func methodModifiers(a parser.MethodAccessFlags) []string {
+	return modifiers(a.Names(), "public", "private", "protected", "static", "final", "synchronized", "native", "abstract", "strict")
+}
@@ -0,0 +1,514 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"go-javap/descriptor"
+	"go-javap/parser"
+)
+
+func (d *disassembler) writeAttributes(attributes []parser.Attribute, method *parser.MethodInfo) {
+	for _, a := range attributes {
+		d.writeAttribute(a, method)
+	}
+}
+
+func (d *disassembler) writeAttribute(attribute parser.Attribute, method *parser.MethodInfo) {
+	w := d.out
+	switch a := attribute.(type) {
+	case *parser.CodeAttribute:
+		argsSize := 0
+		if method != nil {
+			params, _ := javaMethodType(d.pool.GetUTF8(method.DescriptorIndex))
+			argsSize = len(params)
+			if !method.AccessFlags.Static() {
+				argsSize++
+			}
+		}
+		w.println("Code:")
+		w.indent++
+		w.printf("stack=%d, locals=%d, args_size=%d", a.MaxStack, a.MaxLocals, argsSize)
+		w.println()
+		d.writeInstructions(a)
+		d.writeExceptionTable(a)
+		d.writeAttributes(a.Attributes, nil)
+		w.indent--
+	case *parser.SourceFileAttribute:
+		w.println(`SourceFile: "`, d.pool.GetUTF8(a.SourceFileIndex), `"`)
+	case *parser.SignatureAttribute:
+		d.writeIndexWithComment("Signature: ", a.SignatureIndex)
+	case *parser.ConstantValueAttribute:
+		w.print("ConstantValue: ")
+		d.writeConstant(a.ConstantValueIndex)
+		w.println()
+	case *parser.ExceptionsAttribute:
+		names := make([]string, len(a.ExceptionIndexTable))
+		for i, e := range a.ExceptionIndexTable {
+			names[i] = descriptor.JavaName(d.pool.GetClass(e))
+		}
+		w.println("Exceptions:")
+		w.indent++
+		w.println("throws ", strings.Join(names, ", "))
+		w.indent--
+	case *parser.LineNumberTableAttribute:
+		w.println("LineNumberTable:")
+		w.indent++
+		for _, e := range a.LineNumberTable {
+			w.printf("line %d: %d", e.LineNumber, e.StartPC)
+			w.println()
+		}
+		w.indent--
+	case *parser.LocalVariableTableAttribute:
+		w.println("LocalVariableTable:")
+		w.indent++
+		w.println("Start  Length  Slot  Name   Signature")
+		for _, e := range a.LocalVariableTable {
+			w.printf("%5d %7d %5d %5s   %s", e.StartPC, e.Length, e.Index, constantString(d.pool, e.NameIndex), constantString(d.pool, e.DescriptorIndex))
+			w.println()
+		}
+		w.indent--
+	case *parser.LocalVariableTypeTableAttribute:
+		w.println("LocalVariableTypeTable:")
+		w.indent++
+		w.println("Start  Length  Slot  Name   Signature")
+		for _, e := range a.LocalVariableTypeTable {
+			w.printf("%5d %7d %5d %5s   %s", e.StartPC, e.Length, e.Index, constantString(d.pool, e.NameIndex), constantString(d.pool, e.SignatureIndex))
+			w.println()
+		}
+		w.indent--
+	case *parser.StackMapTableAttribute:
+		d.writeStackMapTable(a)
+	case *parser.InnerClassesAttribute:
+		d.writeInnerClasses(a)
+	case *parser.EnclosingMethodAttribute:
+		w.printf("EnclosingMethod: #%d.#%d", a.ClassIndex, a.MethodIndex)
+		w.tab()
+		w.print("// ", descriptor.JavaName(d.pool.GetClass(a.ClassIndex)))
+		if a.MethodIndex != 0 {
+			// Like javap, only the name of the method is shown.
+			if nat, err := d.pool.GetNameAndType(a.MethodIndex); err == nil {
+				w.print(".", nat.Name)
+			} else {
+				w.print(".", constantString(d.pool, a.MethodIndex))
+			}
+		}
+		w.println()
+	case *parser.SyntheticAttribute:
+		w.println("Synthetic: true")
+	case *parser.DeprecatedAttribute:
+		w.println("Deprecated: true")
+	case *parser.SourceDebugExtensionAttribute:
+		w.println("SourceDebugExtension:")
+		w.indent++
+		for _, line := range strings.Split(strings.TrimRight(string(a.DebugExtension), "\n"), "\n") {
+			w.println(line)
+		}
+		w.indent--
+	case *parser.BootstrapMethodsAttribute:
+		w.println("BootstrapMethods:")
+		w.indent++
+		for i, m := range a.BootstrapMethods {
+			w.printf("%d: #%d %s", i, m.BootstrapMethodRef, constantString(d.pool, m.BootstrapMethodRef))
+			w.println()
+			w.indent++
+			w.println("Method arguments:")
+			w.indent++
+			for _, arg := range m.BootstrapArguments {
+				w.printf("#%d %s", arg, constantString(d.pool, arg))
+				w.println()
+			}
+			w.indent -= 2
+		}
+		w.indent--
+	case *parser.NestHostAttribute:
+		w.print("NestHost: ")
+		d.writeConstant(a.HostClassIndex)
+		w.println()
+	case *parser.NestMembersAttribute:
+		d.writeClassList("NestMembers:", a.Classes)
+	case *parser.PermittedSubclassesAttribute:
+		d.writeClassList("PermittedSubclasses:", a.Classes)
+	case *parser.RecordAttribute:
+		w.println("Record:")
+		w.indent++
+		for _, c := range a.Components {
+			desc := d.pool.GetUTF8(c.DescriptorIndex)
+			typ := javaType(desc)
+			w.println(typ, " ", d.pool.GetUTF8(c.NameIndex), ";")
+			w.indent++
+			w.println("descriptor: ", desc)
+			d.writeAttributes(c.Attributes, nil)
+			w.indent--
+			w.println()
+		}
+		w.indent--
+	case *parser.MethodParametersAttribute:
+		w.println("MethodParameters:")
+		w.indent++
+		w.printf("%-30s %s", "Name", "Flags")
+		w.println()
+		for _, p := range a.Parameters {
+			name := "<no name>"
+			if p.NameIndex != 0 {
+				name = constantString(d.pool, p.NameIndex)
+			}
+			flags := make([]string, 0)
+			for _, f := range []struct {
+				flag uint16
+				name string
+			}{{0x0010, "final"}, {0x8000, "mandated"}, {0x1000, "synthetic"}} {
+				if p.AccessFlags&f.flag != 0 {
+					flags = append(flags, f.name)
+				}
+			}
+			w.printf("%-30s %s", name, strings.Join(flags, " "))
+			w.println()
+		}
+		w.indent--
+	case *parser.ModuleAttribute:
+		d.writeModule(a)
+	case *parser.ModulePackagesAttribute:
+		d.writeClassList("ModulePackages:", a.PackageIndex)
+	case *parser.ModuleMainClassAttribute:
+		d.writeIndexWithComment("ModuleMainClass: ", a.MainClassIndex)
+	case *parser.RuntimeVisibleAnnotationsAttribute:
+		d.writeAnnotations("RuntimeVisibleAnnotations:", a.Annotations)
+	case *parser.RuntimeInvisibleAnnotationsAttribute:
+		d.writeAnnotations("RuntimeInvisibleAnnotations:", a.Annotations)
+	case *parser.RuntimeVisibleParameterAnnotationsAttribute:
+		d.writeParameterAnnotations("RuntimeVisibleParameterAnnotations:", a.ParameterAnnotations)
+	case *parser.RuntimeInvisibleParameterAnnotationsAttribute:
+		d.writeParameterAnnotations("RuntimeInvisibleParameterAnnotations:", a.ParameterAnnotations)
+	case *parser.RuntimeVisibleTypeAnnotationsAttribute:
+		d.writeTypeAnnotations("RuntimeVisibleTypeAnnotations:", a.Annotations)
+	case *parser.RuntimeInvisibleTypeAnnotationsAttribute:
+		d.writeTypeAnnotations("RuntimeInvisibleTypeAnnotations:", a.Annotations)
+	case *parser.AnnotationDefaultAttribute:
+		w.println("AnnotationDefault:")
+		w.indent++
+		w.println("default_value: ", elementValueString(a.DefaultValue))
+		w.indent--
+	case *parser.AttributeInfo:
+		w.printf("%s: length = 0x%x", d.pool.AttributeName(a), len(a.Attribute))
+		w.println()
+		for i := 0; i < len(a.Attribute); i += 16 {
+			end := i + 16
+			if end > len(a.Attribute) {
+				end = len(a.Attribute)
+			}
+			line := make([]string, 0, 16)
+			for _, b := range a.Attribute[i:end] {
+				line = append(line, fmt.Sprintf("%02x", b))
+			}
+			w.println("   ", strings.Join(line, " "))
+		}
+	default:
+		w.println(d.pool.AttributeName(a), ":")
+	}
+}
+
+// writeConstant writes a constant with its kind, e.g. "int 10" or "class java/lang/Object".
+func (d *disassembler) writeConstant(index uint16) {
+	if index > 0 && int(index) <= len(d.pool) {
+		d.out.print(constantKindName(d.pool[index-1]), " ")
+	}
+	d.out.print(constantString(d.pool, index))
+}
+
+func (d *disassembler) writeClassList(header string, indexes []uint16) {
+	w := d.out
+	w.println(header)
+	w.indent++
+	for _, index := range indexes {
+		w.println(constantString(d.pool, index))
+	}
+	w.indent--
+}
+
+func (d *disassembler) writeInnerClasses(a *parser.InnerClassesAttribute) {
+	w := d.out
+	first := true
+	for _, c := range a.Classes {
+		flags := c.InnerClassAccessFlags
+		if !d.checkAccess(flags&parser.FieldAccessPublic != 0, flags&parser.FieldAccessProtected != 0, flags&parser.FieldAccessPrivate != 0) {
+			continue
+		}
+		if first {
+			w.println("InnerClasses:")
+			w.indent++
+			first = false
+		}
+		if flags&parser.AccessInterface != 0 {
+			flags &^= parser.AccessAbstract
+		}
+		// The flags of an inner class have the bits of the method flags with these names.
+		names := parser.MethodAccessFlags(flags).Names()
+		w.print(strings.Join(append(modifiers(names, "public", "private", "protected", "static", "final", "abstract"), ""), " "))
+		if c.InnerNameIndex != 0 {
+			w.printf("#%d= ", c.InnerNameIndex)
+		}
+		w.printf("#%d", c.InnerClassInfoIndex)
+		if c.OuterClassInfoIndex != 0 {
+			w.printf(" of #%d", c.OuterClassInfoIndex)
+		}
+		w.print(";")
+		w.tab()
+		w.print("// ")
+		if c.InnerNameIndex != 0 {
+			w.print(descriptor.JavaName(constantString(d.pool, c.InnerNameIndex)), "=")
+		}
+		d.writeConstant(c.InnerClassInfoIndex)
+		if c.OuterClassInfoIndex != 0 {
+			w.print(" of ")
+			d.writeConstant(c.OuterClassInfoIndex)
+		}
+		w.println()
+	}
+	if !first {
+		w.indent--
+	}
+}
+
+func (d *disassembler) writeStackMapTable(a *parser.StackMapTableAttribute) {
+	w := d.out
+	w.printf("StackMapTable: number_of_entries = %d", len(a.Entries))
+	w.println()
+	w.indent++
+	for _, f := range a.Entries {
+		var kind string
+		switch {
+		case f.FrameType < parser.StackMapSameLocals1StackItemFrame:
+			kind = "same"
+		case f.FrameType < 128:
+			kind = "same_locals_1_stack_item"
+		case f.FrameType == parser.StackMapSameLocals1StackItemFrameExtended:
+			kind = "same_locals_1_stack_item_frame_extended"
+		case f.FrameType >= parser.StackMapChopFrame && f.FrameType < parser.StackMapSameFrameExtended:
+			kind = "chop"
+		case f.FrameType == parser.StackMapSameFrameExtended:
+			kind = "same_frame_extended"
+		case f.FrameType >= parser.StackMapAppendFrame && f.FrameType < parser.StackMapFullFrame:
+			kind = "append"
+		case f.FrameType == parser.StackMapFullFrame:
+			kind = "full_frame"
+		}
+		w.printf("frame_type = %d /* %s */", f.FrameType, kind)
+		w.println()
+		w.indent++
+		if f.FrameType >= parser.StackMapSameLocals1StackItemFrameExtended {
+			w.printf("offset_delta = %d", f.OffsetDelta)
+			w.println()
+		}
+		if f.Locals != nil {
+			d.writeVerificationTypes("locals", f.Locals)
+		}
+		if f.Stack != nil || f.FrameType == parser.StackMapFullFrame {
+			d.writeVerificationTypes("stack", f.Stack)
+		}
+		w.indent--
+	}
+	w.indent--
+}
+
+func (d *disassembler) writeVerificationTypes(name string, types []parser.VerificationTypeInfo) {
+	w := d.out
+	w.print(name, " = [")
+	for i, t := range types {
+		switch t.Tag {
+		case parser.VerificationObject:
+			w.print(" ")
+			d.writeConstant(t.Index)
+		case parser.VerificationUninitialized:
+			w.printf(" uninitialized %d", t.Index)
+		case parser.VerificationUninitializedThis:
+			w.print(" this")
+		default:
+			w.print(" ", t.Tag.String())
+		}
+		if i == len(types)-1 {
+			w.print(" ")
+		} else {
+			w.print(",")
+		}
+	}
+	w.println("]")
+}
+
+func (d *disassembler) writeModule(a *parser.ModuleAttribute) {
+	w := d.out
+	w.println("Module:")
+	w.indent++
+	d.writeModuleEntry(fmt.Sprintf("#%d,%x", a.ModuleNameIndex, a.ModuleFlags), constantString(d.pool, a.ModuleNameIndex))
+	d.writeModuleEntry(fmt.Sprintf("#%d", a.ModuleVersionIndex), constantString(d.pool, a.ModuleVersionIndex))
+	d.writeModuleEntry(fmt.Sprint(len(a.Requires)), "requires")
+	w.indent++
+	for _, r := range a.Requires {
+		d.writeModuleEntry(fmt.Sprintf("#%d,%x", r.RequiresIndex, r.RequiresFlags), constantString(d.pool, r.RequiresIndex))
+		d.writeModuleEntry(fmt.Sprintf("#%d", r.RequiresVersionIndex), constantString(d.pool, r.RequiresVersionIndex))
+	}
+	w.indent--
+	d.writeModuleEntry(fmt.Sprint(len(a.Exports)), "exports")
+	w.indent++
+	for _, e := range a.Exports {
+		d.writeModuleTargets(e.ExportsIndex, e.ExportsFlags, e.ExportsToIndex, "exports")
+	}
+	w.indent--
+	d.writeModuleEntry(fmt.Sprint(len(a.Opens)), "opens")
+	w.indent++
+	for _, o := range a.Opens {
+		d.writeModuleTargets(o.OpensIndex, o.OpensFlags, o.OpensToIndex, "opens")
+	}
+	w.indent--
+	d.writeModuleEntry(fmt.Sprint(len(a.UsesIndex)), "uses")
+	w.indent++
+	for _, u := range a.UsesIndex {
+		d.writeModuleEntry(fmt.Sprintf("#%d", u), constantString(d.pool, u))
+	}
+	w.indent--
+	d.writeModuleEntry(fmt.Sprint(len(a.Provides)), "provides")
+	w.indent++
+	for _, p := range a.Provides {
+		d.writeModuleEntry(fmt.Sprintf("#%d", p.ProvidesIndex), constantString(d.pool, p.ProvidesIndex))
+		d.writeModuleEntry(fmt.Sprintf("%d", len(p.ProvidesWithIndex)), "with ... ")
+		w.indent++
+		for _, with := range p.ProvidesWithIndex {
+			d.writeModuleEntry(fmt.Sprintf("#%d", with), "... with "+constantString(d.pool, with))
+		}
+		w.indent--
+	}
+	w.indent--
+	w.indent--
+}
+
+func (d *disassembler) writeModuleTargets(index, flags uint16, targets []uint16, kind string) {
+	d.writeModuleEntry(fmt.Sprintf("#%d,%x", index, flags), constantString(d.pool, index))
+	d.out.indent++
+	d.writeModuleEntry(fmt.Sprint(len(targets)), "... to")
+	for _, to := range targets {
+		d.writeModuleEntry(fmt.Sprintf("#%d", to), "... to "+constantString(d.pool, to))
+	}
+	d.out.indent--
+}
+
+func (d *disassembler) writeModuleEntry(value, comment string) {
+	d.out.print(value)
+	d.out.tab()
+	d.out.println("// ", comment)
+}
+
+func (d *disassembler) writeAnnotations(header string, annotations []parser.AnnotationInfo) {
+	w := d.out
+	w.println(header)
+	w.indent++
+	for i, a := range annotations {
+		w.printf("%d: %s", i, annotationString(a))
+		w.println()
+	}
+	w.indent--
+}
+
+func (d *disassembler) writeParameterAnnotations(header string, parameters [][]parser.AnnotationInfo) {
+	w := d.out
+	w.println(header)
+	w.indent++
+	for i, annotations := range parameters {
+		w.printf("parameter %d:", i)
+		w.println()
+		w.indent++
+		for j, a := range annotations {
+			w.printf("%d: %s", j, annotationString(a))
+			w.println()
+		}
+		w.indent--
+	}
+	w.indent--
+}
+
+func (d *disassembler) writeTypeAnnotations(header string, annotations []parser.TypeAnnotationInfo) {
+	w := d.out
+	w.println(header)
+	w.indent++
+	for i, a := range annotations {
+		w.printf("%d: %s: %s", i, annotationString(a.AnnotationInfo), typeAnnotationTargetName(a.TargetType))
+		w.println()
+	}
+	w.indent--
+}
+
+// annotationString renders an annotation with constant pool indexes, e.g. #10(#11=s#12).
+func annotationString(a parser.AnnotationInfo) string {
+	pairs := make([]string, len(a.ElementValuePairs))
+	for i, p := range a.ElementValuePairs {
+		pairs[i] = fmt.Sprintf("#%d=%s", p.ElementNameIndex, elementValueString(p.Value))
+	}
+	return fmt.Sprintf("#%d(%s)", a.TypeIndex, strings.Join(pairs, ","))
+}
+
+func elementValueString(v parser.ElementValueInfo) string {
+	switch v.Tag {
+	case parser.ElementValueEnum:
+		return fmt.Sprintf("e#%d.#%d", v.TypeNameIndex, v.ConstNameIndex)
+	case parser.ElementValueClass:
+		return fmt.Sprintf("c#%d", v.ClassInfoIndex)
+	case parser.ElementValueAnnotation:
+		return "@" + annotationString(*v.AnnotationValue)
+	case parser.ElementValueArray:
+		values := make([]string, len(v.Values))
+		for i, value := range v.Values {
+			values[i] = elementValueString(value)
+		}
+		return "[" + strings.Join(values, ",") + "]"
+	}
+	return fmt.Sprintf("%c#%d", v.Tag, v.ConstValueIndex)
+}
+
+func typeAnnotationTargetName(targetType uint8) string {
+	switch targetType {
+	case 0x00:
+		return "CLASS_TYPE_PARAMETER"
+	case 0x01:
+		return "METHOD_TYPE_PARAMETER"
+	case 0x10:
+		return "CLASS_EXTENDS"
+	case 0x11:
+		return "CLASS_TYPE_PARAMETER_BOUND"
+	case 0x12:
+		return "METHOD_TYPE_PARAMETER_BOUND"
+	case 0x13:
+		return "FIELD"
+	case 0x14:
+		return "METHOD_RETURN"
+	case 0x15:
+		return "METHOD_RECEIVER"
+	case 0x16:
+		return "METHOD_FORMAL_PARAMETER"
+	case 0x17:
+		return "THROWS"
+	case 0x40:
+		return "LOCAL_VARIABLE"
+	case 0x41:
+		return "RESOURCE_VARIABLE"
+	case 0x42:
+		return "EXCEPTION_PARAMETER"
+	case 0x43:
+		return "INSTANCEOF"
+	case 0x44:
+		return "NEW"
+	case 0x45:
+		return "CONSTRUCTOR_REFERENCE"
+	case 0x46:
+		return "METHOD_REFERENCE"
+	case 0x47:
+		return "CAST"
+	case 0x48:
+		return "CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT"
+	case 0x49:
+		return "METHOD_INVOCATION_TYPE_ARGUMENT"
+	case 0x4A:
+		return "CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT"
+	case 0x4B:
+		return "METHOD_REFERENCE_TYPE_ARGUMENT"
+	}
+	return fmt.Sprintf("UNKNOWN(0x%02x)", targetType)
+}
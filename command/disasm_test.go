@@ -0,0 +1,170 @@
+package command
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-javap/classpath"
+	"go-javap/parser"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestDisassembleFile(t *testing.T) {
+	tests := []struct {
+		class  string
+		golden string
+		opts   disasmOptions
+		// build builds the class file instead of reading it from testdata.
+		build func(testing.TB) []byte
+	}{
+		{"JsonDecoder.class", "JsonDecoder.javap", disasmOptions{}, nil},
+		{"JsonDecoder.class", "JsonDecoder-c-p.javap", disasmOptions{code: true, access: accessPrivate}, nil},
+		{"JsonDecoder.class", "JsonDecoder-v-p.javap", disasmOptions{verbose: true, access: accessPrivate}, nil},
+		{"Sign$1.class", "Sign$1-v.javap", disasmOptions{verbose: true}, nil},
+		{"Wide.class", "Wide-c.javap", disasmOptions{code: true}, wideClass},
+	}
+	modified := time.Date(2023, 8, 21, 7, 40, 0, 0, time.UTC)
+	var err error
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			path := filepath.Join("..", "testdata", tt.class)
+			var data []byte
+			if tt.build != nil {
+				data = tt.build(t)
+			} else if data, err = ioutil.ReadFile(path); err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err := disassembleFile(&b, path, modified, data, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			// The golden files have the path of the class file as given to javap.
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Replace(b.String(), abs, tt.class, 1)
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s:\n%s", golden, diff(got, string(want)))
+			}
+		})
+	}
+}
+
+// wideClass returns a class with a method using the wide forms of the instructions
+// on local variables, which javac writes for methods with more than 255 of them.
+func wideClass(tb testing.TB) []byte {
+	b := parser.NewClassBuilder("Wide")
+	code := []byte{
+		0xC4, 0x15, 0x01, 0x00, // wide iload 256
+		0xC4, 0x36, 0x01, 0x2C, // wide istore 300
+		0xC4, 0x84, 0x01, 0x00, 0xFF, 0xFE, // wide iinc 256, -2
+		0xB1, // return
+	}
+	data, err := b.Super("java/lang/Object").
+		AddMethod(parser.MethodAccessPublic|parser.MethodAccessStatic, "run", "()V", b.Code(1, 301, code)).
+		Bytes()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+func TestDisassembleEntry_Modified(t *testing.T) {
+	// Class files in archives have the time of their entry.
+	jar := filepath.Join(t.TempDir(), "lib.jar")
+	f, err := os.Create(jar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	e, err := w.CreateHeader(&zip.FileHeader{Name: "Foo.class", Modified: time.Date(2023, 8, 21, 7, 40, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Write(classBytes(t, "Foo"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var b bytes.Buffer
+	err = classpath.Walk(jar, func(entry classpath.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		return disassembleEntry(&b, entry, disasmOptions{verbose: true})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "  Last modified Aug 21, 2023; size "; !strings.Contains(b.String(), want) {
+		t.Errorf("output has no %q:\n%s", want, b.String())
+	}
+}
+
+func TestDisassembleFile_ClassAccess(t *testing.T) {
+	b := parser.NewClassBuilder("Hidden")
+	hidden, err := b.Access(parser.AccessSuper).Super("java/lang/Object").Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		data   []byte
+		access accessLevel
+		want   bool
+	}{
+		{classBytes(t, "Foo"), accessPublic, true},
+		{hidden, accessPublic, false},
+		{hidden, accessProtected, false},
+		{hidden, accessPackage, true},
+		{hidden, accessPrivate, true},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := disassembleFile(&out, "X.class", time.Time{}, tt.data, disasmOptions{access: tt.access}); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.Len() > 0; got != tt.want {
+			t.Errorf("disassembleFile(access %d) wrote %q, want output %v", tt.access, out.String(), tt.want)
+		}
+	}
+}
+
+// diff describes the first line which differs between got and want.
+func diff(got, want string) string {
+	g, w := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(g) || i < len(w); i++ {
+		var gl, wl string
+		if i < len(g) {
+			gl = g[i]
+		}
+		if i < len(w) {
+			wl = w[i]
+		}
+		if gl != wl {
+			return fmt.Sprintf("line %d:\n got: %s\nwant: %s", i+1, gl, wl)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"go-javap/parser"
+
+	"github.com/urfave/cli"
+)
+
+func dumpCommand() cli.Command {
+	return cli.Command{
+		Name:      "dump",
+		Usage:     "dump the structures of class files as they are parsed",
+		ArgsUsage: "<class file>...",
+		Flags: append([]cli.Flag{
+			cli.BoolFlag{Name: "json", Usage: "write JSON, which assemble --json reads back"},
+			cli.BoolFlag{Name: "strict", Usage: "reject class files with malformed attributes or constants not allowed in their version"},
+		}, limitFlags...),
+		Action: func(c *cli.Context) error {
+			out := bufio.NewWriter(os.Stdout)
+			defer out.Flush()
+			for _, file := range c.Args() {
+				if err := dumpFile(out, file, c.Bool("json"), parseOptions(c.Bool("strict"), parseLimits(c), file)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func dumpFile(w io.Writer, file string, asJSON bool, opts parser.ParseOptions) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	classFile, err := parser.ReadWithOptions(bufio.NewReader(f), opts)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(classFile)
+	}
+	return dumpClassFile(w, file, classFile)
+}
+
+// dumpClassFile writes the structures with their String methods.
+func dumpClassFile(w io.Writer, file string, c *parser.ClassFile) error {
+	out := &javapWriter{out: w}
+	out.printf("file: %s\n", file)
+	out.printf("version: %d.%d\n", c.MajorVersion, c.MinorVersion)
+	out.printf("constant_pool:\n")
+	for i := 0; i < len(c.ConstantPool); i++ {
+		info := c.ConstantPool[i]
+		out.printf("  #%d = %s\n", i+1, info)
+		switch info.(type) {
+		case parser.ConstantLongInfo, parser.ConstantDoubleInfo:
+			i++
+		}
+	}
+	out.printf("access_flags: %s\n", c.AccessFlags)
+	out.printf("this_class: #%d\n", c.ThisClass)
+	out.printf("super_class: #%d\n", c.SuperClass)
+	out.printf("interfaces: %v\n", c.Interfaces)
+	for i, f := range c.Fields {
+		out.printf("field[%d]: %s\n", i, f)
+		dumpAttributes(out, "  ", c.ConstantPool, f.Attributes)
+	}
+	for i, m := range c.Methods {
+		out.printf("method[%d]: %s\n", i, m)
+		dumpAttributes(out, "  ", c.ConstantPool, m.Attributes)
+	}
+	dumpAttributes(out, "", c.ConstantPool, c.Attributes)
+	return out.err
+}
+
+func dumpAttributes(out *javapWriter, indent string, pool parser.ConstantPool, attributes []parser.Attribute) {
+	for _, a := range attributes {
+		out.printf("%s%s: %+v\n", indent, pool.AttributeName(a), a)
+		if code, ok := a.(*parser.CodeAttribute); ok {
+			dumpAttributes(out, indent+"  ", pool, code.Attributes)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go-javap/descriptor"
+	"go-javap/parser"
+)
+
+const javapTabColumn = 40
+
+// javapWriter mimics the line handling of the JDK's javap: output is indented
+// two spaces per level, trailing spaces are dropped and tab() aligns comments.
+type javapWriter struct {
+	out            io.Writer
+	indent         int
+	lineIndent     int
+	line           strings.Builder
+	pendingNewline bool
+	err            error
+}
+
+func (w *javapWriter) print(s ...string) {
+	if w.pendingNewline {
+		w.pendingNewline = false
+		w.println()
+	}
+	for _, v := range s {
+		w.write(v)
+	}
+}
+
+func (w *javapWriter) write(s string) {
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			w.append(s)
+			return
+		}
+		w.append(s[:i])
+		w.endLine()
+		s = s[i+1:]
+	}
+}
+
+// append adds s to the current line, which keeps the indentation in effect when it was started.
+func (w *javapWriter) append(s string) {
+	if w.line.Len() == 0 {
+		w.lineIndent = w.indent
+	}
+	w.line.WriteString(s)
+}
+
+func (w *javapWriter) printf(format string, args ...interface{}) {
+	w.print(fmt.Sprintf(format, args...))
+}
+
+func (w *javapWriter) println(s ...string) {
+	w.print(s...)
+	w.endLine()
+}
+
+func (w *javapWriter) endLine() {
+	line := strings.TrimRight(w.line.String(), " ")
+	w.line.Reset()
+	if line != "" {
+		line = strings.Repeat("  ", w.lineIndent) + line
+	}
+	if w.err == nil {
+		_, w.err = fmt.Fprintln(w.out, line)
+	}
+}
+
+func (w *javapWriter) tab() {
+	n := javapTabColumn - utf8.RuneCountInString(w.line.String())
+	if n < 1 {
+		n = 1
+	}
+	w.append(strings.Repeat(" ", n))
+}
+
+// constantTagName returns the tag name used in the constant pool listing.
+func constantTagName(info parser.ConstantInfo) string {
+	switch info.(type) {
+	case parser.ConstantUtf8Info:
+		return "Utf8"
+	case parser.ConstantIntegerInfo:
+		return "Integer"
+	case parser.ConstantFloatInfo:
+		return "Float"
+	case parser.ConstantLongInfo:
+		return "Long"
+	case parser.ConstantDoubleInfo:
+		return "Double"
+	case parser.ConstantClassInfo:
+		return "Class"
+	case parser.ConstantStringInfo:
+		return "String"
+	case parser.ConstantFieldrefInfo:
+		return "Fieldref"
+	case parser.ConstantMethodrefInfo:
+		return "Methodref"
+	case parser.ConstantInterfaceMethodrefInfo:
+		return "InterfaceMethodref"
+	case parser.ConstantNameAndTypeInfo:
+		return "NameAndType"
+	case parser.ConstantMethodHandleInfo:
+		return "MethodHandle"
+	case parser.ConstantMethodTypeInfo:
+		return "MethodType"
+	case parser.ConstantDynamicInfo:
+		return "Dynamic"
+	case parser.ConstantInvokeDynamicInfo:
+		return "InvokeDynamic"
+	case parser.ConstantModuleInfo:
+		return "Module"
+	case parser.ConstantPackageInfo:
+		return "Package"
+	}
+	return "Unknown"
+}
+
+// constantKindName returns the tag name used in bytecode comments.
+func constantKindName(info parser.ConstantInfo) string {
+	switch info.(type) {
+	case parser.ConstantIntegerInfo:
+		return "int"
+	case parser.ConstantFloatInfo:
+		return "float"
+	case parser.ConstantLongInfo:
+		return "long"
+	case parser.ConstantDoubleInfo:
+		return "double"
+	case parser.ConstantClassInfo:
+		return "class"
+	case parser.ConstantFieldrefInfo:
+		return "Field"
+	case parser.ConstantMethodrefInfo:
+		return "Method"
+	case parser.ConstantInterfaceMethodrefInfo:
+		return "InterfaceMethod"
+	}
+	return constantTagName(info)
+}
+
+// constantString renders a constant the way javap does in comments.
+func constantString(pool parser.ConstantPool, index uint16) string {
+	if index == 0 || int(index) > len(pool) {
+		return fmt.Sprintf("#%d", index)
+	}
+	switch info := pool[index-1].(type) {
+	case parser.ConstantUtf8Info:
+		s, _ := info.Value()
+		return escapeJavaString(s)
+	case parser.ConstantIntegerInfo:
+		return strconv.FormatInt(int64(info.Value), 10)
+	case parser.ConstantFloatInfo:
+		return parser.FormatFloat(float64(info.Value), 32) + "f"
+	case parser.ConstantLongInfo:
+		return strconv.FormatInt(info.Value, 10) + "l"
+	case parser.ConstantDoubleInfo:
+		return parser.FormatFloat(info.Value, 64) + "d"
+	case parser.ConstantClassInfo:
+		return checkName(pool.GetUTF8(info.NameIndex))
+	case parser.ConstantStringInfo:
+		return constantString(pool, info.StringIndex)
+	case parser.ConstantFieldrefInfo:
+		return constantString(pool, info.ClassIndex) + "." + constantString(pool, info.NameAndTypeIndex)
+	case parser.ConstantMethodrefInfo:
+		return constantString(pool, info.ClassIndex) + "." + constantString(pool, info.NameAndTypeIndex)
+	case parser.ConstantInterfaceMethodrefInfo:
+		return constantString(pool, info.ClassIndex) + "." + constantString(pool, info.NameAndTypeIndex)
+	case parser.ConstantNameAndTypeInfo:
+		return checkName(pool.GetUTF8(info.NameIndex)) + ":" + constantString(pool, info.DescriptorIndex)
+	case parser.ConstantMethodHandleInfo:
+		return "REF_" + info.ReferenceKind.String() + " " + constantString(pool, info.ReferenceIndex)
+	case parser.ConstantMethodTypeInfo:
+		return constantString(pool, info.DescriptorIndex)
+	case parser.ConstantDynamicInfo:
+		return fmt.Sprintf("#%d:%s", info.BootstrapMethodAttrIndex, constantString(pool, info.NameAndTypeIndex))
+	case parser.ConstantInvokeDynamicInfo:
+		return fmt.Sprintf("#%d:%s", info.BootstrapMethodAttrIndex, constantString(pool, info.NameAndTypeIndex))
+	case parser.ConstantModuleInfo:
+		return checkName(pool.GetUTF8(info.NameIndex))
+	case parser.ConstantPackageInfo:
+		return checkName(pool.GetUTF8(info.NameIndex))
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// checkName quotes names which are not valid Java identifiers, e.g. "<init>".
+func checkName(name string) string {
+	if name == "" {
+		return `""`
+	}
+	prev := '/'
+	for _, c := range name {
+		if (prev == '/' && !isJavaIdentifierStart(c)) || (c != '/' && !isJavaIdentifierPart(c)) {
+			return `"` + escapeJavaString(name) + `"`
+		}
+		prev = c
+	}
+	return name
+}
+
+func isJavaIdentifierStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '$' || c == '_' || unicode.Is(unicode.Sc, c) || unicode.Is(unicode.Pc, c)
+}
+
+func isJavaIdentifierPart(c rune) bool {
+	return isJavaIdentifierStart(c) || unicode.IsDigit(c) || unicode.Is(unicode.Mn, c) || unicode.Is(unicode.Mc, c)
+}
+
+func escapeJavaString(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			if unicode.IsControl(c) {
+				fmt.Fprintf(&b, `\u%04x`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// javaType converts a field descriptor to Java source form.
+// Malformed descriptors are returned as they are.
+func javaType(desc string) string {
+	t, err := descriptor.ParseField(desc)
+	if err != nil {
+		return desc
+	}
+	return t.String()
+}
+
+// javaMethodType converts a method descriptor to Java source parameter and return types.
+func javaMethodType(desc string) ([]string, string) {
+	m, err := descriptor.ParseMethod(desc)
+	if err != nil {
+		return []string{}, desc
+	}
+	return m.JavaParameters(false), m.Return.String()
+}
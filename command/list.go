@@ -1,75 +1,247 @@
 package command
 
 import (
-	"archive/zip"
+	"bufio"
+	"bytes"
 	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"go-javap/parser"
 
 	"github.com/urfave/cli"
 )
 
+// listEntry is a record of the list command. The JSON form is stable:
+//
+//	{"file": "app.jar!/com/acme/Foo.class", "class_type": "class", "name": "com/acme/Foo",
+//	 "super_name": "java/lang/Object", "interfaces": ["java/io/Serializable"], "signature": ""}
+//
+// file is the path of the class file, with !/ after each archive like app.war!/WEB-INF/lib/x.jar!/com/Foo.class.
+// class_type is one of interface, annotation, enum, abstract and class.
+// Names are internal names, interfaces is never null and signature is the
+// generic signature in Java syntax, or "" if the class has none.
+// release is added with N for a class file in META-INF/versions/N of a multi-release jar.
+// The other formats have a release column only when releases are listed.
+type listEntry struct {
+	File       string   `json:"file"`
+	ClassType  string   `json:"class_type"`
+	Name       string   `json:"name"`
+	SuperName  string   `json:"super_name"`
+	Interfaces []string `json:"interfaces"`
+	Signature  string   `json:"signature"`
+	Release    int      `json:"release,omitempty"`
+}
+
+var listHeader = []string{"file", "class_type", "name", "super_name", "interfaces", "signature"}
+
+// header returns the column names, with release if releases.
+func header(releases bool) []string {
+	if releases {
+		return append(listHeader[:len(listHeader):len(listHeader)], "release")
+	}
+	return listHeader
+}
+
+func (e listEntry) record(releases bool) []string {
+	record := []string{e.File, e.ClassType, e.Name, e.SuperName, strings.Join(e.Interfaces, ", "), e.Signature}
+	if releases {
+		var release string
+		if e.Release != 0 {
+			release = strconv.Itoa(e.Release)
+		}
+		record = append(record, release)
+	}
+	return record
+}
+
+// marshal encodes e without escaping <, > and & which are common in signatures.
+func (e listEntry) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(e); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// listWriter writes list entries in an output format. close writes what is buffered
+// and ends the output, e.g. the closing bracket of a JSON array.
+type listWriter interface {
+	write(e listEntry) error
+	close() error
+}
+
+var listFormats = []string{"csv", "json", "ndjson", "tsv", "table"}
+
+// newListWriter returns a writer of the format. If releases, the release of versioned
+// class files is written in a column, which JSON has anyway.
+func newListWriter(format string, w io.Writer, releases bool) (listWriter, error) {
+	switch format {
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write(header(releases))
+		return &csvListWriter{cw, releases}, cw.Error()
+	case "json":
+		return &jsonListWriter{w: w}, nil
+	case "ndjson":
+		return &ndjsonListWriter{w}, nil
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header(releases), "\t")))
+		return &tableListWriter{tw, releases}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, want one of %s", format, strings.Join(listFormats, ", "))
+}
+
+type csvListWriter struct {
+	w        *csv.Writer
+	releases bool
+}
+
+func (l *csvListWriter) write(e listEntry) error {
+	return l.w.Write(e.record(l.releases))
+}
+
+func (l *csvListWriter) close() error {
+	l.w.Flush()
+	return l.w.Error()
+}
+
+// jsonListWriter writes a JSON array with an entry per line.
+type jsonListWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (l *jsonListWriter) write(e listEntry) error {
+	b, err := e.marshal()
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if !l.started {
+		prefix = "[\n"
+		l.started = true
+	}
+	_, err = fmt.Fprintf(l.w, "%s%s", prefix, b)
+	return err
+}
+
+func (l *jsonListWriter) close() error {
+	end := "\n]\n"
+	if !l.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(l.w, end)
+	return err
+}
+
+type ndjsonListWriter struct {
+	w io.Writer
+}
+
+func (l *ndjsonListWriter) write(e listEntry) error {
+	b, err := e.marshal()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(l.w, "%s\n", b)
+	return err
+}
+
+func (l *ndjsonListWriter) close() error {
+	return nil
+}
+
+// tableListWriter aligns the columns, so nothing is written until close.
+type tableListWriter struct {
+	w        *tabwriter.Writer
+	releases bool
+}
+
+func (l *tableListWriter) write(e listEntry) error {
+	record := e.record(l.releases)
+	for i, s := range record {
+		// Tabs and newlines would break the columns.
+		record[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(s)
+	}
+	_, err := fmt.Fprintln(l.w, strings.Join(record, "\t"))
+	return err
+}
+
+func (l *tableListWriter) close() error {
+	return l.w.Flush()
+}
+
 func listCommand() cli.Command {
 	return cli.Command{
-		Name: "list",
+		Name:      "list",
+		Usage:     "list the classes in directories, class files and archives like jar, war and ear",
+		ArgsUsage: "<directory or file>...",
+		Flags: append(append([]cli.Flag{
+			cli.StringFlag{Name: "format", Value: "csv", Usage: "output format: " + strings.Join(listFormats, ", ")},
+			cli.BoolFlag{Name: "strict", Usage: "reject class files with malformed attributes or constants not allowed in their version"},
+			cli.IntFlag{Name: "jobs", Value: runtime.GOMAXPROCS(0), Usage: "number of class files parsed and archives read in parallel"},
+			cli.BoolFlag{Name: "unordered", Usage: "write the classes as they are parsed instead of in the order of the arguments"},
+		}, limitFlags...), walkFlags...),
 		Action: func(c *cli.Context) error {
-			w := csv.NewWriter(os.Stdout)
-			w.Write([]string{"file", "class_type", "name", "super_name", "interfaces"})
-			for _, file := range c.Args() {
-				r, err := zip.OpenReader(file)
-				if err != nil {
-					return err
-				}
-				defer r.Close()
-				for _, entry := range r.File {
-					if !strings.HasSuffix(entry.Name, ".class") {
-						continue
-					}
-					if entry.FileInfo().IsDir() {
-						continue
-					}
-					entryReader, err := entry.Open()
-					if err != nil {
-						log.Printf("cannot open class file.  classfile=%s, jar=%s err=%v", entry.Name, file, err)
-						return err
-					}
-
-					c, err := parser.ReadClass(entryReader)
-					if err != nil {
-						log.Printf("failed to parse %s: %v", entry.Name, err)
-						continue
-					}
-					record := make([]string, 0)
-					record = append(record, file)
-					{
-						var t string
-						switch {
-						case c.IsInterface():
-							t = "interface"
-						case c.IsAnnotation():
-							t = "annotation"
-						case c.IsEnum():
-							t = "enum"
-						case c.IsAbstract():
-							t = "abstract"
-						default:
-							t = "class"
-						}
-						record = append(record, t)
-					}
-					record = append(record, c.Name())
-					record = append(record, c.SuperClassName())
-					record = append(record, strings.Join(c.Interfaces(), ", "))
-
-					w.Write(record)
-					w.Flush()
-				}
-
+			out := bufio.NewWriter(os.Stdout)
+			defer out.Flush()
+			w, err := newListWriter(c.String("format"), out, c.Bool("all-releases"))
+			if err != nil {
+				return err
+			}
+			l := lister{
+				opts:      walkOptions(c),
+				strict:    c.Bool("strict"),
+				limits:    parseLimits(c),
+				jobs:      c.Int("jobs"),
+				unordered: c.Bool("unordered"),
 			}
-			return nil
+			if err := l.list(c.Args(), w.write); err != nil {
+				return err
+			}
+			return w.close()
 		},
 	}
 }
+
+// newListEntry describes the class c read from file.
+func newListEntry(file string, c *parser.Class) listEntry {
+	e := listEntry{
+		File:       file,
+		Name:       c.Name(),
+		SuperName:  c.SuperClassName(),
+		Interfaces: c.Interfaces(),
+	}
+	switch {
+	case c.IsInterface():
+		e.ClassType = "interface"
+	case c.IsAnnotation():
+		e.ClassType = "annotation"
+	case c.IsEnum():
+		e.ClassType = "enum"
+	case c.IsAbstract():
+		e.ClassType = "abstract"
+	default:
+		e.ClassType = "class"
+	}
+	if sig, err := c.Signature(); err != nil {
+		log.Printf("invalid signature of %s: %v", file, err)
+	} else if sig != nil {
+		e.Signature = sig.Java(false)
+	}
+	return e
+}
@@ -0,0 +1,215 @@
+package command
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"go-javap/classpath"
+	"go-javap/parser"
+)
+
+// listJob is a class file read by a walk, which a worker parses.
+type listJob struct {
+	arg, seq int
+	path     string
+	release  int
+	data     []byte
+}
+
+// listResult is the entry of a parsed class file, or nil for a corrupt one.
+// A walk ends with a result with done set, seq as the number of its class files
+// and err as the error of the walk.
+type listResult struct {
+	arg, seq int
+	entry    *listEntry
+	done     bool
+	err      error
+}
+
+// errListStopped stops the walks after an error.
+var errListStopped = errors.New("stopped")
+
+// defaultListWindow is the number of class files a walk reads ahead of the ones written.
+const defaultListWindow = 256
+
+// lister lists the classes of the args with the class files read by up to jobs walks,
+// one for each arg, and parsed by jobs workers.
+type lister struct {
+	opts      classpath.Options
+	strict    bool
+	limits    parser.Limits
+	jobs      int
+	unordered bool
+	// window is the number of class files each walk reads ahead of the ones written,
+	// which bounds the memory held by results waiting for those before them.
+	// Zero is defaultListWindow.
+	window int
+}
+
+// list calls write for each class. Unless unordered, the classes are written in the order
+// of the args and the walks. The first error stops the list and is returned.
+func (l lister) list(args []string, write func(listEntry) error) error {
+	jobs := l.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	window := l.window
+	if window < 1 {
+		window = defaultListWindow
+	}
+	stop := make(chan struct{})
+	jobCh := make(chan listJob, jobs)
+	results := make(chan listResult, jobs)
+	// Each walk takes a slot of its window for a class file, which is freed once the class
+	// is written. A window for each walk lets the first walk run while the others wait.
+	windows := make([]chan struct{}, len(args))
+	for i := range windows {
+		windows[i] = make(chan struct{}, window)
+	}
+
+	argCh := make(chan int)
+	go func() {
+		defer close(argCh)
+		for i := range args {
+			select {
+			case argCh <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var walkers sync.WaitGroup
+	for i := 0; i < jobs && i < len(args); i++ {
+		walkers.Add(1)
+		go func() {
+			defer walkers.Done()
+			for arg := range argCh {
+				n, err := l.walk(arg, args[arg], jobCh, windows[arg], stop)
+				results <- listResult{arg: arg, seq: n, done: true, err: err}
+			}
+		}()
+	}
+	var workers sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobCh {
+				results <- listResult{arg: job.arg, seq: job.seq, entry: l.parse(job)}
+			}
+		}()
+	}
+	go func() {
+		walkers.Wait()
+		close(jobCh)
+		workers.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			close(stop)
+		}
+	}
+	// The results of the ordered list wait in pending until those before them are written.
+	pending := map[[2]int]listResult{}
+	next := [2]int{}
+	for r := range results {
+		if firstErr != nil {
+			// Drain the results until the walks and the workers end.
+			continue
+		}
+		if l.unordered {
+			if !r.done {
+				<-windows[r.arg]
+			}
+			switch {
+			case r.err != nil && r.err != errListStopped:
+				fail(r.err)
+			case r.entry != nil:
+				if err := write(*r.entry); err != nil {
+					fail(err)
+				}
+			}
+			continue
+		}
+		pending[[2]int{r.arg, r.seq}] = r
+		for firstErr == nil {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			switch {
+			case r.done && r.err != nil:
+				fail(r.err)
+			case r.done:
+				next = [2]int{next[0] + 1, 0}
+			default:
+				<-windows[r.arg]
+				if r.entry != nil {
+					if err := write(*r.entry); err != nil {
+						fail(err)
+					}
+				}
+				next[1]++
+			}
+		}
+	}
+	return firstErr
+}
+
+// walk reads the class files of arg into jobs and returns their number.
+// Each class file takes a slot of window before it is read.
+func (l lister) walk(arg int, path string, jobCh chan<- listJob, window chan struct{}, stop <-chan struct{}) (int, error) {
+	n := 0
+	err := classpath.WalkWithOptions(path, l.opts, func(entry classpath.Entry, err error) error {
+		if err != nil {
+			log.Printf("cannot read %s: %v", entry.Path, err)
+			return nil
+		}
+		select {
+		case window <- struct{}{}:
+		case <-stop:
+			return errListStopped
+		}
+		data, err := entry.ReadAll(l.limits.MaxTotalBytes)
+		if err != nil {
+			<-window
+		}
+		var limitErr *parser.LimitError
+		if errors.As(err, &limitErr) {
+			// Like a class file rejected by the parser.
+			log.Printf("corrupt class file %s: %v", entry.Path, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case jobCh <- listJob{arg, n, entry.Path, entry.Release, data}:
+			n++
+			return nil
+		case <-stop:
+			return errListStopped
+		}
+	})
+	return n, err
+}
+
+// parse reads the class of job, skipping the members which a list entry does not need.
+func (l lister) parse(job listJob) *listEntry {
+	opts := parseOptions(l.strict, l.limits, job.path)
+	opts.SkipMembers = true
+	classFile, err := parser.ReadBytesWithOptions(job.data, opts)
+	if err != nil {
+		log.Printf("corrupt class file %s: %v", job.path, err)
+		return nil
+	}
+	e := newListEntry(job.path, parser.NewClass(classFile))
+	e.Release = job.release
+	return &e
+}
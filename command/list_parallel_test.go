@@ -0,0 +1,135 @@
+package command
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+
+	"go-javap/parser"
+)
+
+// classBytes returns a class file of the class name with some fields and methods.
+func classBytes(tb testing.TB, name string) []byte {
+	b := parser.NewClassBuilder(name)
+	init := b.Pool().Methodref("java/lang/Object", "<init>", "()V")
+	b.Super("java/lang/Object").
+		Implements("java/lang/Runnable").
+		AddMethod(parser.MethodAccessPublic, "<init>", "()V", b.Code(1, 1, []byte{0x2A, 0xB7, byte(init >> 8), byte(init), 0xB1})).
+		AddAttribute(b.SourceFile("Foo.java"), b.Signature("<T:Ljava/lang/Object;>Ljava/lang/Object;Ljava/lang/Runnable;"))
+	for i := 0; i < 20; i++ {
+		b.AddField(parser.FieldAccessPrivate, fmt.Sprintf("field%d", i), "Ljava/util/List;", b.Signature("Ljava/util/List<TT;>;"))
+		b.AddMethod(parser.MethodAccessPublic|parser.MethodAccessAbstract, fmt.Sprintf("method%d", i), "(ILjava/lang/String;)Ljava/util/Map;", b.Exceptions("java/io/IOException"))
+	}
+	data, err := b.Bytes()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+// writeJars writes jars jars with classes classes each into dir and returns their paths.
+func writeJars(tb testing.TB, dir string, jars, classes int) []string {
+	var paths []string
+	for i := 0; i < jars; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("lib%d.jar", i))
+		f, err := os.Create(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		w := zip.NewWriter(f)
+		for j := 0; j < classes; j++ {
+			name := fmt.Sprintf("com/acme/lib%d/Class%d", i, j)
+			e, err := w.Create(name + ".class")
+			if err != nil {
+				tb.Fatal(err)
+			}
+			e.Write(classBytes(tb, name))
+		}
+		if err := w.Close(); err != nil {
+			tb.Fatal(err)
+		}
+		f.Close()
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestLister(t *testing.T) {
+	dir := t.TempDir()
+	args := writeJars(t, dir, 3, 20)
+	corrupt := filepath.Join(dir, "Corrupt.class")
+	if err := ioutil.WriteFile(corrupt, []byte{0xCA, 0xFE}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	args = append(args, corrupt, args[0])
+
+	var want []string
+	if err := (lister{jobs: 1}).list(args, func(e listEntry) error {
+		want = append(want, e.Name)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 80 || want[0] != "com/acme/lib0/Class0" || want[79] != "com/acme/lib0/Class19" {
+		t.Fatalf("list(jobs=1) = %d entries %q", len(want), want)
+	}
+	for _, l := range []lister{{jobs: 4}, {jobs: 100}, {jobs: 4, unordered: true}, {jobs: 4, window: 1}, {jobs: 100, window: 2, unordered: true}} {
+		var got []string
+		if err := l.list(args, func(e listEntry) error {
+			got = append(got, e.Name)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		want := want
+		if l.unordered {
+			want = append([]string{}, want...)
+			sort.Strings(want)
+			sort.Strings(got)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("list(%+v) = %q, want %q", l, got, want)
+		}
+	}
+
+	// Classes before a missing file are written.
+	var got []string
+	err := (lister{jobs: 4}).list([]string{args[0], filepath.Join(dir, "missing.jar"), args[1]}, func(e listEntry) error {
+		got = append(got, e.Name)
+		return nil
+	})
+	if err == nil || len(got) != 20 {
+		t.Errorf("list(missing.jar) wrote %d entries, error = %v", len(got), err)
+	}
+	stop := fmt.Errorf("stop")
+	if err := (lister{jobs: 4}).list(args, func(listEntry) error { return stop }); err != stop {
+		t.Errorf("list() error = %v, want the error of write", err)
+	}
+}
+
+func BenchmarkLister(b *testing.B) {
+	args := writeJars(b, b.TempDir(), 8, 500)
+	for _, l := range []lister{
+		{jobs: 1},
+		{jobs: runtime.GOMAXPROCS(0)},
+		{jobs: runtime.GOMAXPROCS(0), unordered: true},
+	} {
+		name := fmt.Sprintf("jobs=%d", l.jobs)
+		if l.unordered {
+			name += "/unordered"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := l.list(args, func(listEntry) error { return nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
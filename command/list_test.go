@@ -0,0 +1,61 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestListWriter(t *testing.T) {
+	entries := []listEntry{
+		{File: "a.jar", ClassType: "class", Name: "com/acme/Foo", SuperName: "java/lang/Object", Interfaces: []string{"java/lang/Runnable", "java/io/Serializable"}},
+		{File: "a.jar", ClassType: "interface", Name: "com/acme/Bar", SuperName: "java/lang/Object", Interfaces: []string{}, Signature: "<T> java.lang.Object"},
+	}
+	versioned := []listEntry{
+		{File: "a.jar!/com/acme/Foo.class", ClassType: "class", Name: "com/acme/Foo", SuperName: "java/lang/Object", Interfaces: []string{}},
+		{File: "a.jar!/META-INF/versions/11/com/acme/Foo.class", ClassType: "class", Name: "com/acme/Foo", SuperName: "java/lang/Object", Interfaces: []string{}, Release: 11},
+	}
+	tests := []struct {
+		format   string
+		releases bool
+		entries  []listEntry
+		want     string
+	}{
+		{"csv", false, entries, "file,class_type,name,super_name,interfaces,signature\n" +
+			"a.jar,class,com/acme/Foo,java/lang/Object,\"java/lang/Runnable, java/io/Serializable\",\n" +
+			"a.jar,interface,com/acme/Bar,java/lang/Object,,<T> java.lang.Object\n"},
+		{"tsv", false, entries[:1], "file\tclass_type\tname\tsuper_name\tinterfaces\tsignature\n" +
+			"a.jar\tclass\tcom/acme/Foo\tjava/lang/Object\tjava/lang/Runnable, java/io/Serializable\t\n"},
+		{"json", false, entries, "[\n" +
+			`{"file":"a.jar","class_type":"class","name":"com/acme/Foo","super_name":"java/lang/Object","interfaces":["java/lang/Runnable","java/io/Serializable"],"signature":""},` + "\n" +
+			`{"file":"a.jar","class_type":"interface","name":"com/acme/Bar","super_name":"java/lang/Object","interfaces":[],"signature":"<T> java.lang.Object"}` + "\n]\n"},
+		{"json", false, nil, "[]\n"},
+		{"ndjson", false, entries[1:], `{"file":"a.jar","class_type":"interface","name":"com/acme/Bar","super_name":"java/lang/Object","interfaces":[],"signature":"<T> java.lang.Object"}` + "\n"},
+		{"table", false, entries[1:], "FILE   CLASS_TYPE  NAME          SUPER_NAME        INTERFACES  SIGNATURE\n" +
+			"a.jar  interface   com/acme/Bar  java/lang/Object              <T> java.lang.Object\n"},
+		{"csv", true, versioned, "file,class_type,name,super_name,interfaces,signature,release\n" +
+			"a.jar!/com/acme/Foo.class,class,com/acme/Foo,java/lang/Object,,,\n" +
+			"a.jar!/META-INF/versions/11/com/acme/Foo.class,class,com/acme/Foo,java/lang/Object,,,11\n"},
+		{"ndjson", true, versioned[1:], `{"file":"a.jar!/META-INF/versions/11/com/acme/Foo.class","class_type":"class","name":"com/acme/Foo","super_name":"java/lang/Object","interfaces":[],"signature":"","release":11}` + "\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := newListWriter(tt.format, &buf, tt.releases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range tt.entries {
+			if err := w.write(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s output =\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+	if _, err := newListWriter("xml", &bytes.Buffer{}, false); err == nil {
+		t.Error("newListWriter(xml) error = nil")
+	}
+}
@@ -0,0 +1,274 @@
+// Package descriptor parses and renders field and method descriptors (JVMS 4.3).
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// Kind is the base type of a field type, using the descriptor character.
+	Kind byte
+
+	// Type is a field type or a method return type.
+	Type struct {
+		Kind Kind
+		// ClassName is the internal binary name like java/lang/String if Kind is Object.
+		ClassName string
+		// Dimensions is the number of array dimensions.
+		Dimensions int
+	}
+
+	Method struct {
+		Parameters []Type
+		Return     Type
+	}
+)
+
+const (
+	Byte    Kind = 'B'
+	Char    Kind = 'C'
+	Double  Kind = 'D'
+	Float   Kind = 'F'
+	Int     Kind = 'I'
+	Long    Kind = 'J'
+	Short   Kind = 'S'
+	Boolean Kind = 'Z'
+	Void    Kind = 'V'
+	Object  Kind = 'L'
+
+	// MaxDimensions is the maximum number of array dimensions allowed by the JVMS.
+	MaxDimensions = 255
+	// MaxParameterSlots is the maximum number of local variable slots the parameters of a method may use.
+	MaxParameterSlots = 255
+)
+
+// ParseField parses a field descriptor like [Ljava/lang/String;.
+func ParseField(s string) (Type, error) {
+	p := parser{s: s}
+	t := p.fieldType()
+	if p.err == nil && p.pos != len(s) {
+		p.fail("unexpected trailing characters")
+	}
+	if p.err != nil {
+		return Type{}, p.err
+	}
+	return t, nil
+}
+
+// ParseMethod parses a method descriptor like (I[Ljava/lang/String;J)V.
+func ParseMethod(s string) (Method, error) {
+	p := parser{s: s}
+	m := Method{Parameters: make([]Type, 0)}
+	if !p.consume('(') {
+		p.fail("expected '('")
+	}
+	slots := 0
+	for p.err == nil && !p.consume(')') {
+		if p.pos == len(s) {
+			p.fail("expected ')'")
+			break
+		}
+		t := p.fieldType()
+		slots += t.Slots()
+		m.Parameters = append(m.Parameters, t)
+	}
+	if p.err == nil && slots > MaxParameterSlots {
+		p.fail("parameters use %d slots, more than %d", slots, MaxParameterSlots)
+	}
+	if p.err == nil {
+		if p.consume(byte(Void)) {
+			m.Return = Type{Kind: Void}
+		} else {
+			m.Return = p.fieldType()
+		}
+	}
+	if p.err == nil && p.pos != len(s) {
+		p.fail("unexpected trailing characters")
+	}
+	if p.err != nil {
+		return Method{}, p.err
+	}
+	return m, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid descriptor %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) fieldType() Type {
+	var t Type
+	for p.consume('[') {
+		t.Dimensions++
+	}
+	if t.Dimensions > MaxDimensions {
+		p.fail("%d array dimensions, more than %d", t.Dimensions, MaxDimensions)
+		return t
+	}
+	if p.pos == len(p.s) {
+		p.fail("unexpected end of descriptor")
+		return t
+	}
+	t.Kind = Kind(p.s[p.pos])
+	switch t.Kind {
+	case Byte, Char, Double, Float, Int, Long, Short, Boolean:
+		p.pos++
+	case Object:
+		p.pos++
+		end := strings.IndexByte(p.s[p.pos:], ';')
+		if end < 0 {
+			p.fail("unterminated class name")
+			return t
+		}
+		t.ClassName = p.s[p.pos : p.pos+end]
+		if !validClassName(t.ClassName) {
+			p.fail("invalid class name %q", t.ClassName)
+			return t
+		}
+		p.pos += end + 1
+	default:
+		p.fail("unexpected character %q", p.s[p.pos])
+	}
+	return t
+}
+
+// validClassName checks an internal binary name as used in descriptors.
+func validClassName(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || strings.ContainsAny(part, ".;[<>") {
+			return false
+		}
+	}
+	return true
+}
+
+// Slots returns the number of local variable slots used by a value of the type.
+func (t Type) Slots() int {
+	switch {
+	case t.Kind == Void:
+		return 0
+	case t.Dimensions == 0 && (t.Kind == Long || t.Kind == Double):
+		return 2
+	}
+	return 1
+}
+
+// IsPrimitive reports whether the type is a primitive type or void.
+func (t Type) IsPrimitive() bool {
+	return t.Dimensions == 0 && t.Kind != Object
+}
+
+// Elem returns the element type of an array type.
+func (t Type) Elem() Type {
+	if t.Dimensions > 0 {
+		t.Dimensions--
+	}
+	return t
+}
+
+// Descriptor encodes the type back to its descriptor form.
+func (t Type) Descriptor() string {
+	s := strings.Repeat("[", t.Dimensions)
+	if t.Kind == Object {
+		return s + "L" + t.ClassName + ";"
+	}
+	return s + string(t.Kind)
+}
+
+// Java renders the type as Java source, e.g. java.lang.String[].
+// If short is true, package names are omitted as if the classes were imported.
+func (t Type) Java(short bool) string {
+	var name string
+	switch t.Kind {
+	case Byte:
+		name = "byte"
+	case Char:
+		name = "char"
+	case Double:
+		name = "double"
+	case Float:
+		name = "float"
+	case Int:
+		name = "int"
+	case Long:
+		name = "long"
+	case Short:
+		name = "short"
+	case Boolean:
+		name = "boolean"
+	case Void:
+		name = "void"
+	case Object:
+		if short {
+			name = ShortName(t.ClassName)
+		} else {
+			name = JavaName(t.ClassName)
+		}
+	}
+	return name + strings.Repeat("[]", t.Dimensions)
+}
+
+func (t Type) String() string {
+	return t.Java(false)
+}
+
+// Descriptor encodes the method type back to its descriptor form.
+func (m Method) Descriptor() string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, p := range m.Parameters {
+		b.WriteString(p.Descriptor())
+	}
+	b.WriteByte(')')
+	b.WriteString(m.Return.Descriptor())
+	return b.String()
+}
+
+// ParameterSlots returns the number of local variable slots used by the parameters, excluding this.
+func (m Method) ParameterSlots() int {
+	slots := 0
+	for _, p := range m.Parameters {
+		slots += p.Slots()
+	}
+	return slots
+}
+
+// JavaParameters renders the parameter types as Java source.
+func (m Method) JavaParameters(short bool) []string {
+	params := make([]string, len(m.Parameters))
+	for i, p := range m.Parameters {
+		params[i] = p.Java(short)
+	}
+	return params
+}
+
+// Java renders the method as a Java source declaration like void m(int, java.lang.String[], long).
+func (m Method) Java(name string, short bool) string {
+	return m.Return.Java(short) + " " + name + "(" + strings.Join(m.JavaParameters(short), ", ") + ")"
+}
+
+// JavaName converts an internal binary name like java/lang/String to java.lang.String.
+func JavaName(internalName string) string {
+	return strings.Replace(internalName, "/", ".", -1)
+}
+
+// ShortName returns the class name without its package, like String for java/lang/String.
+func ShortName(internalName string) string {
+	return internalName[strings.LastIndexByte(internalName, '/')+1:]
+}
@@ -0,0 +1,219 @@
+package descriptor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Type
+		java    string
+		short   string
+		wantErr bool
+	}{
+		{name: "int", s: "I", want: Type{Kind: Int}, java: "int", short: "int"},
+		{name: "long", s: "J", want: Type{Kind: Long}, java: "long", short: "long"},
+		{name: "boolean", s: "Z", want: Type{Kind: Boolean}, java: "boolean", short: "boolean"},
+		{
+			name:  "object",
+			s:     "Ljava/lang/String;",
+			want:  Type{Kind: Object, ClassName: "java/lang/String"},
+			java:  "java.lang.String",
+			short: "String",
+		},
+		{
+			name:  "default package",
+			s:     "LFoo;",
+			want:  Type{Kind: Object, ClassName: "Foo"},
+			java:  "Foo",
+			short: "Foo",
+		},
+		{
+			name:  "inner class",
+			s:     "Ljava/util/Map$Entry;",
+			want:  Type{Kind: Object, ClassName: "java/util/Map$Entry"},
+			java:  "java.util.Map$Entry",
+			short: "Map$Entry",
+		},
+		{name: "primitive array", s: "[[D", want: Type{Kind: Double, Dimensions: 2}, java: "double[][]", short: "double[][]"},
+		{
+			name:  "object array",
+			s:     "[Ljava/lang/Object;",
+			want:  Type{Kind: Object, ClassName: "java/lang/Object", Dimensions: 1},
+			java:  "java.lang.Object[]",
+			short: "Object[]",
+		},
+		{
+			name:  "max dimensions",
+			s:     strings.Repeat("[", 255) + "B",
+			want:  Type{Kind: Byte, Dimensions: 255},
+			java:  "byte" + strings.Repeat("[]", 255),
+			short: "byte" + strings.Repeat("[]", 255),
+		},
+		{name: "empty", s: "", wantErr: true},
+		{name: "void", s: "V", wantErr: true},
+		{name: "void array", s: "[V", wantErr: true},
+		{name: "unknown character", s: "X", wantErr: true},
+		{name: "lower case", s: "i", wantErr: true},
+		{name: "array without element", s: "[", wantErr: true},
+		{name: "too many dimensions", s: strings.Repeat("[", 256) + "B", wantErr: true},
+		{name: "trailing characters", s: "II", wantErr: true},
+		{name: "trailing after object", s: "Ljava/lang/String;I", wantErr: true},
+		{name: "unterminated class", s: "Ljava/lang/String", wantErr: true},
+		{name: "bare L", s: "L", wantErr: true},
+		{name: "empty class name", s: "L;", wantErr: true},
+		{name: "dotted class name", s: "Ljava.lang.String;", wantErr: true},
+		{name: "empty package segment", s: "Ljava//String;", wantErr: true},
+		{name: "leading slash", s: "L/String;", wantErr: true},
+		{name: "trailing slash", s: "Ljava/;", wantErr: true},
+		{name: "array in class name", s: "Ljava/[String;", wantErr: true},
+		{name: "generic class name", s: "Ljava/util/List<TT;>;", wantErr: true},
+		{name: "method descriptor", s: "()V", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseField(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseField(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseField(%q) = %#v, want %#v", tt.s, got, tt.want)
+			}
+			if d := got.Descriptor(); d != tt.s {
+				t.Errorf("Descriptor() = %q, want %q", d, tt.s)
+			}
+			if j := got.Java(false); j != tt.java {
+				t.Errorf("Java(false) = %q, want %q", j, tt.java)
+			}
+			if j := got.Java(true); j != tt.short {
+				t.Errorf("Java(true) = %q, want %q", j, tt.short)
+			}
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Method
+		java    string
+		short   string
+		slots   int
+		wantErr bool
+	}{
+		{
+			name:  "no parameters",
+			s:     "()V",
+			want:  Method{Parameters: []Type{}, Return: Type{Kind: Void}},
+			java:  "void m()",
+			short: "void m()",
+		},
+		{
+			name: "mixed parameters",
+			s:    "(I[Ljava/lang/String;J)V",
+			want: Method{
+				Parameters: []Type{
+					{Kind: Int},
+					{Kind: Object, ClassName: "java/lang/String", Dimensions: 1},
+					{Kind: Long},
+				},
+				Return: Type{Kind: Void},
+			},
+			java:  "void m(int, java.lang.String[], long)",
+			short: "void m(int, String[], long)",
+			slots: 4,
+		},
+		{
+			name: "object return",
+			s:    "(D[J)Ljava/util/List;",
+			want: Method{
+				Parameters: []Type{{Kind: Double}, {Kind: Long, Dimensions: 1}},
+				Return:     Type{Kind: Object, ClassName: "java/util/List"},
+			},
+			java:  "java.util.List m(double, long[])",
+			short: "List m(double, long[])",
+			slots: 3,
+		},
+		{
+			name:  "array return",
+			s:     "()[[I",
+			want:  Method{Parameters: []Type{}, Return: Type{Kind: Int, Dimensions: 2}},
+			java:  "int[][] m()",
+			short: "int[][] m()",
+		},
+		{
+			name: "max parameter slots",
+			s:    "(" + strings.Repeat("J", 127) + "I)V",
+			want: Method{
+				Parameters: append(repeatType(Type{Kind: Long}, 127), Type{Kind: Int}),
+				Return:     Type{Kind: Void},
+			},
+			java:  "void m(" + strings.Repeat("long, ", 127) + "int)",
+			short: "void m(" + strings.Repeat("long, ", 127) + "int)",
+			slots: 255,
+		},
+		{name: "empty", s: "", wantErr: true},
+		{name: "field descriptor", s: "I", wantErr: true},
+		{name: "missing open paren", s: "I)V", wantErr: true},
+		{name: "missing close paren", s: "(I", wantErr: true},
+		{name: "missing close paren before return", s: "(IV", wantErr: true},
+		{name: "missing return", s: "(I)", wantErr: true},
+		{name: "void parameter", s: "(V)V", wantErr: true},
+		{name: "void array return", s: "()[V", wantErr: true},
+		{name: "two returns", s: "()VV", wantErr: true},
+		{name: "trailing after return", s: "()Ljava/lang/String;;", wantErr: true},
+		{name: "unterminated parameter class", s: "(Ljava/lang/String)V", wantErr: true},
+		{name: "bad parameter", s: "(Q)V", wantErr: true},
+		{name: "nested parens", s: "(()V)V", wantErr: true},
+		{name: "too many parameter slots", s: "(" + strings.Repeat("J", 128) + ")V", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMethod(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMethod(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMethod(%q) = %#v, want %#v", tt.s, got, tt.want)
+			}
+			if d := got.Descriptor(); d != tt.s {
+				t.Errorf("Descriptor() = %q, want %q", d, tt.s)
+			}
+			if j := got.Java("m", false); j != tt.java {
+				t.Errorf("Java(false) = %q, want %q", j, tt.java)
+			}
+			if j := got.Java("m", true); j != tt.short {
+				t.Errorf("Java(true) = %q, want %q", j, tt.short)
+			}
+			if s := got.ParameterSlots(); s != tt.slots {
+				t.Errorf("ParameterSlots() = %d, want %d", s, tt.slots)
+			}
+		})
+	}
+}
+
+func TestParseErrorOffset(t *testing.T) {
+	_, err := ParseMethod("(ILjava/lang/String;X)V")
+	if err == nil || !strings.Contains(err.Error(), "offset 20") {
+		t.Errorf("ParseMethod() error = %v, want offset 20", err)
+	}
+}
+
+func repeatType(t Type, n int) []Type {
+	types := make([]Type, n)
+	for i := range types {
+		types[i] = t
+	}
+	return types
+}
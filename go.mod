@@ -1,3 +1,5 @@
 module go-javap
 
+go 1.18
+
 require github.com/urfave/cli v1.20.0
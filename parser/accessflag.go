@@ -16,6 +16,7 @@ const (
 	AccessSynthetic  = 0x1000
 	AccessAnnotation = 0x2000
 	AccessEnum       = 0x4000
+	AccessModule     = 0x8000
 )
 
 func (a AccessFlags) Public() bool {
@@ -47,36 +48,65 @@ func (a AccessFlags) Annotation() bool {
 }
 
 func (a AccessFlags) Enum() bool {
-	return a.is(AccessAnnotation)
+	return a.is(AccessEnum)
+}
+
+func (a AccessFlags) Module() bool {
+	return a.is(AccessModule)
+}
+
+// classFlagNames are the names of the flags in the order they are written.
+var classFlagNames = []flagName{
+	{AccessPublic, "public"},
+	{AccessFinal, "final"},
+	{AccessSuper, "super"},
+	{AccessInterface, "interface"},
+	{AccessAbstract, "abstract"},
+	{AccessSynthetic, "synthetic"},
+	{AccessAnnotation, "annotation"},
+	{AccessEnum, "enum"},
+	{AccessModule, "module"},
 }
 
 func (a AccessFlags) String() string {
-	flags := make([]string, 0)
-	if a.Public() {
-		flags = append(flags, "public")
-	}
-	if a.Final() {
-		flags = append(flags, "final")
-	}
-	if a.Super() {
-		flags = append(flags, "super")
-	}
-	if a.Interface() {
-		flags = append(flags, "interface")
-	}
-	if a.Abstract() {
-		flags = append(flags, "abstract")
-	}
-	if a.Synthetic() {
-		flags = append(flags, "synthetic")
-	}
-	if a.Annotation() {
-		flags = append(flags, "annotation")
+	return fmt.Sprintf("AccessFlags[%s]", strings.Join(flagNames(uint16(a), classFlagNames), ", "))
+}
+
+// Names returns the lower case names of the flags like public, in the order they are written.
+func (a AccessFlags) Names() []string {
+	return flagNames(uint16(a), classFlagNames)
+}
+
+// ClassAccessFlag returns the class flag with the name returned by AccessFlags.Names.
+func ClassAccessFlag(name string) (AccessFlags, bool) {
+	flag, ok := flagByName(name, classFlagNames)
+	return AccessFlags(flag), ok
+}
+
+// flagName is the name of an access flag.
+type flagName struct {
+	flag uint16
+	name string
+}
+
+// flagNames returns the names of the flags set in flags. Bits without a name are left out.
+func flagNames(flags uint16, names []flagName) []string {
+	s := make([]string, 0)
+	for _, n := range names {
+		if flags&n.flag != 0 {
+			s = append(s, n.name)
+		}
 	}
-	if a.Enum() {
-		flags = append(flags, "enum")
+	return s
+}
+
+func flagByName(name string, names []flagName) (uint16, bool) {
+	for _, n := range names {
+		if n.name == name {
+			return n.flag, true
+		}
 	}
-	return fmt.Sprintf("AccessFlags[%s]", strings.Join(flags, ", "))
+	return 0, false
 }
 
 func (a AccessFlags) is(n uint16) bool {
@@ -0,0 +1,698 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go-javap/descriptor"
+)
+
+type (
+	RuntimeVisibleAnnotationsAttribute struct {
+		AttributeHeader
+		Annotations []AnnotationInfo
+	}
+
+	RuntimeInvisibleAnnotationsAttribute struct {
+		AttributeHeader
+		Annotations []AnnotationInfo
+	}
+
+	RuntimeVisibleParameterAnnotationsAttribute struct {
+		AttributeHeader
+		ParameterAnnotations [][]AnnotationInfo
+	}
+
+	RuntimeInvisibleParameterAnnotationsAttribute struct {
+		AttributeHeader
+		ParameterAnnotations [][]AnnotationInfo
+	}
+
+	RuntimeVisibleTypeAnnotationsAttribute struct {
+		AttributeHeader
+		Annotations []TypeAnnotationInfo
+	}
+
+	RuntimeInvisibleTypeAnnotationsAttribute struct {
+		AttributeHeader
+		Annotations []TypeAnnotationInfo
+	}
+
+	AnnotationDefaultAttribute struct {
+		AttributeHeader
+		DefaultValue ElementValueInfo
+	}
+
+	AnnotationInfo struct {
+		TypeIndex         uint16
+		ElementValuePairs []ElementValuePair
+	}
+
+	ElementValuePair struct {
+		ElementNameIndex uint16
+		Value            ElementValueInfo
+	}
+
+	// ElementValueInfo is a node of an annotation value tree.
+	// Which fields are used depends on Tag.
+	ElementValueInfo struct {
+		Tag ElementValueTag
+		// ConstValueIndex is used by the primitive tags and 's'.
+		ConstValueIndex uint16
+		// TypeNameIndex and ConstNameIndex are used by 'e'.
+		TypeNameIndex  uint16
+		ConstNameIndex uint16
+		// ClassInfoIndex is used by 'c'.
+		ClassInfoIndex uint16
+		// AnnotationValue is used by '@'.
+		AnnotationValue *AnnotationInfo
+		// Values is used by '['.
+		Values []ElementValueInfo
+	}
+
+	ElementValueTag uint8
+
+	TypeAnnotationInfo struct {
+		TargetType uint8
+		TargetInfo TypeAnnotationTarget
+		TargetPath []TypePathEntry
+		AnnotationInfo
+	}
+
+	// TypeAnnotationTarget is the target_info union. Which fields are used depends on the target type.
+	TypeAnnotationTarget struct {
+		TypeParameterIndex   uint8
+		SupertypeIndex       uint16
+		BoundIndex           uint8
+		FormalParameterIndex uint8
+		ThrowsTypeIndex      uint16
+		LocalVariables       []LocalVariableTarget
+		ExceptionTableIndex  uint16
+		Offset               uint16
+		TypeArgumentIndex    uint8
+	}
+
+	LocalVariableTarget struct {
+		StartPC uint16
+		Length  uint16
+		Index   uint16
+	}
+
+	TypePathEntry struct {
+		TypePathKind      uint8
+		TypeArgumentIndex uint8
+	}
+)
+
+const (
+	ElementValueByte       ElementValueTag = 'B'
+	ElementValueChar       ElementValueTag = 'C'
+	ElementValueDouble     ElementValueTag = 'D'
+	ElementValueFloat      ElementValueTag = 'F'
+	ElementValueInt        ElementValueTag = 'I'
+	ElementValueLong       ElementValueTag = 'J'
+	ElementValueShort      ElementValueTag = 'S'
+	ElementValueBoolean    ElementValueTag = 'Z'
+	ElementValueString     ElementValueTag = 's'
+	ElementValueEnum       ElementValueTag = 'e'
+	ElementValueClass      ElementValueTag = 'c'
+	ElementValueAnnotation ElementValueTag = '@'
+	ElementValueArray      ElementValueTag = '['
+)
+
+func init() {
+	RegisterAttribute("RuntimeVisibleAnnotations", decodeRuntimeVisibleAnnotations)
+	RegisterAttribute("RuntimeInvisibleAnnotations", decodeRuntimeInvisibleAnnotations)
+	RegisterAttribute("RuntimeVisibleParameterAnnotations", decodeRuntimeVisibleParameterAnnotations)
+	RegisterAttribute("RuntimeInvisibleParameterAnnotations", decodeRuntimeInvisibleParameterAnnotations)
+	RegisterAttribute("RuntimeVisibleTypeAnnotations", decodeRuntimeVisibleTypeAnnotations)
+	RegisterAttribute("RuntimeInvisibleTypeAnnotations", decodeRuntimeInvisibleTypeAnnotations)
+	RegisterAttribute("AnnotationDefault", decodeAnnotationDefault)
+}
+
+func decodeRuntimeVisibleAnnotations(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RuntimeVisibleAnnotationsAttribute{AttributeHeader{nameIndex}, r.annotations()}
+	return a, r.finish()
+}
+
+func decodeRuntimeInvisibleAnnotations(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RuntimeInvisibleAnnotationsAttribute{AttributeHeader{nameIndex}, r.annotations()}
+	return a, r.finish()
+}
+
+func decodeRuntimeVisibleParameterAnnotations(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RuntimeVisibleParameterAnnotationsAttribute{AttributeHeader{nameIndex}, r.parameterAnnotations()}
+	return a, r.finish()
+}
+
+func decodeRuntimeInvisibleParameterAnnotations(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RuntimeInvisibleParameterAnnotationsAttribute{AttributeHeader{nameIndex}, r.parameterAnnotations()}
+	return a, r.finish()
+}
+
+func decodeRuntimeVisibleTypeAnnotations(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RuntimeVisibleTypeAnnotationsAttribute{AttributeHeader{nameIndex}, r.typeAnnotations()}
+	return a, r.finish()
+}
+
+func decodeRuntimeInvisibleTypeAnnotations(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RuntimeInvisibleTypeAnnotationsAttribute{AttributeHeader{nameIndex}, r.typeAnnotations()}
+	return a, r.finish()
+}
+
+func decodeAnnotationDefault(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &AnnotationDefaultAttribute{AttributeHeader{nameIndex}, r.elementValue()}
+	return a, r.finish()
+}
+
+func (r *attributeReader) annotations() []AnnotationInfo {
+	count := r.u2()
+	if !r.check(int(count) * 4) {
+		return nil
+	}
+	annotations := make([]AnnotationInfo, count)
+	for i := range annotations {
+		annotations[i] = r.annotation()
+	}
+	return annotations
+}
+
+func (r *attributeReader) parameterAnnotations() [][]AnnotationInfo {
+	count := r.u1()
+	if !r.check(int(count) * 2) {
+		return nil
+	}
+	parameters := make([][]AnnotationInfo, count)
+	for i := range parameters {
+		parameters[i] = r.annotations()
+	}
+	return parameters
+}
+
+func (r *attributeReader) annotation() AnnotationInfo {
+	a := AnnotationInfo{TypeIndex: r.u2()}
+	count := r.u2()
+	if !r.check(int(count) * 3) {
+		return a
+	}
+	a.ElementValuePairs = make([]ElementValuePair, count)
+	for i := range a.ElementValuePairs {
+		a.ElementValuePairs[i] = ElementValuePair{r.u2(), r.elementValue()}
+	}
+	return a
+}
+
+// MaxElementValueDepth is the deepest nesting of element values in arrays and annotations.
+// Deeper element values fail to decode with a *LimitError.
+const MaxElementValueDepth = 256
+
+func (r *attributeReader) elementValue() ElementValueInfo {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > MaxElementValueDepth {
+		if r.err == nil {
+			r.err = &LimitError{LimitElementValueDepth, int64(r.depth), MaxElementValueDepth}
+		}
+		return ElementValueInfo{}
+	}
+	v := ElementValueInfo{Tag: ElementValueTag(r.u1())}
+	switch v.Tag {
+	case ElementValueByte, ElementValueChar, ElementValueDouble, ElementValueFloat, ElementValueInt,
+		ElementValueLong, ElementValueShort, ElementValueBoolean, ElementValueString:
+		v.ConstValueIndex = r.u2()
+	case ElementValueEnum:
+		v.TypeNameIndex = r.u2()
+		v.ConstNameIndex = r.u2()
+	case ElementValueClass:
+		v.ClassInfoIndex = r.u2()
+	case ElementValueAnnotation:
+		a := r.annotation()
+		v.AnnotationValue = &a
+	case ElementValueArray:
+		count := r.u2()
+		if r.check(int(count)) {
+			v.Values = make([]ElementValueInfo, count)
+			for i := range v.Values {
+				v.Values[i] = r.elementValue()
+			}
+		}
+	default:
+		if r.err == nil {
+			r.fail("unknown element value tag %q", rune(v.Tag))
+		}
+	}
+	return v
+}
+
+func (r *attributeReader) typeAnnotations() []TypeAnnotationInfo {
+	count := r.u2()
+	if !r.check(int(count) * 6) {
+		return nil
+	}
+	annotations := make([]TypeAnnotationInfo, count)
+	for i := range annotations {
+		a := &annotations[i]
+		a.TargetType = r.u1()
+		t := &a.TargetInfo
+		switch a.TargetType {
+		case 0x00, 0x01:
+			t.TypeParameterIndex = r.u1()
+		case 0x10:
+			t.SupertypeIndex = r.u2()
+		case 0x11, 0x12:
+			t.TypeParameterIndex = r.u1()
+			t.BoundIndex = r.u1()
+		case 0x13, 0x14, 0x15:
+		case 0x16:
+			t.FormalParameterIndex = r.u1()
+		case 0x17:
+			t.ThrowsTypeIndex = r.u2()
+		case 0x40, 0x41:
+			length := r.u2()
+			if r.check(int(length) * 6) {
+				t.LocalVariables = make([]LocalVariableTarget, length)
+				for j := range t.LocalVariables {
+					t.LocalVariables[j] = LocalVariableTarget{r.u2(), r.u2(), r.u2()}
+				}
+			}
+		case 0x42:
+			t.ExceptionTableIndex = r.u2()
+		case 0x43, 0x44, 0x45, 0x46:
+			t.Offset = r.u2()
+		case 0x47, 0x48, 0x49, 0x4A, 0x4B:
+			t.Offset = r.u2()
+			t.TypeArgumentIndex = r.u1()
+		default:
+			r.fail("unknown type annotation target type 0x%02X", a.TargetType)
+			return nil
+		}
+		pathLength := r.u1()
+		if r.check(int(pathLength) * 2) {
+			a.TargetPath = make([]TypePathEntry, pathLength)
+			for j := range a.TargetPath {
+				a.TargetPath[j] = TypePathEntry{r.u1(), r.u1()}
+			}
+		}
+		a.AnnotationInfo = r.annotation()
+	}
+	return annotations
+}
+
+type (
+	// Annotation is an annotation with its constant pool references resolved.
+	Annotation struct {
+		// Type is the field descriptor of the annotation type like Ljava/lang/Deprecated;.
+		Type string
+		// Visible reports whether the annotation is retained at run time.
+		Visible  bool
+		Elements []AnnotationElement
+	}
+
+	// AnnotationElement is an element value pair of an annotation.
+	// Value is one of int8, uint16 (char), float64, float32, int32, int64, int16, bool, string,
+	// AnnotationEnum, AnnotationClass, Annotation or []interface{} for arrays.
+	AnnotationElement struct {
+		Name  string
+		Value interface{}
+	}
+
+	AnnotationEnum struct {
+		// Type is the field descriptor of the enum type.
+		Type string
+		Name string
+	}
+
+	AnnotationClass struct {
+		// Descriptor is the return descriptor of the class, like Ljava/lang/String; or V.
+		Descriptor string
+	}
+)
+
+// resolveAnnotations resolves the runtime visible and invisible annotations in attributes.
+func resolveAnnotations(attributes []Attribute, pool ConstantPool) []Annotation {
+	annotations := make([]Annotation, 0)
+	for _, a := range attributes {
+		switch a := a.(type) {
+		case *RuntimeVisibleAnnotationsAttribute:
+			for _, info := range a.Annotations {
+				annotations = append(annotations, resolveAnnotation(info, true, pool))
+			}
+		case *RuntimeInvisibleAnnotationsAttribute:
+			for _, info := range a.Annotations {
+				annotations = append(annotations, resolveAnnotation(info, false, pool))
+			}
+		}
+	}
+	return annotations
+}
+
+func resolveAnnotation(info AnnotationInfo, visible bool, pool ConstantPool) Annotation {
+	a := Annotation{
+		Type:     pool.GetUTF8(info.TypeIndex),
+		Visible:  visible,
+		Elements: make([]AnnotationElement, len(info.ElementValuePairs)),
+	}
+	for i, pair := range info.ElementValuePairs {
+		a.Elements[i] = AnnotationElement{pool.GetUTF8(pair.ElementNameIndex), resolveElementValue(pair.Value, visible, pool)}
+	}
+	return a
+}
+
+func resolveElementValue(v ElementValueInfo, visible bool, pool ConstantPool) interface{} {
+	switch v.Tag {
+	case ElementValueString:
+		return pool.GetUTF8(v.ConstValueIndex)
+	case ElementValueEnum:
+		return AnnotationEnum{pool.GetUTF8(v.TypeNameIndex), pool.GetUTF8(v.ConstNameIndex)}
+	case ElementValueClass:
+		return AnnotationClass{pool.GetUTF8(v.ClassInfoIndex)}
+	case ElementValueAnnotation:
+		if v.AnnotationValue == nil {
+			return nil
+		}
+		return resolveAnnotation(*v.AnnotationValue, visible, pool)
+	case ElementValueArray:
+		values := make([]interface{}, len(v.Values))
+		for i, value := range v.Values {
+			values[i] = resolveElementValue(value, visible, pool)
+		}
+		return values
+	}
+	return constantValue(pool, v.ConstValueIndex, string(v.Tag))
+}
+
+// resolveParameterAnnotations resolves the runtime visible and invisible parameter annotations
+// in attributes, by parameter index. It returns nil if there are none.
+func resolveParameterAnnotations(attributes []Attribute, pool ConstantPool) [][]Annotation {
+	var parameters [][]Annotation
+	add := func(infos [][]AnnotationInfo, visible bool) {
+		for len(parameters) < len(infos) {
+			parameters = append(parameters, make([]Annotation, 0))
+		}
+		for i, annotations := range infos {
+			for _, info := range annotations {
+				parameters[i] = append(parameters[i], resolveAnnotation(info, visible, pool))
+			}
+		}
+	}
+	for _, a := range attributes {
+		switch a := a.(type) {
+		case *RuntimeVisibleParameterAnnotationsAttribute:
+			add(a.ParameterAnnotations, true)
+		case *RuntimeInvisibleParameterAnnotationsAttribute:
+			add(a.ParameterAnnotations, false)
+		}
+	}
+	return parameters
+}
+
+// resolveAnnotationDefault resolves the AnnotationDefault attribute in attributes,
+// or returns nil if there is none. Nested annotations in the value are resolved as visible
+// since the attribute does not tell their retention.
+func resolveAnnotationDefault(attributes []Attribute, pool ConstantPool) interface{} {
+	for _, a := range attributes {
+		if a, ok := a.(*AnnotationDefaultAttribute); ok {
+			return resolveElementValue(a.DefaultValue, true, pool)
+		}
+	}
+	return nil
+}
+
+// Java renders the annotation as Java source like @Produces({"application/json"}).
+// If short is true, package names are omitted as if the classes were imported.
+func (a Annotation) Java(short bool) string {
+	var b strings.Builder
+	b.WriteString("@")
+	b.WriteString(typeJava(a.Type, short))
+	if len(a.Elements) == 0 {
+		return b.String()
+	}
+	b.WriteString("(")
+	if len(a.Elements) == 1 && a.Elements[0].Name == "value" {
+		b.WriteString(AnnotationValueJava(a.Elements[0].Value, short))
+	} else {
+		for i, e := range a.Elements {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(e.Name)
+			b.WriteString(" = ")
+			b.WriteString(AnnotationValueJava(e.Value, short))
+		}
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (a Annotation) String() string {
+	return a.Java(false)
+}
+
+// AnnotationsJava renders the annotations as Java source separated by spaces,
+// like @Path("/users") @Produces({"application/json"}).
+func AnnotationsJava(annotations []Annotation, short bool) string {
+	s := make([]string, len(annotations))
+	for i, a := range annotations {
+		s[i] = a.Java(short)
+	}
+	return strings.Join(s, " ")
+}
+
+// AnnotationValueJava renders an element value of an annotation as a Java expression,
+// like "a", 1L, java.util.concurrent.TimeUnit.SECONDS, String.class or {1, 2}.
+func AnnotationValueJava(value interface{}, short bool) string {
+	switch v := value.(type) {
+	case string:
+		return javaQuote(v, '"')
+	case uint16:
+		if v >= 0xD800 && v <= 0xDFFF {
+			return fmt.Sprintf(`'\u%04x'`, v)
+		}
+		return javaQuote(string(rune(v)), '\'')
+	case int64:
+		return strconv.FormatInt(v, 10) + "L"
+	case float32:
+		return floatJava(float64(v), 32)
+	case float64:
+		return floatJava(v, 64)
+	case AnnotationEnum:
+		return typeJava(v.Type, short) + "." + v.Name
+	case AnnotationClass:
+		if v.Descriptor == "V" {
+			return "void.class"
+		}
+		return typeJava(v.Descriptor, short) + ".class"
+	case Annotation:
+		return v.Java(short)
+	case []interface{}:
+		s := make([]string, len(v))
+		for i, e := range v {
+			s[i] = AnnotationValueJava(e, short)
+		}
+		return "{" + strings.Join(s, ", ") + "}"
+	}
+	return fmt.Sprint(value)
+}
+
+// typeJava renders a field descriptor as Java source, or returns it as it is if it is invalid.
+func typeJava(desc string, short bool) string {
+	t, err := descriptor.ParseField(desc)
+	if err != nil {
+		return desc
+	}
+	return t.Java(short)
+}
+
+// floatJava renders a float or double literal, using the constants of Float or Double
+// for the values without one.
+func floatJava(v float64, bitSize int) string {
+	class, suffix := "Double", ""
+	if bitSize == 32 {
+		class, suffix = "Float", "f"
+	}
+	switch {
+	case math.IsNaN(v):
+		return class + ".NaN"
+	case math.IsInf(v, 1):
+		return class + ".POSITIVE_INFINITY"
+	case math.IsInf(v, -1):
+		return class + ".NEGATIVE_INFINITY"
+	}
+	return FormatFloat(v, bitSize) + suffix
+}
+
+// javaQuote quotes s as a Java string or char literal.
+func javaQuote(s string, quote rune) string {
+	var b strings.Builder
+	b.WriteRune(quote)
+	for _, r := range s {
+		switch r {
+		case quote, '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7F {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteRune(quote)
+	return b.String()
+}
+
+func (a RuntimeVisibleAnnotationsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.annotations(a.Annotations)
+	return w.finish()
+}
+
+func (a RuntimeInvisibleAnnotationsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.annotations(a.Annotations)
+	return w.finish()
+}
+
+func (a RuntimeVisibleParameterAnnotationsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.parameterAnnotations(a.ParameterAnnotations)
+	return w.finish()
+}
+
+func (a RuntimeInvisibleParameterAnnotationsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.parameterAnnotations(a.ParameterAnnotations)
+	return w.finish()
+}
+
+func (a RuntimeVisibleTypeAnnotationsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.typeAnnotations(a.Annotations)
+	return w.finish()
+}
+
+func (a RuntimeInvisibleTypeAnnotationsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.typeAnnotations(a.Annotations)
+	return w.finish()
+}
+
+func (a AnnotationDefaultAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.elementValue(a.DefaultValue)
+	return w.finish()
+}
+
+func (w *attributeWriter) annotations(annotations []AnnotationInfo) {
+	w.count(len(annotations), "annotations")
+	for _, a := range annotations {
+		w.annotation(a)
+	}
+}
+
+func (w *attributeWriter) parameterAnnotations(parameters [][]AnnotationInfo) {
+	w.count1(len(parameters), "parameters")
+	for _, annotations := range parameters {
+		w.annotations(annotations)
+	}
+}
+
+func (w *attributeWriter) annotation(a AnnotationInfo) {
+	w.u2(a.TypeIndex)
+	w.count(len(a.ElementValuePairs), "element value pairs")
+	for _, p := range a.ElementValuePairs {
+		w.u2(p.ElementNameIndex)
+		w.elementValue(p.Value)
+	}
+}
+
+func (w *attributeWriter) elementValue(v ElementValueInfo) {
+	w.u1(uint8(v.Tag))
+	switch v.Tag {
+	case ElementValueByte, ElementValueChar, ElementValueDouble, ElementValueFloat, ElementValueInt,
+		ElementValueLong, ElementValueShort, ElementValueBoolean, ElementValueString:
+		w.u2(v.ConstValueIndex)
+	case ElementValueEnum:
+		w.u2(v.TypeNameIndex)
+		w.u2(v.ConstNameIndex)
+	case ElementValueClass:
+		w.u2(v.ClassInfoIndex)
+	case ElementValueAnnotation:
+		if v.AnnotationValue == nil {
+			w.fail("annotation element value without annotation")
+			return
+		}
+		w.annotation(*v.AnnotationValue)
+	case ElementValueArray:
+		w.count(len(v.Values), "array values")
+		for _, value := range v.Values {
+			w.elementValue(value)
+		}
+	default:
+		w.fail("unknown element value tag %q", rune(v.Tag))
+	}
+}
+
+func (w *attributeWriter) typeAnnotations(annotations []TypeAnnotationInfo) {
+	w.count(len(annotations), "type annotations")
+	for _, a := range annotations {
+		w.u1(a.TargetType)
+		t := a.TargetInfo
+		switch a.TargetType {
+		case 0x00, 0x01:
+			w.u1(t.TypeParameterIndex)
+		case 0x10:
+			w.u2(t.SupertypeIndex)
+		case 0x11, 0x12:
+			w.u1(t.TypeParameterIndex)
+			w.u1(t.BoundIndex)
+		case 0x13, 0x14, 0x15:
+		case 0x16:
+			w.u1(t.FormalParameterIndex)
+		case 0x17:
+			w.u2(t.ThrowsTypeIndex)
+		case 0x40, 0x41:
+			w.count(len(t.LocalVariables), "local variable targets")
+			for _, v := range t.LocalVariables {
+				w.u2(v.StartPC)
+				w.u2(v.Length)
+				w.u2(v.Index)
+			}
+		case 0x42:
+			w.u2(t.ExceptionTableIndex)
+		case 0x43, 0x44, 0x45, 0x46:
+			w.u2(t.Offset)
+		case 0x47, 0x48, 0x49, 0x4A, 0x4B:
+			w.u2(t.Offset)
+			w.u1(t.TypeArgumentIndex)
+		default:
+			w.fail("unknown type annotation target type 0x%02X", a.TargetType)
+		}
+		w.count1(len(a.TargetPath), "type path entries")
+		for _, p := range a.TargetPath {
+			w.u1(p.TypePathKind)
+			w.u1(p.TypeArgumentIndex)
+		}
+		w.annotation(a.AnnotationInfo)
+	}
+}
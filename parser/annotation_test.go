@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClass_Annotations(t *testing.T) {
+	got := AnnotationsJava(testClass().Annotations(), false)
+	if want := "@javax.inject.Singleton"; got != want {
+		t.Errorf("Annotations() = %s, want %s", got, want)
+	}
+}
+
+func TestAnnotation_Java(t *testing.T) {
+	path := Annotation{Type: "Ljavax/ws/rs/Path;", Elements: []AnnotationElement{{"value", "/users"}}}
+	produces := Annotation{Type: "Ljavax/ws/rs/Produces;", Elements: []AnnotationElement{{"value", []interface{}{"application/json"}}}}
+	mapping := Annotation{Type: "Lorg/springframework/web/bind/annotation/RequestMapping;", Elements: []AnnotationElement{
+		{"path", []interface{}{"/a", "/b"}},
+		{"method", []interface{}{AnnotationEnum{"Lorg/springframework/web/bind/annotation/RequestMethod;", "GET"}}},
+	}}
+	tests := []struct {
+		name  string
+		value interface{}
+		short string
+		long  string
+	}{
+		{"marker", Annotation{Type: "Ljava/lang/Deprecated;"}, "@Deprecated", "@java.lang.Deprecated"},
+		{"value", path, `@Path("/users")`, `@javax.ws.rs.Path("/users")`},
+		{"array", produces, `@Produces({"application/json"})`, `@javax.ws.rs.Produces({"application/json"})`},
+		{"elements", mapping,
+			`@RequestMapping(path = {"/a", "/b"}, method = {RequestMethod.GET})`,
+			`@org.springframework.web.bind.annotation.RequestMapping(path = {"/a", "/b"}, method = {org.springframework.web.bind.annotation.RequestMethod.GET})`},
+		{"nested", Annotation{Type: "LPaths;", Elements: []AnnotationElement{{"value", []interface{}{path}}}}, `@Paths({@Path("/users")})`, `@Paths({@javax.ws.rs.Path("/users")})`},
+		{"empty array", []interface{}{}, "{}", "{}"},
+		{"string", "a\"b\\c\n\x00é", `"a\"b\\c\n\u0000é"`, `"a\"b\\c\n\u0000é"`},
+		{"char", uint16('\''), `'\''`, `'\''`},
+		{"surrogate", uint16(0xD800), `'\ud800'`, `'\ud800'`},
+		{"int", int32(-1), "-1", "-1"},
+		{"byte", int8(1), "1", "1"},
+		{"long", int64(1) << 40, "1099511627776L", "1099511627776L"},
+		{"boolean", true, "true", "true"},
+		{"float", float32(1.5), "1.5f", "1.5f"},
+		{"float integral", float32(2), "2.0f", "2.0f"},
+		{"double", 1e100, "1.0E100", "1.0E100"},
+		{"small double", 1e-5, "1.0E-5", "1.0E-5"},
+		{"NaN", math.NaN(), "Double.NaN", "Double.NaN"},
+		{"infinity", float32(math.Inf(-1)), "Float.NEGATIVE_INFINITY", "Float.NEGATIVE_INFINITY"},
+		{"class", AnnotationClass{"Ljava/lang/String;"}, "String.class", "java.lang.String.class"},
+		{"array class", AnnotationClass{"[I"}, "int[].class", "int[].class"},
+		{"void class", AnnotationClass{"V"}, "void.class", "void.class"},
+	}
+	for _, tt := range tests {
+		if got := AnnotationValueJava(tt.value, true); got != tt.short {
+			t.Errorf("%s: AnnotationValueJava(short) = %s, want %s", tt.name, got, tt.short)
+		}
+		if got := AnnotationValueJava(tt.value, false); got != tt.long {
+			t.Errorf("%s: AnnotationValueJava() = %s, want %s", tt.name, got, tt.long)
+		}
+	}
+	if got, want := AnnotationsJava([]Annotation{path, produces}, true), `@Path("/users") @Produces({"application/json"})`; got != want {
+		t.Errorf("AnnotationsJava() = %s, want %s", got, want)
+	}
+}
+
+func TestResolveElementValue_NilAnnotation(t *testing.T) {
+	value := ElementValueInfo{Tag: ElementValueArray, Values: []ElementValueInfo{{Tag: ElementValueAnnotation}}}
+	if got, want := resolveElementValue(value, true, nil), []interface{}{nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveElementValue() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeAttribute_ElementValueDepth(t *testing.T) {
+	pool := ConstantPool{ConstantUtf8Info{[]byte("AnnotationDefault")}}
+	nested := func(depth int) []byte {
+		var info []byte
+		for i := 1; i < depth; i++ {
+			info = append(info, '[', 0x00, 0x01)
+		}
+		return append(info, 'Z', 0x00, 0x01)
+	}
+	if _, err := decodeAttribute(1, nested(MaxElementValueDepth), pool); err != nil {
+		t.Errorf("decodeAttribute(depth %d) error = %v", MaxElementValueDepth, err)
+	}
+	a, err := decodeAttribute(1, nested(1000000), pool)
+	if err == nil || !strings.Contains(err.Error(), "element value nesting depth 257 exceeds the limit 256") {
+		t.Errorf("decodeAttribute(depth 1000000) error = %v, want a limit error", err)
+	}
+	if _, ok := a.(*AttributeInfo); !ok {
+		t.Errorf("decodeAttribute(depth 1000000) = %T, want *AttributeInfo", a)
+	}
+}
@@ -3,26 +3,234 @@ package parser
 import (
 	"encoding/hex"
 	"fmt"
+	"sync"
 )
 
-type AttributeInfo struct {
-	NameIndex uint16
-	Attribute []byte
+type (
+	// Attribute is implemented by every decoded attribute.
+	// Attributes without a registered decoder are kept as *AttributeInfo.
+	Attribute interface {
+		AttributeNameIndex() uint16
+	}
+
+	// Attributes is an attributes table, which is written to JSON with the name of each attribute.
+	Attributes []Attribute
+
+	// AttributeDecoder decodes the info bytes of an attribute.
+	AttributeDecoder func(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error)
+
+	AttributeInfo struct {
+		NameIndex uint16
+		Attribute []byte
+	}
+
+	// AttributeHeader is embedded by typed attributes to keep the name index for writing.
+	AttributeHeader struct {
+		NameIndex uint16 `json:"-"`
+	}
+)
+
+var (
+	attributeDecodersMutex sync.RWMutex
+	attributeDecoders      = map[string]AttributeDecoder{}
+)
+
+// RegisterAttribute registers a decoder for attributes with the given name.
+// It replaces any decoder previously registered for the name, including the standard ones.
+func RegisterAttribute(name string, decoder AttributeDecoder) {
+	attributeDecodersMutex.Lock()
+	defer attributeDecodersMutex.Unlock()
+	if decoder == nil {
+		delete(attributeDecoders, name)
+		return
+	}
+	attributeDecoders[name] = decoder
+}
+
+func lookupAttributeDecoder(name string) (AttributeDecoder, bool) {
+	attributeDecodersMutex.RLock()
+	defer attributeDecodersMutex.RUnlock()
+	decoder, ok := attributeDecoders[name]
+	return decoder, ok
+}
+
+func (a *AttributeInfo) AttributeNameIndex() uint16 {
+	return a.NameIndex
 }
 
 func (a AttributeInfo) String() string {
 	return fmt.Sprintf("AttributeInfo[nameIndex=%d, attribute=%s]", a.NameIndex, hex.EncodeToString(a.Attribute))
 }
 
-func readAttribute(r *Reader) ([]AttributeInfo, error) {
-	attributesCount, _ := r.Read16()
-	attributes := make([]AttributeInfo, attributesCount)
-	for j := uint16(0); j < attributesCount; j++ {
-		attributeNameIndex, _ := r.Read16()
-		attributeLength, _ := r.Read32()
-		info := make([]byte, attributeLength)
-		r.ReadBytes(info)
-		attributes = append(attributes, AttributeInfo{attributeNameIndex, info})
+func (h AttributeHeader) AttributeNameIndex() uint16 {
+	return h.NameIndex
+}
+
+// AttributeName resolves the name of the attribute.
+func (p ConstantPool) AttributeName(a Attribute) string {
+	if !p.has(a.AttributeNameIndex()) {
+		return ""
+	}
+	return p.GetUTF8(a.AttributeNameIndex())
+}
+
+// decodeAttribute decodes an attribute with the decoder registered for its name.
+// A malformed attribute is returned as an *AttributeInfo with the error. An attribute
+// with malformed nested attributes is decoded with them kept raw, and returned with the error.
+func decodeAttribute(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	var name string
+	if pool.has(nameIndex) {
+		name = pool.GetUTF8(nameIndex)
+	}
+	decoder, ok := lookupAttributeDecoder(name)
+	if !ok {
+		return &AttributeInfo{nameIndex, info}, nil
+	}
+	a, err := decoder(nameIndex, info, pool)
+	if err != nil {
+		if _, ok := err.(nestedError); !ok || a == nil {
+			a = &AttributeInfo{nameIndex, info}
+		}
+		return a, fmt.Errorf("failed to decode %s attribute: %v", name, err)
+	}
+	return a, nil
+}
+
+// attributeReader reads the info of an attribute.
+// The first error is kept and returned by finish, so decoders don't need to check each read.
+type attributeReader struct {
+	data []byte
+	pos  int
+	err  error
+	// depth is the nesting of the element value being read.
+	depth int
+	// nested is the error of the first malformed nested attribute.
+	nested error
+}
+
+// nestedError is the error of a malformed attribute nested in a decoded one,
+// like a LineNumberTable in a Code attribute.
+type nestedError struct {
+	err error
+}
+
+func (e nestedError) Error() string {
+	return e.err.Error()
+}
+
+func newAttributeReader(data []byte) *attributeReader {
+	return &attributeReader{data: data}
+}
+
+func (r *attributeReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
 	}
-	return nil, nil
+}
+
+func (r *attributeReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data)-r.pos {
+		r.fail("unexpected end of attribute at offset %d", r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *attributeReader) u1() uint8 {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *attributeReader) u2() uint16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func (r *attributeReader) u4() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// bytes returns the next n bytes, which share the memory of the attribute
+// with their capacity limited to n.
+func (r *attributeReader) bytes(n int) []byte {
+	b := r.next(n)
+	if b == nil {
+		return nil
+	}
+	return b[:n:n]
+}
+
+// u2s reads a u2 length followed by that many u2 values.
+func (r *attributeReader) u2s() []uint16 {
+	n := r.u2()
+	if !r.check(int(n) * 2) {
+		return nil
+	}
+	values := make([]uint16, n)
+	for i := range values {
+		values[i] = r.u2()
+	}
+	return values
+}
+
+// check reports whether at least n bytes remain, which protects the
+// following allocation from corrupted counts.
+func (r *attributeReader) check(n int) bool {
+	if r.err == nil && n > len(r.data)-r.pos {
+		r.fail("count exceeds attribute length at offset %d", r.pos)
+	}
+	return r.err == nil
+}
+
+func (r *attributeReader) attributes(pool ConstantPool) []Attribute {
+	count := r.u2()
+	if !r.check(int(count) * 6) {
+		return nil
+	}
+	attributes := make([]Attribute, 0, count)
+	for i := uint16(0); i < count; i++ {
+		nameIndex := r.u2()
+		length := r.u4()
+		if !r.check(int(length)) {
+			return nil
+		}
+		info := r.bytes(int(length))
+		// A malformed attribute nested in another one is kept as raw bytes,
+		// and its error is returned with the outer attribute by finish.
+		a, err := decodeAttribute(nameIndex, info, pool)
+		if err != nil && r.nested == nil {
+			r.nested = nestedError{err}
+		}
+		attributes = append(attributes, a)
+	}
+	return attributes
+}
+
+func (r *attributeReader) finish() error {
+	if r.err == nil && r.pos != len(r.data) {
+		r.fail("%d trailing bytes in attribute", len(r.data)-r.pos)
+	}
+	if r.err == nil && r.nested != nil {
+		return r.nested
+	}
+	return r.err
+}
+
+func (a AttributeInfo) MarshalBinary() ([]byte, error) {
+	return a.Attribute, nil
 }
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeAttribute(t *testing.T) {
+	pool := ConstantPool{
+		ConstantUtf8Info{[]byte("SourceFile")},
+		ConstantUtf8Info{[]byte("Exceptions")},
+		ConstantUtf8Info{[]byte("Vendor")},
+		ConstantUtf8Info{[]byte("Deprecated")},
+		ConstantUtf8Info{[]byte("InnerClasses")},
+	}
+	tests := []struct {
+		name      string
+		nameIndex uint16
+		info      []byte
+		want      Attribute
+		wantErr   bool
+	}{
+		{
+			name:      "source file",
+			nameIndex: 1,
+			info:      []byte{0x00, 0x07},
+			want:      &SourceFileAttribute{AttributeHeader{1}, 7},
+		},
+		{
+			name:      "exceptions",
+			nameIndex: 2,
+			info:      []byte{0x00, 0x02, 0x00, 0x03, 0x00, 0x04},
+			want:      &ExceptionsAttribute{AttributeHeader{2}, []uint16{3, 4}},
+		},
+		{
+			name:      "unknown attribute is kept raw",
+			nameIndex: 3,
+			info:      []byte{0xCA, 0xFE},
+			want:      &AttributeInfo{3, []byte{0xCA, 0xFE}},
+		},
+		{
+			name:      "invalid name index is kept raw",
+			nameIndex: 100,
+			info:      []byte{0x01},
+			want:      &AttributeInfo{100, []byte{0x01}},
+		},
+		{
+			name:      "truncated",
+			nameIndex: 1,
+			info:      []byte{0x00},
+			wantErr:   true,
+		},
+		{
+			name:      "trailing bytes",
+			nameIndex: 4,
+			info:      []byte{0x00},
+			wantErr:   true,
+		},
+		{
+			name:      "count exceeds length",
+			nameIndex: 5,
+			info:      []byte{0xFF, 0xFF},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAttribute(tt.nameIndex, tt.info, pool)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeAttribute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// Malformed attributes are returned raw with the error.
+				tt.want = &AttributeInfo{tt.nameIndex, tt.info}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeAttribute() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+type vendorAttribute struct {
+	AttributeHeader
+	Value uint8
+}
+
+func TestRegisterAttribute(t *testing.T) {
+	pool := ConstantPool{ConstantUtf8Info{[]byte("com.acme.Vendor")}}
+	RegisterAttribute("com.acme.Vendor", func(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+		r := newAttributeReader(info)
+		a := &vendorAttribute{AttributeHeader{nameIndex}, r.u1()}
+		return a, r.finish()
+	})
+	defer RegisterAttribute("com.acme.Vendor", nil)
+
+	got, err := decodeAttribute(1, []byte{0x2A}, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&vendorAttribute{AttributeHeader{1}, 42}); !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeAttribute() = %#v, want %#v", got, want)
+	}
+
+	RegisterAttribute("com.acme.Vendor", nil)
+	got, err = decodeAttribute(1, []byte{0x2A}, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.(*AttributeInfo); !ok {
+		t.Errorf("decodeAttribute() = %T after unregistering, want *AttributeInfo", got)
+	}
+}
+
+func TestReadWithOptions_MalformedAttribute(t *testing.T) {
+	b := NewClassBuilder("Foo")
+	signature := &AttributeInfo{b.Pool().Utf8("Signature"), []byte{0x00, 0x01, 0xFF}}
+	sourceFile := &AttributeInfo{b.Pool().Utf8("SourceFile"), []byte{0x00}}
+	lines := &AttributeInfo{b.Pool().Utf8("LineNumberTable"), []byte{0x00, 0x05}}
+	b.AddMethod(MethodAccessStatic, "run", "()V", b.Code(0, 0, []byte{0xB1}, lines), signature).
+		AddAttribute(sourceFile)
+	data, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []*ParseError
+	c, err := ReadWithOptions(bytes.NewReader(data), ParseOptions{Warn: func(err *ParseError) {
+		warnings = append(warnings, err)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 3 {
+		t.Errorf("Warn called with %v, want the Code, Signature and SourceFile attributes", warnings)
+	}
+	if got := c.Attributes[0]; !reflect.DeepEqual(got, sourceFile) {
+		t.Errorf("class attribute = %#v, want %#v", got, sourceFile)
+	}
+	m := c.Methods[0]
+	if got := m.Attributes[1]; !reflect.DeepEqual(got, signature) {
+		t.Errorf("method attribute = %#v, want %#v", got, signature)
+	}
+	// The Code attribute is decoded with the malformed attribute in it kept raw.
+	if code := m.Code(); code == nil || !reflect.DeepEqual(code.Attributes, Attributes{lines}) {
+		t.Errorf("Code() = %#v, want the LineNumberTable kept raw", code)
+	}
+	var out bytes.Buffer
+	if err := Write(&out, c); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("Write() = %x, %v, want %x", out.Bytes(), err, data)
+	}
+
+	_, err = ReadWithOptions(bytes.NewReader(data), ParseOptions{Strict: true})
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("ReadWithOptions(Strict) error = %v, want a *ParseError", err)
+	}
+
+	// A malformed nested attribute alone is reported like the others.
+	b = NewClassBuilder("Foo")
+	lines = &AttributeInfo{b.Pool().Utf8("LineNumberTable"), []byte{0x00, 0x05}}
+	data, err = b.AddMethod(MethodAccessStatic, "run", "()V", b.Code(0, 0, []byte{0xB1}, lines)).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings = nil
+	if _, err := ReadWithOptions(bytes.NewReader(data), ParseOptions{Warn: func(err *ParseError) {
+		warnings = append(warnings, err)
+	}}); err != nil || len(warnings) != 1 || warnings[0].Path != "method[0].attribute[0]" {
+		t.Errorf("ReadWithOptions(nested) error = %v, warnings %v, want a warning for the Code attribute", err, warnings)
+	}
+	_, err = ReadWithOptions(bytes.NewReader(data), ParseOptions{Strict: true})
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("ReadWithOptions(nested, Strict) error = %v, want a *ParseError", err)
+	}
+}
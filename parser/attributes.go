@@ -0,0 +1,334 @@
+package parser
+
+type (
+	ConstantValueAttribute struct {
+		AttributeHeader
+		ConstantValueIndex uint16
+	}
+
+	ExceptionsAttribute struct {
+		AttributeHeader
+		ExceptionIndexTable []uint16
+	}
+
+	InnerClassesAttribute struct {
+		AttributeHeader
+		Classes []InnerClass
+	}
+
+	InnerClass struct {
+		InnerClassInfoIndex   uint16
+		OuterClassInfoIndex   uint16
+		InnerNameIndex        uint16
+		InnerClassAccessFlags uint16
+	}
+
+	EnclosingMethodAttribute struct {
+		AttributeHeader
+		ClassIndex  uint16
+		MethodIndex uint16
+	}
+
+	SyntheticAttribute struct {
+		AttributeHeader
+	}
+
+	DeprecatedAttribute struct {
+		AttributeHeader
+	}
+
+	SignatureAttribute struct {
+		AttributeHeader
+		SignatureIndex uint16
+	}
+
+	SourceFileAttribute struct {
+		AttributeHeader
+		SourceFileIndex uint16
+	}
+
+	SourceDebugExtensionAttribute struct {
+		AttributeHeader
+		DebugExtension []byte
+	}
+
+	BootstrapMethodsAttribute struct {
+		AttributeHeader
+		BootstrapMethods []BootstrapMethod
+	}
+
+	BootstrapMethod struct {
+		BootstrapMethodRef uint16
+		BootstrapArguments []uint16
+	}
+
+	NestHostAttribute struct {
+		AttributeHeader
+		HostClassIndex uint16
+	}
+
+	NestMembersAttribute struct {
+		AttributeHeader
+		Classes []uint16
+	}
+
+	PermittedSubclassesAttribute struct {
+		AttributeHeader
+		Classes []uint16
+	}
+
+	RecordAttribute struct {
+		AttributeHeader
+		Components []RecordComponentInfo
+	}
+
+	RecordComponentInfo struct {
+		NameIndex       uint16
+		DescriptorIndex uint16
+		Attributes      Attributes
+	}
+
+	MethodParametersAttribute struct {
+		AttributeHeader
+		Parameters []MethodParameter
+	}
+
+	MethodParameter struct {
+		NameIndex   uint16
+		AccessFlags uint16
+	}
+)
+
+func init() {
+	RegisterAttribute("ConstantValue", decodeConstantValue)
+	RegisterAttribute("Exceptions", decodeExceptions)
+	RegisterAttribute("InnerClasses", decodeInnerClasses)
+	RegisterAttribute("EnclosingMethod", decodeEnclosingMethod)
+	RegisterAttribute("Synthetic", decodeSynthetic)
+	RegisterAttribute("Deprecated", decodeDeprecated)
+	RegisterAttribute("Signature", decodeSignature)
+	RegisterAttribute("SourceFile", decodeSourceFile)
+	RegisterAttribute("SourceDebugExtension", decodeSourceDebugExtension)
+	RegisterAttribute("BootstrapMethods", decodeBootstrapMethods)
+	RegisterAttribute("NestHost", decodeNestHost)
+	RegisterAttribute("NestMembers", decodeNestMembers)
+	RegisterAttribute("PermittedSubclasses", decodePermittedSubclasses)
+	RegisterAttribute("Record", decodeRecord)
+	RegisterAttribute("MethodParameters", decodeMethodParameters)
+}
+
+func decodeConstantValue(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &ConstantValueAttribute{AttributeHeader{nameIndex}, r.u2()}
+	return a, r.finish()
+}
+
+func decodeExceptions(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &ExceptionsAttribute{AttributeHeader{nameIndex}, r.u2s()}
+	return a, r.finish()
+}
+
+func decodeInnerClasses(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &InnerClassesAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	count := r.u2()
+	if r.check(int(count) * 8) {
+		a.Classes = make([]InnerClass, count)
+		for i := range a.Classes {
+			a.Classes[i] = InnerClass{r.u2(), r.u2(), r.u2(), r.u2()}
+		}
+	}
+	return a, r.finish()
+}
+
+func decodeEnclosingMethod(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &EnclosingMethodAttribute{AttributeHeader{nameIndex}, r.u2(), r.u2()}
+	return a, r.finish()
+}
+
+func decodeSynthetic(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	return &SyntheticAttribute{AttributeHeader{nameIndex}}, newAttributeReader(info).finish()
+}
+
+func decodeDeprecated(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	return &DeprecatedAttribute{AttributeHeader{nameIndex}}, newAttributeReader(info).finish()
+}
+
+func decodeSignature(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &SignatureAttribute{AttributeHeader{nameIndex}, r.u2()}
+	return a, r.finish()
+}
+
+func decodeSourceFile(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &SourceFileAttribute{AttributeHeader{nameIndex}, r.u2()}
+	return a, r.finish()
+}
+
+func decodeSourceDebugExtension(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	return &SourceDebugExtensionAttribute{AttributeHeader{nameIndex}, info}, nil
+}
+
+func decodeBootstrapMethods(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &BootstrapMethodsAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	count := r.u2()
+	if r.check(int(count) * 4) {
+		a.BootstrapMethods = make([]BootstrapMethod, count)
+		for i := range a.BootstrapMethods {
+			a.BootstrapMethods[i] = BootstrapMethod{r.u2(), r.u2s()}
+		}
+	}
+	return a, r.finish()
+}
+
+func decodeNestHost(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &NestHostAttribute{AttributeHeader{nameIndex}, r.u2()}
+	return a, r.finish()
+}
+
+func decodeNestMembers(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &NestMembersAttribute{AttributeHeader{nameIndex}, r.u2s()}
+	return a, r.finish()
+}
+
+func decodePermittedSubclasses(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &PermittedSubclassesAttribute{AttributeHeader{nameIndex}, r.u2s()}
+	return a, r.finish()
+}
+
+func decodeRecord(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &RecordAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	count := r.u2()
+	if r.check(int(count) * 6) {
+		a.Components = make([]RecordComponentInfo, count)
+		for i := range a.Components {
+			a.Components[i] = RecordComponentInfo{r.u2(), r.u2(), r.attributes(pool)}
+		}
+	}
+	return a, r.finish()
+}
+
+func decodeMethodParameters(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &MethodParametersAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	count := r.u1()
+	if r.check(int(count) * 4) {
+		a.Parameters = make([]MethodParameter, count)
+		for i := range a.Parameters {
+			a.Parameters[i] = MethodParameter{r.u2(), r.u2()}
+		}
+	}
+	return a, r.finish()
+}
+
+func (a ConstantValueAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.ConstantValueIndex)
+	return w.finish()
+}
+
+func (a ExceptionsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2s(a.ExceptionIndexTable)
+	return w.finish()
+}
+
+func (a InnerClassesAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.Classes), "inner classes")
+	for _, c := range a.Classes {
+		w.u2(c.InnerClassInfoIndex)
+		w.u2(c.OuterClassInfoIndex)
+		w.u2(c.InnerNameIndex)
+		w.u2(c.InnerClassAccessFlags)
+	}
+	return w.finish()
+}
+
+func (a EnclosingMethodAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.ClassIndex)
+	w.u2(a.MethodIndex)
+	return w.finish()
+}
+
+func (a SyntheticAttribute) MarshalBinary() ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (a DeprecatedAttribute) MarshalBinary() ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (a SignatureAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.SignatureIndex)
+	return w.finish()
+}
+
+func (a SourceFileAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.SourceFileIndex)
+	return w.finish()
+}
+
+func (a SourceDebugExtensionAttribute) MarshalBinary() ([]byte, error) {
+	return a.DebugExtension, nil
+}
+
+func (a BootstrapMethodsAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.BootstrapMethods), "bootstrap methods")
+	for _, m := range a.BootstrapMethods {
+		w.u2(m.BootstrapMethodRef)
+		w.u2s(m.BootstrapArguments)
+	}
+	return w.finish()
+}
+
+func (a NestHostAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.HostClassIndex)
+	return w.finish()
+}
+
+func (a NestMembersAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2s(a.Classes)
+	return w.finish()
+}
+
+func (a PermittedSubclassesAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2s(a.Classes)
+	return w.finish()
+}
+
+func (a RecordAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.Components), "record components")
+	for _, c := range a.Components {
+		w.u2(c.NameIndex)
+		w.u2(c.DescriptorIndex)
+		w.attributes(c.Attributes)
+	}
+	return w.finish()
+}
+
+func (a MethodParametersAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count1(len(a.Parameters), "method parameters")
+	for _, p := range a.Parameters {
+		w.u2(p.NameIndex)
+		w.u2(p.AccessFlags)
+	}
+	return w.finish()
+}
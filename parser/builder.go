@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ClassBuilder builds a class file, managing the constant pool.
+//
+//	b := NewClassBuilder("com/acme/Foo")
+//	b.Super("java/lang/Object").
+//		AddField(FieldAccessPublic|FieldAccessStatic|FieldAccessFinal, "MAX", "I", b.ConstantValue(int32(10))).
+//		AddMethod(MethodAccessPublic|MethodAccessAbstract, "run", "()V")
+//	data, err := b.Bytes()
+type ClassBuilder struct {
+	pool  *ConstantPoolBuilder
+	class *ClassFile
+	// hasSuper is set by Super. Otherwise Build adds java/lang/Object as the super class.
+	hasSuper bool
+	err      error
+}
+
+// NewClassBuilder creates a builder of a public class with the internal name like com/acme/Foo.
+// The class extends java.lang.Object and targets Java 8 until changed.
+func NewClassBuilder(name string) *ClassBuilder {
+	return NewClassBuilderWithPool(name, NewConstantPoolBuilder())
+}
+
+// NewClassBuilderWithPool is NewClassBuilder adding the constants to pool,
+// which may have entries already, like the constants of a class being rebuilt.
+func NewClassBuilderWithPool(name string, pool *ConstantPoolBuilder) *ClassBuilder {
+	b := &ClassBuilder{pool: pool}
+	b.class = &ClassFile{
+		MajorVersion: 52,
+		AccessFlags:  AccessPublic | AccessSuper,
+		Interfaces:   []uint16{},
+		Fields:       []FieldInfo{},
+		Methods:      []MethodInfo{},
+		Attributes:   []Attribute{},
+	}
+	b.class.ThisClass = b.pool.Class(name)
+	return b
+}
+
+// Pool returns the constant pool builder to create constants referenced from code or custom attributes.
+func (b *ClassBuilder) Pool() *ConstantPoolBuilder {
+	return b.pool
+}
+
+func (b *ClassBuilder) Version(major, minor uint16) *ClassBuilder {
+	b.class.MajorVersion = major
+	b.class.MinorVersion = minor
+	return b
+}
+
+func (b *ClassBuilder) Access(flags AccessFlags) *ClassBuilder {
+	b.class.AccessFlags = flags
+	return b
+}
+
+// Super sets the super class. An empty name removes it, which is only valid for java.lang.Object and modules.
+func (b *ClassBuilder) Super(name string) *ClassBuilder {
+	b.hasSuper = true
+	if name == "" {
+		b.class.SuperClass = 0
+	} else {
+		b.class.SuperClass = b.pool.Class(name)
+	}
+	return b
+}
+
+func (b *ClassBuilder) Implements(names ...string) *ClassBuilder {
+	for _, name := range names {
+		b.class.Interfaces = append(b.class.Interfaces, b.pool.Class(name))
+	}
+	return b
+}
+
+func (b *ClassBuilder) AddField(flags FieldAccessFlags, name, descriptor string, attributes ...Attribute) *ClassBuilder {
+	b.class.Fields = append(b.class.Fields, FieldInfo{flags, b.pool.Utf8(name), b.pool.Utf8(descriptor), nonNil(attributes)})
+	return b
+}
+
+func (b *ClassBuilder) AddMethod(flags MethodAccessFlags, name, descriptor string, attributes ...Attribute) *ClassBuilder {
+	b.class.Methods = append(b.class.Methods, MethodInfo{flags, b.pool.Utf8(name), b.pool.Utf8(descriptor), nonNil(attributes)})
+	return b
+}
+
+// AddAttribute adds a class attribute.
+func (b *ClassBuilder) AddAttribute(attributes ...Attribute) *ClassBuilder {
+	b.class.Attributes = append(b.class.Attributes, attributes...)
+	return b
+}
+
+func (b *ClassBuilder) header(name string) AttributeHeader {
+	return AttributeHeader{b.pool.Utf8(name)}
+}
+
+func (b *ClassBuilder) SourceFile(name string) Attribute {
+	return &SourceFileAttribute{b.header("SourceFile"), b.pool.Utf8(name)}
+}
+
+func (b *ClassBuilder) Signature(signature string) Attribute {
+	return &SignatureAttribute{b.header("Signature"), b.pool.Utf8(signature)}
+}
+
+// ConstantValue creates a ConstantValue attribute of an int32, int64, float32, float64 or string value.
+// bool, int8, uint16 and int16 values are stored as int constants.
+func (b *ClassBuilder) ConstantValue(value interface{}) Attribute {
+	var index uint16
+	switch v := value.(type) {
+	case int32:
+		index = b.pool.Integer(v)
+	case int64:
+		index = b.pool.Long(v)
+	case float32:
+		index = b.pool.Float(v)
+	case float64:
+		index = b.pool.Double(v)
+	case string:
+		index = b.pool.String(v)
+	case bool:
+		if v {
+			index = b.pool.Integer(1)
+		} else {
+			index = b.pool.Integer(0)
+		}
+	case int8:
+		index = b.pool.Integer(int32(v))
+	case uint16:
+		index = b.pool.Integer(int32(v))
+	case int16:
+		index = b.pool.Integer(int32(v))
+	default:
+		b.fail("unsupported constant value type %T", value)
+	}
+	return &ConstantValueAttribute{b.header("ConstantValue"), index}
+}
+
+// Code creates a Code attribute. Constants referenced by the code are added through Pool.
+func (b *ClassBuilder) Code(maxStack, maxLocals uint16, code []byte, attributes ...Attribute) *CodeAttribute {
+	return &CodeAttribute{b.header("Code"), maxStack, maxLocals, code, []ExceptionTableEntry{}, nonNil(attributes)}
+}
+
+// Exceptions creates an Exceptions attribute of the classes with internal names.
+func (b *ClassBuilder) Exceptions(classes ...string) Attribute {
+	indexes := make([]uint16, len(classes))
+	for i, c := range classes {
+		indexes[i] = b.pool.Class(c)
+	}
+	return &ExceptionsAttribute{b.header("Exceptions"), indexes}
+}
+
+func (b *ClassBuilder) fail(format string, args ...interface{}) {
+	if b.err == nil {
+		b.err = fmt.Errorf(format, args...)
+	}
+}
+
+// Build returns the class file. The builder should not be used afterwards.
+func (b *ClassBuilder) Build() (*ClassFile, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if !b.hasSuper {
+		b.Super("java/lang/Object")
+	}
+	if err := b.pool.Err(); err != nil {
+		return nil, err
+	}
+	b.class.ConstantPool = b.pool.ConstantPool()
+	return b.class, nil
+}
+
+// Bytes builds the class file and serializes it.
+func (b *ClassBuilder) Bytes() ([]byte, error) {
+	c, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func nonNil(attributes []Attribute) []Attribute {
+	if attributes == nil {
+		return []Attribute{}
+	}
+	return attributes
+}
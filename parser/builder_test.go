@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestConstantPoolBuilder(t *testing.T) {
+	b := NewConstantPoolBuilder()
+	object := b.Class("java/lang/Object")
+	if object != 2 {
+		t.Errorf("Class() = %d, want 2 after its Utf8", object)
+	}
+	if again := b.Class("java/lang/Object"); again != object {
+		t.Errorf("Class() = %d for the same name, want %d", again, object)
+	}
+	long := b.Long(10)
+	if next := b.Integer(10); next != long+2 {
+		t.Errorf("entry after Long = %d, want %d", next, long+2)
+	}
+	if again := b.Long(10); again != long {
+		t.Errorf("Long() = %d for the same value, want %d", again, long)
+	}
+	if zero, negativeZero := b.Double(0), b.Double(math.Copysign(0, -1)); zero == negativeZero {
+		t.Errorf("Double(-0) reused the entry of Double(0)")
+	}
+	ref := b.Methodref("java/lang/Object", "<init>", "()V")
+	if info := b.ConstantPool()[ref-1].(ConstantMethodrefInfo); info.ClassIndex != object {
+		t.Errorf("Methodref class = %d, want %d", info.ClassIndex, object)
+	}
+	if err := b.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConstantPoolBuilder_Add(t *testing.T) {
+	// javac writes references before the entries they refer to.
+	b := NewConstantPoolBuilder()
+	ref := b.Add(ConstantMethodrefInfo{2, 3})
+	object := b.Add(ConstantClassInfo{4})
+	b.Add(ConstantNameAndTypeInfo{5, 6})
+	b.Add(ConstantUtf8Info{[]byte("java/lang/Object")})
+	b.Add(ConstantUtf8Info{[]byte("<init>")})
+	b.Add(ConstantUtf8Info{[]byte("()V")})
+	if ref != 1 || object != 2 {
+		t.Errorf("Add() = %d, %d, want 1, 2", ref, object)
+	}
+	if got := b.Methodref("java/lang/Object", "<init>", "()V"); got != ref {
+		t.Errorf("Methodref() = %d, want the added %d", got, ref)
+	}
+	if again := b.Add(ConstantClassInfo{4}); again != 7 {
+		t.Errorf("Add() = %d for an equal constant, want a new entry 7", again)
+	}
+	if got := b.Class("java/lang/Object"); got != object {
+		t.Errorf("Class() = %d, want the first entry %d", got, object)
+	}
+	// An unpaired surrogate is read as U+FFFD, which is encoded differently.
+	malformed := b.Add(ConstantUtf8Info{[]byte{0xED, 0xA0, 0x80}})
+	if got := b.Utf8("\uFFFD"); got == malformed {
+		t.Errorf("Utf8() reused the malformed entry %d", got)
+	}
+	if err := b.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConstantPoolBuilder_Overflow(t *testing.T) {
+	b := NewConstantPoolBuilder()
+	for i := int32(0); i < 70000; i++ {
+		b.Integer(i)
+	}
+	if b.Err() == nil {
+		t.Error("Err() = nil after 70000 entries")
+	}
+}
+
+func TestClassBuilder(t *testing.T) {
+	b := NewClassBuilder("com/acme/Foo")
+	init := b.Pool().Methodref("java/lang/Object", "<init>", "()V")
+	data, err := b.Super("java/lang/Object").
+		Implements("java/lang/Runnable").
+		AddField(FieldAccessPublic|FieldAccessStatic|FieldAccessFinal, "MAX", "J", b.ConstantValue(int64(1)<<40)).
+		AddField(FieldAccessPrivate, "name", "Ljava/lang/String;").
+		AddMethod(MethodAccessPublic, "<init>", "()V", b.Code(1, 1, []byte{0x2A, 0xB7, byte(init >> 8), byte(init), 0xB1})).
+		AddMethod(MethodAccessPublic|MethodAccessAbstract, "run", "()V", b.Exceptions("java/io/IOException")).
+		AddAttribute(b.SourceFile("Foo.java")).
+		Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ReadClass(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name() != "com/acme/Foo" || c.SuperClassName() != "java/lang/Object" {
+		t.Errorf("class = %s extends %s", c.Name(), c.SuperClassName())
+	}
+	if intf := c.Interfaces(); len(intf) != 1 || intf[0] != "java/lang/Runnable" {
+		t.Errorf("Interfaces() = %v", intf)
+	}
+	if f := c.FieldByName("MAX"); f == nil || f.ConstantValue != int64(1)<<40 {
+		t.Errorf("FieldByName(MAX) = %v", f)
+	}
+	init2 := c.Method("<init>", "()V")
+	if init2 == nil || init2.Code == nil {
+		t.Fatal("Method(<init>) has no code")
+	}
+	instructions, err := init2.Code.Instructions(c.classFile.ConstantPool)
+	if err != nil || len(instructions) != 3 || instructions[1].Opcode != OpcodeInvokespecial {
+		t.Errorf("Instructions() = %v, %v", instructions, err)
+	}
+	if run := c.Method("run", "()V"); run == nil || len(run.Exceptions) != 1 || run.Exceptions[0] != "java/io/IOException" {
+		t.Errorf("Method(run) = %v", run)
+	}
+	if s := c.classFile.SourceFile(); s != "Foo.java" {
+		t.Errorf("SourceFile() = %q", s)
+	}
+}
+
+func TestClassBuilder_Super(t *testing.T) {
+	tests := []struct {
+		super string
+		set   bool
+		want  []string
+	}{
+		{"", false, []string{"Foo", "java/lang/Object"}},
+		{"com/acme/Base", true, []string{"Foo", "com/acme/Base"}},
+		{"", true, []string{"Foo"}},
+	}
+	for _, tt := range tests {
+		b := NewClassBuilder("Foo")
+		if tt.set {
+			b.Super(tt.super)
+		}
+		c, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The pool has no dead entries for a super class replaced by Super.
+		var names []string
+		for _, info := range c.ConstantPool {
+			if info, ok := info.(ConstantUtf8Info); ok {
+				names = append(names, string(info.Bytes))
+			}
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("Super(%q): pool has Utf8 %q, want %q", tt.super, names, tt.want)
+		}
+	}
+}
+
+func TestClassBuilder_InvalidConstantValue(t *testing.T) {
+	b := NewClassBuilder("A")
+	b.AddField(FieldAccessStatic, "x", "I", b.ConstantValue(1))
+	if _, err := b.Build(); err == nil {
+		t.Error("Build() succeeded with an int constant value")
+	}
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"fmt"
 	"io"
+
+	"go-javap/signature"
 )
 
 type Class struct {
@@ -17,6 +19,11 @@ func ReadClass(reader io.Reader) (*Class, error) {
 	return &Class{classFile}, nil
 }
 
+// NewClass wraps a class file, e.g. one read by ReadWithOptions.
+func NewClass(classFile *ClassFile) *Class {
+	return &Class{classFile}
+}
+
 func (c *Class) Name() string {
 	return c.classFile.ConstantPool.GetClass(c.classFile.ThisClass)
 }
@@ -27,12 +34,27 @@ func (c *Class) SuperClassName() string {
 
 func (c *Class) Interfaces() []string {
 	interfaces := make([]string, len(c.classFile.Interfaces))
-	for _, intf := range c.classFile.Interfaces {
-		interfaces = append(interfaces, c.classFile.ConstantPool.GetClass(intf))
+	for i, intf := range c.classFile.Interfaces {
+		interfaces[i] = c.classFile.ConstantPool.GetClass(intf)
 	}
 	return interfaces
 }
 
+// Signature parses the generic signature of the class.
+// It returns nil if the class has no Signature attribute.
+func (c *Class) Signature() (*signature.ClassSignature, error) {
+	s := c.classFile.Signature()
+	if s == "" {
+		return nil, nil
+	}
+	return signature.ParseClass(s)
+}
+
+// Annotations resolves the runtime visible and invisible annotations of the class.
+func (c *Class) Annotations() []Annotation {
+	return resolveAnnotations(c.classFile.Attributes, c.classFile.ConstantPool)
+}
+
 func (c *Class) AccessFlags() AccessFlags {
 	return c.classFile.AccessFlags
 }
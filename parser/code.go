@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+)
+
+type (
+	CodeAttribute struct {
+		AttributeHeader
+		MaxStack       uint16
+		MaxLocals      uint16
+		Code           []byte
+		ExceptionTable []ExceptionTableEntry
+		Attributes     Attributes
+	}
+
+	ExceptionTableEntry struct {
+		StartPC   uint16
+		EndPC     uint16
+		HandlerPC uint16
+		CatchType uint16
+	}
+
+	LineNumberTableAttribute struct {
+		AttributeHeader
+		LineNumberTable []LineNumberTableEntry
+	}
+
+	LineNumberTableEntry struct {
+		StartPC    uint16
+		LineNumber uint16
+	}
+
+	LocalVariableTableAttribute struct {
+		AttributeHeader
+		LocalVariableTable []LocalVariableTableEntry
+	}
+
+	LocalVariableTableEntry struct {
+		StartPC         uint16
+		Length          uint16
+		NameIndex       uint16
+		DescriptorIndex uint16
+		Index           uint16
+	}
+
+	LocalVariableTypeTableAttribute struct {
+		AttributeHeader
+		LocalVariableTypeTable []LocalVariableTypeTableEntry
+	}
+
+	LocalVariableTypeTableEntry struct {
+		StartPC        uint16
+		Length         uint16
+		NameIndex      uint16
+		SignatureIndex uint16
+		Index          uint16
+	}
+)
+
+func init() {
+	RegisterAttribute("Code", decodeCode)
+	RegisterAttribute("LineNumberTable", decodeLineNumberTable)
+	RegisterAttribute("LocalVariableTable", decodeLocalVariableTable)
+	RegisterAttribute("LocalVariableTypeTable", decodeLocalVariableTypeTable)
+}
+
+func decodeCode(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	c := &CodeAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	c.MaxStack = r.u2()
+	c.MaxLocals = r.u2()
+	codeLength := r.u4()
+	if r.check(int(codeLength)) {
+		c.Code = r.bytes(int(codeLength))
+	}
+	exceptionTableLength := r.u2()
+	if r.check(int(exceptionTableLength) * 8) {
+		c.ExceptionTable = make([]ExceptionTableEntry, exceptionTableLength)
+		for i := range c.ExceptionTable {
+			c.ExceptionTable[i] = ExceptionTableEntry{r.u2(), r.u2(), r.u2(), r.u2()}
+		}
+	}
+	c.Attributes = r.attributes(pool)
+	return c, r.finish()
+}
+
+func decodeLineNumberTable(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &LineNumberTableAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	length := r.u2()
+	if r.check(int(length) * 4) {
+		a.LineNumberTable = make([]LineNumberTableEntry, length)
+		for i := range a.LineNumberTable {
+			a.LineNumberTable[i] = LineNumberTableEntry{r.u2(), r.u2()}
+		}
+	}
+	return a, r.finish()
+}
+
+func decodeLocalVariableTable(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &LocalVariableTableAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	length := r.u2()
+	if r.check(int(length) * 10) {
+		a.LocalVariableTable = make([]LocalVariableTableEntry, length)
+		for i := range a.LocalVariableTable {
+			a.LocalVariableTable[i] = LocalVariableTableEntry{r.u2(), r.u2(), r.u2(), r.u2(), r.u2()}
+		}
+	}
+	return a, r.finish()
+}
+
+func decodeLocalVariableTypeTable(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &LocalVariableTypeTableAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	length := r.u2()
+	if r.check(int(length) * 10) {
+		a.LocalVariableTypeTable = make([]LocalVariableTypeTableEntry, length)
+		for i := range a.LocalVariableTypeTable {
+			a.LocalVariableTypeTable[i] = LocalVariableTypeTableEntry{r.u2(), r.u2(), r.u2(), r.u2(), r.u2()}
+		}
+	}
+	return a, r.finish()
+}
+
+func (c *CodeAttribute) Instructions(pool ConstantPool) ([]Instruction, error) {
+	return DecodeInstructions(c.Code, pool)
+}
+
+func (c *CodeAttribute) LineNumberTable() []LineNumberTableEntry {
+	for _, a := range c.Attributes {
+		if t, ok := a.(*LineNumberTableAttribute); ok {
+			return t.LineNumberTable
+		}
+	}
+	return nil
+}
+
+func (c *CodeAttribute) LocalVariableTable() []LocalVariableTableEntry {
+	for _, a := range c.Attributes {
+		if t, ok := a.(*LocalVariableTableAttribute); ok {
+			return t.LocalVariableTable
+		}
+	}
+	return nil
+}
+
+func (c *CodeAttribute) LocalVariableTypeTable() []LocalVariableTypeTableEntry {
+	for _, a := range c.Attributes {
+		if t, ok := a.(*LocalVariableTypeTableAttribute); ok {
+			return t.LocalVariableTypeTable
+		}
+	}
+	return nil
+}
+
+func (c CodeAttribute) String() string {
+	return fmt.Sprintf("Code[maxStack=%d, maxLocals=%d, codeLength=%d, exceptionTable=%v]", c.MaxStack, c.MaxLocals, len(c.Code), c.ExceptionTable)
+}
+
+func (e ExceptionTableEntry) String() string {
+	return fmt.Sprintf("ExceptionTableEntry[startPC=%d, endPC=%d, handlerPC=%d, catchType=%d]", e.StartPC, e.EndPC, e.HandlerPC, e.CatchType)
+}
+
+func (c CodeAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(c.MaxStack)
+	w.u2(c.MaxLocals)
+	w.u4(uint32(len(c.Code)))
+	w.bytes(c.Code)
+	w.count(len(c.ExceptionTable), "exception table entries")
+	for _, e := range c.ExceptionTable {
+		w.u2(e.StartPC)
+		w.u2(e.EndPC)
+		w.u2(e.HandlerPC)
+		w.u2(e.CatchType)
+	}
+	w.attributes(c.Attributes)
+	return w.finish()
+}
+
+func (a LineNumberTableAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.LineNumberTable), "line numbers")
+	for _, e := range a.LineNumberTable {
+		w.u2(e.StartPC)
+		w.u2(e.LineNumber)
+	}
+	return w.finish()
+}
+
+func (a LocalVariableTableAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.LocalVariableTable), "local variables")
+	for _, e := range a.LocalVariableTable {
+		w.u2(e.StartPC)
+		w.u2(e.Length)
+		w.u2(e.NameIndex)
+		w.u2(e.DescriptorIndex)
+		w.u2(e.Index)
+	}
+	return w.finish()
+}
+
+func (a LocalVariableTypeTableAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.LocalVariableTypeTable), "local variable types")
+	for _, e := range a.LocalVariableTypeTable {
+		w.u2(e.StartPC)
+		w.u2(e.Length)
+		w.u2(e.NameIndex)
+		w.u2(e.SignatureIndex)
+		w.u2(e.Index)
+	}
+	return w.finish()
+}
@@ -1,6 +1,11 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"go-javap/descriptor"
+)
 
 const (
 	ConstantUtf8               = 0x01
@@ -16,6 +21,7 @@ const (
 	ConstantNameAndType        = 0x0C
 	ConstantMethodHandle       = 0x0F
 	ConstantMethodType         = 0x10
+	ConstantDynamic            = 0x11
 	ConstantInvokeDynamic      = 0x12
 	ConstantModule             = 0x13
 	ConstantPackage            = 0x14
@@ -32,7 +38,10 @@ const (
 )
 
 type (
-	ConstantPool     []ConstantInfo
+	// ConstantPool is the constant pool of a class file, where index i is at ConstantPool[i-1].
+	// A Long or Double constant takes two slots, and the second one is a ConstantUnusableInfo.
+	ConstantPool []ConstantInfo
+	// ConstantUtf8Info keeps the Modified UTF-8 bytes as read, so they are written back unchanged.
 	ConstantUtf8Info struct {
 		Bytes []byte
 	}
@@ -81,6 +90,11 @@ type (
 	ConstantMethodTypeInfo struct {
 		DescriptorIndex uint16
 	}
+	// ConstantDynamicInfo is a dynamically-computed constant, loaded by ldc or passed to bootstrap methods.
+	ConstantDynamicInfo struct {
+		BootstrapMethodAttrIndex uint16
+		NameAndTypeIndex         uint16
+	}
 	ConstantInvokeDynamicInfo struct {
 		BootstrapMethodAttrIndex uint16
 		NameAndTypeIndex         uint16
@@ -91,43 +105,354 @@ type (
 	ConstantPackageInfo struct {
 		NameIndex uint16
 	}
+	// ConstantUnusableInfo is the entry after each Long or Double constant, which takes
+	// two slots of the pool. The second slot cannot be referenced and is not written.
+	ConstantUnusableInfo struct{}
 )
 
-func (p ConstantPool) get(index uint16) ConstantInfo {
-	return p[index-1]
+type (
+	// ConstantError is returned when a constant pool index cannot be resolved.
+	ConstantError struct {
+		Index uint16
+		Kind  ConstantErrorKind
+		// Want and Got are the expected and actual tags if Kind is ConstantWrongTag,
+		// and Got is the reference kind if Kind is ConstantInvalidReferenceKind.
+		Want []uint8
+		Got  uint8
+	}
+
+	ConstantErrorKind int
+
+	// NameAndType is a resolved NameAndType constant. Descriptor is a field or a method descriptor.
+	NameAndType struct {
+		Name       string
+		Descriptor string
+	}
+
+	// FieldRef is a resolved Fieldref constant.
+	FieldRef struct {
+		Class      string
+		Name       string
+		Descriptor descriptor.Type
+	}
+
+	// MethodRef is a resolved Methodref or InterfaceMethodref constant.
+	MethodRef struct {
+		Class      string
+		Name       string
+		Descriptor descriptor.Method
+		// Interface is true for an InterfaceMethodref.
+		Interface bool
+	}
+
+	// MethodHandle is a resolved MethodHandle constant.
+	// Field is set for the field kinds and Method for the others.
+	MethodHandle struct {
+		Kind   MethodHandleRef
+		Field  *FieldRef
+		Method *MethodRef
+	}
+
+	// InvokeDynamic is a resolved InvokeDynamic constant.
+	InvokeDynamic struct {
+		// BootstrapMethodAttrIndex is an index into the BootstrapMethods attribute.
+		BootstrapMethodAttrIndex uint16
+		Name                     string
+		Descriptor               descriptor.Method
+	}
+)
+
+const (
+	ConstantZeroIndex ConstantErrorKind = iota
+	ConstantIndexOutOfRange
+	// ConstantUnusableIndex is the second slot of a Long or Double constant.
+	ConstantUnusableIndex
+	ConstantWrongTag
+	// ConstantInvalidReferenceKind is a MethodHandle constant with an unknown reference kind.
+	ConstantInvalidReferenceKind
+)
+
+func (e *ConstantError) Error() string {
+	switch e.Kind {
+	case ConstantZeroIndex:
+		return "invalid constant pool index 0"
+	case ConstantIndexOutOfRange:
+		return fmt.Sprintf("constant pool index #%d out of range", e.Index)
+	case ConstantUnusableIndex:
+		return fmt.Sprintf("constant pool index #%d is the second slot of a Long or Double constant", e.Index)
+	case ConstantInvalidReferenceKind:
+		return fmt.Sprintf("constant #%d has invalid reference kind %d", e.Index, e.Got)
+	}
+	want := make([]string, len(e.Want))
+	for i, tag := range e.Want {
+		want[i] = constantTagNames[tag]
+	}
+	return fmt.Sprintf("constant #%d is %s, want %s", e.Index, constantTagNames[e.Got], strings.Join(want, " or "))
 }
 
-func (p ConstantPool) GetUTF8(index uint16) string {
+// get returns the constant at index, or a *ConstantError if the index is not usable.
+func (p ConstantPool) get(index uint16) (ConstantInfo, error) {
 	if index == 0 {
-		return ""
+		return nil, &ConstantError{Index: index, Kind: ConstantZeroIndex}
+	}
+	if int(index) > len(p) {
+		return nil, &ConstantError{Index: index, Kind: ConstantIndexOutOfRange}
+	}
+	if _, ok := p[index-1].(ConstantUnusableInfo); ok {
+		return nil, &ConstantError{Index: index, Kind: ConstantUnusableIndex}
+	}
+	return p[index-1], nil
+}
+
+// lookup returns the constant at index if it has one of tags.
+func (p ConstantPool) lookup(index uint16, tags ...uint8) (ConstantInfo, error) {
+	info, err := p.get(index)
+	if err != nil {
+		return nil, err
+	}
+	got := constantTag(info)
+	for _, tag := range tags {
+		if got == tag {
+			return info, nil
+		}
+	}
+	return nil, &ConstantError{Index: index, Kind: ConstantWrongTag, Want: tags, Got: got}
+}
+
+func (p ConstantPool) has(index uint16) bool {
+	_, err := p.get(index)
+	return err == nil
+}
+
+func isWideConstant(info ConstantInfo) bool {
+	switch info.(type) {
+	case ConstantLongInfo, ConstantDoubleInfo:
+		return true
+	}
+	return false
+}
+
+func constantTag(info ConstantInfo) uint8 {
+	switch info.(type) {
+	case ConstantUtf8Info:
+		return ConstantUtf8
+	case ConstantIntegerInfo:
+		return ConstantInteger
+	case ConstantFloatInfo:
+		return ConstantFloat
+	case ConstantLongInfo:
+		return ConstantLong
+	case ConstantDoubleInfo:
+		return ConstantDouble
+	case ConstantClassInfo:
+		return ConstantClass
+	case ConstantStringInfo:
+		return ConstantString
+	case ConstantFieldrefInfo:
+		return ConstantFieldref
+	case ConstantMethodrefInfo:
+		return ConstantMethodref
+	case ConstantInterfaceMethodrefInfo:
+		return ConstantInterfaceMethodref
+	case ConstantNameAndTypeInfo:
+		return ConstantNameAndType
+	case ConstantMethodHandleInfo:
+		return ConstantMethodHandle
+	case ConstantMethodTypeInfo:
+		return ConstantMethodType
+	case ConstantDynamicInfo:
+		return ConstantDynamic
+	case ConstantInvokeDynamicInfo:
+		return ConstantInvokeDynamic
+	case ConstantModuleInfo:
+		return ConstantModule
+	case ConstantPackageInfo:
+		return ConstantPackage
 	}
-	info := p.get(index)
-	if i, ok := info.(ConstantUtf8Info); ok {
-		return string(i.Bytes)
+	return 0
+}
+
+// GetUTF8 returns the decoded string of a Utf8 constant. Malformed bytes are decoded as U+FFFD.
+// It returns "" if index is not a Utf8 constant.
+func (p ConstantPool) GetUTF8(index uint16) string {
+	s, _ := p.utf8(index)
+	return s
+}
+
+func (p ConstantPool) utf8(index uint16) (string, error) {
+	info, err := p.lookup(index, ConstantUtf8)
+	if err != nil {
+		return "", err
 	}
-	return ""
+	s, _ := info.(ConstantUtf8Info).Value()
+	return s, nil
 }
 
 func (p ConstantPool) GetString(index uint16) string {
-	if index == 0 {
+	info, err := p.lookup(index, ConstantString)
+	if err != nil {
 		return ""
 	}
-	info := p.get(index)
-	if i, ok := info.(ConstantStringInfo); ok {
-		return p.GetUTF8(i.StringIndex)
-	}
-	return ""
+	return p.GetUTF8(info.(ConstantStringInfo).StringIndex)
 }
 
 func (p ConstantPool) GetClass(index uint16) string {
-	if index == 0 {
-		return ""
+	s, _ := p.class(index)
+	return s
+}
+
+func (p ConstantPool) class(index uint16) (string, error) {
+	info, err := p.lookup(index, ConstantClass)
+	if err != nil {
+		return "", err
+	}
+	return p.utf8(info.(ConstantClassInfo).NameIndex)
+}
+
+func (p ConstantPool) GetNameAndType(index uint16) (NameAndType, error) {
+	info, err := p.lookup(index, ConstantNameAndType)
+	if err != nil {
+		return NameAndType{}, err
+	}
+	nat := info.(ConstantNameAndTypeInfo)
+	name, err := p.utf8(nat.NameIndex)
+	if err != nil {
+		return NameAndType{}, err
+	}
+	desc, err := p.utf8(nat.DescriptorIndex)
+	if err != nil {
+		return NameAndType{}, err
+	}
+	return NameAndType{name, desc}, nil
+}
+
+func (p ConstantPool) GetFieldref(index uint16) (FieldRef, error) {
+	info, err := p.lookup(index, ConstantFieldref)
+	if err != nil {
+		return FieldRef{}, err
+	}
+	ref := info.(ConstantFieldrefInfo)
+	return p.fieldRef(ref.ClassIndex, ref.NameAndTypeIndex)
+}
+
+func (p ConstantPool) fieldRef(classIndex, nameAndTypeIndex uint16) (FieldRef, error) {
+	class, err := p.class(classIndex)
+	if err != nil {
+		return FieldRef{}, err
+	}
+	nat, err := p.GetNameAndType(nameAndTypeIndex)
+	if err != nil {
+		return FieldRef{}, err
+	}
+	desc, err := descriptor.ParseField(nat.Descriptor)
+	if err != nil {
+		return FieldRef{}, err
+	}
+	return FieldRef{class, nat.Name, desc}, nil
+}
+
+func (p ConstantPool) GetMethodref(index uint16) (MethodRef, error) {
+	return p.methodRef(index, ConstantMethodref)
+}
+
+func (p ConstantPool) GetInterfaceMethodref(index uint16) (MethodRef, error) {
+	return p.methodRef(index, ConstantInterfaceMethodref)
+}
+
+// methodRef resolves a Methodref or InterfaceMethodref constant, whichever of tags it is.
+func (p ConstantPool) methodRef(index uint16, tags ...uint8) (MethodRef, error) {
+	info, err := p.lookup(index, tags...)
+	if err != nil {
+		return MethodRef{}, err
 	}
-	info := p.get(index)
-	if i, ok := info.(ConstantClassInfo); ok {
-		return p.GetUTF8(i.NameIndex)
+	var classIndex, nameAndTypeIndex uint16
+	iface := false
+	switch ref := info.(type) {
+	case ConstantMethodrefInfo:
+		classIndex, nameAndTypeIndex = ref.ClassIndex, ref.NameAndTypeIndex
+	case ConstantInterfaceMethodrefInfo:
+		classIndex, nameAndTypeIndex = ref.ClassIndex, ref.NameAndTypeIndex
+		iface = true
 	}
-	return ""
+	class, err := p.class(classIndex)
+	if err != nil {
+		return MethodRef{}, err
+	}
+	nat, err := p.GetNameAndType(nameAndTypeIndex)
+	if err != nil {
+		return MethodRef{}, err
+	}
+	desc, err := descriptor.ParseMethod(nat.Descriptor)
+	if err != nil {
+		return MethodRef{}, err
+	}
+	return MethodRef{class, nat.Name, desc, iface}, nil
+}
+
+// GetMethodHandle resolves a method handle and the field or method it refers to.
+func (p ConstantPool) GetMethodHandle(index uint16) (MethodHandle, error) {
+	info, err := p.lookup(index, ConstantMethodHandle)
+	if err != nil {
+		return MethodHandle{}, err
+	}
+	handle := info.(ConstantMethodHandleInfo)
+	var tags []uint8
+	switch handle.ReferenceKind {
+	case MethodHandleRefGetField, MethodHandleRefGetStatic, MethodHandleRefPutField, MethodHandleRefPutStatic:
+		info, err := p.lookup(handle.ReferenceIndex, ConstantFieldref)
+		if err != nil {
+			return MethodHandle{}, err
+		}
+		ref := info.(ConstantFieldrefInfo)
+		field, err := p.fieldRef(ref.ClassIndex, ref.NameAndTypeIndex)
+		if err != nil {
+			return MethodHandle{}, err
+		}
+		return MethodHandle{Kind: handle.ReferenceKind, Field: &field}, nil
+	case MethodHandleRefInvokeVirtual, MethodHandleRefNewInvokeSpecial:
+		tags = []uint8{ConstantMethodref}
+	case MethodHandleRefInvokeStatic, MethodHandleRefInvokeSpecial:
+		// Interface methods are allowed since class file version 52.
+		tags = []uint8{ConstantMethodref, ConstantInterfaceMethodref}
+	case MethodHandleRefInvokeInterface:
+		tags = []uint8{ConstantInterfaceMethodref}
+	default:
+		return MethodHandle{}, &ConstantError{Index: index, Kind: ConstantInvalidReferenceKind, Got: uint8(handle.ReferenceKind)}
+	}
+	method, err := p.methodRef(handle.ReferenceIndex, tags...)
+	if err != nil {
+		return MethodHandle{}, err
+	}
+	return MethodHandle{Kind: handle.ReferenceKind, Method: &method}, nil
+}
+
+func (p ConstantPool) GetMethodType(index uint16) (descriptor.Method, error) {
+	info, err := p.lookup(index, ConstantMethodType)
+	if err != nil {
+		return descriptor.Method{}, err
+	}
+	desc, err := p.utf8(info.(ConstantMethodTypeInfo).DescriptorIndex)
+	if err != nil {
+		return descriptor.Method{}, err
+	}
+	return descriptor.ParseMethod(desc)
+}
+
+func (p ConstantPool) GetInvokeDynamic(index uint16) (InvokeDynamic, error) {
+	info, err := p.lookup(index, ConstantInvokeDynamic)
+	if err != nil {
+		return InvokeDynamic{}, err
+	}
+	indy := info.(ConstantInvokeDynamicInfo)
+	nat, err := p.GetNameAndType(indy.NameAndTypeIndex)
+	if err != nil {
+		return InvokeDynamic{}, err
+	}
+	desc, err := descriptor.ParseMethod(nat.Descriptor)
+	if err != nil {
+		return InvokeDynamic{}, err
+	}
+	return InvokeDynamic{indy.BootstrapMethodAttrIndex, nat.Name, desc}, nil
 }
 
 func (c ConstantClassInfo) String() string {
@@ -139,7 +464,7 @@ func (c ConstantIntegerInfo) String() string {
 }
 
 func (c ConstantFloatInfo) String() string {
-	return fmt.Sprintf("Float[value=%f]", c.Value)
+	return fmt.Sprintf("Float[value=%v]", c.Value)
 }
 
 func (c ConstantLongInfo) String() string {
@@ -147,7 +472,7 @@ func (c ConstantLongInfo) String() string {
 }
 
 func (c ConstantDoubleInfo) String() string {
-	return fmt.Sprintf("Double[value=%f]", c.Value)
+	return fmt.Sprintf("Double[value=%v]", c.Value)
 }
 
 func (c ConstantMethodrefInfo) String() string {
@@ -158,8 +483,14 @@ func (c ConstantInterfaceMethodrefInfo) String() string {
 	return fmt.Sprintf("InterfaceMethodrefInfo[classIndex=%d, nameAndTypeIndex=%d]", c.ClassIndex, c.NameAndTypeIndex)
 }
 
+// Value decodes the Modified UTF-8 bytes of the constant.
+func (c ConstantUtf8Info) Value() (string, error) {
+	return DecodeModifiedUTF8(c.Bytes)
+}
+
 func (c ConstantUtf8Info) String() string {
-	return fmt.Sprintf("UTF[%s]", string(c.Bytes))
+	s, _ := c.Value()
+	return fmt.Sprintf("UTF[%s]", s)
 }
 
 func (c ConstantNameAndTypeInfo) String() string {
@@ -206,6 +537,10 @@ func (c ConstantMethodTypeInfo) String() string {
 	return fmt.Sprintf("MethodType[descriptorIndex=%d]", c.DescriptorIndex)
 }
 
+func (c ConstantDynamicInfo) String() string {
+	return fmt.Sprintf("Dynamic[bootstrapMethodAttrIndex=%d, nameAndTypeIndex=%d]", c.BootstrapMethodAttrIndex, c.NameAndTypeIndex)
+}
+
 func (c ConstantInvokeDynamicInfo) String() string {
 	return fmt.Sprintf("InvokeDynamic[bootstrapMethodAttrIndex=%d, nameAndTypeIndex=%d]", c.BootstrapMethodAttrIndex, c.NameAndTypeIndex)
 }
@@ -217,3 +552,7 @@ func (c ConstantModuleInfo) String() string {
 func (c ConstantPackageInfo) String() string {
 	return fmt.Sprintf("Package[nameIndex=%d]", c.NameIndex)
 }
+
+func (ConstantUnusableInfo) String() string {
+	return "(second slot)"
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"go-javap/descriptor"
+)
+
+func TestConstantPool_Get(t *testing.T) {
+	b := NewConstantPoolBuilder()
+	long := b.Long(1)
+	fieldref := b.Fieldref("com/acme/Foo", "count", "I")
+	methodref := b.Methodref("com/acme/Foo", "run", "(J)V")
+	imethodref := b.InterfaceMethodref("java/util/List", "size", "()I")
+	getter := b.MethodHandle(MethodHandleRefGetField, fieldref)
+	static := b.MethodHandle(MethodHandleRefInvokeStatic, imethodref)
+	methodType := b.MethodType("(I)Ljava/lang/String;")
+	indy := b.InvokeDynamic(2, "apply", "()Ljava/util/function/Function;")
+	pool := b.ConstantPool()
+
+	intType := descriptor.Type{Kind: descriptor.Int}
+	if got, err := pool.GetFieldref(fieldref); err != nil || !reflect.DeepEqual(got, FieldRef{"com/acme/Foo", "count", intType}) {
+		t.Errorf("GetFieldref() = %v, %v", got, err)
+	}
+	run := MethodRef{"com/acme/Foo", "run", descriptor.Method{Parameters: []descriptor.Type{{Kind: descriptor.Long}}, Return: descriptor.Type{Kind: descriptor.Void}}, false}
+	if got, err := pool.GetMethodref(methodref); err != nil || !reflect.DeepEqual(got, run) {
+		t.Errorf("GetMethodref() = %v, %v", got, err)
+	}
+	size := MethodRef{"java/util/List", "size", descriptor.Method{Parameters: []descriptor.Type{}, Return: intType}, true}
+	if got, err := pool.GetInterfaceMethodref(imethodref); err != nil || !reflect.DeepEqual(got, size) {
+		t.Errorf("GetInterfaceMethodref() = %v, %v", got, err)
+	}
+	if got, err := pool.GetMethodHandle(getter); err != nil || got.Kind != MethodHandleRefGetField || got.Field == nil || got.Field.Name != "count" || got.Method != nil {
+		t.Errorf("GetMethodHandle(getField) = %+v, %v", got, err)
+	}
+	if got, err := pool.GetMethodHandle(static); err != nil || got.Method == nil || !reflect.DeepEqual(*got.Method, size) {
+		t.Errorf("GetMethodHandle(invokeStatic) = %+v, %v", got, err)
+	}
+	if got, err := pool.GetMethodType(methodType); err != nil || len(got.Parameters) != 1 || got.Return.ClassName != "java/lang/String" {
+		t.Errorf("GetMethodType() = %v, %v", got, err)
+	}
+	if got, err := pool.GetInvokeDynamic(indy); err != nil || got.BootstrapMethodAttrIndex != 2 || got.Name != "apply" || got.Descriptor.Return.ClassName != "java/util/function/Function" {
+		t.Errorf("GetInvokeDynamic() = %v, %v", got, err)
+	}
+
+	tests := []struct {
+		name  string
+		get   func() error
+		index uint16
+		kind  ConstantErrorKind
+		msg   string
+	}{
+		{"zero", func() error { _, err := pool.GetFieldref(0); return err }, 0, ConstantZeroIndex, "invalid constant pool index 0"},
+		{"out of range", func() error { _, err := pool.GetNameAndType(1000); return err }, 1000, ConstantIndexOutOfRange, "constant pool index #1000 out of range"},
+		{"second slot", func() error { _, err := pool.GetMethodType(long + 1); return err }, long + 1, ConstantUnusableIndex, "constant pool index #2 is the second slot of a Long or Double constant"},
+		{"wrong tag", func() error { _, err := pool.GetMethodref(fieldref); return err }, fieldref, ConstantWrongTag, "constant #8 is Fieldref, want Methodref"},
+		{"long", func() error { _, err := pool.GetInvokeDynamic(long); return err }, long, ConstantWrongTag, "constant #1 is Long, want InvokeDynamic"},
+		{"interface handle", func() error { _, err := pool.GetMethodHandle(imethodref); return err }, imethodref, ConstantWrongTag, "constant #18 is InterfaceMethodref, want MethodHandle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.get()
+			e, ok := err.(*ConstantError)
+			if !ok || e.Index != tt.index || e.Kind != tt.kind || e.Error() != tt.msg {
+				t.Errorf("error = %#v (%v), want #%d %q", err, err, tt.index, tt.msg)
+			}
+		})
+	}
+}
+
+func TestConstantPool_GetMethodHandle_WrongReference(t *testing.T) {
+	b := NewConstantPoolBuilder()
+	methodref := b.Methodref("Foo", "bar", "()V")
+	handle := b.MethodHandle(MethodHandleRefGetStatic, methodref)
+	_, err := b.ConstantPool().GetMethodHandle(handle)
+	if e, ok := err.(*ConstantError); !ok || e.Index != methodref || e.Kind != ConstantWrongTag || e.Got != ConstantMethodref {
+		t.Errorf("GetMethodHandle() error = %v, want a Methodref for getStatic to be rejected", err)
+	}
+}
+
+func TestConstantPool_GetMethodHandle_InvalidKind(t *testing.T) {
+	b := NewConstantPoolBuilder()
+	handle := b.MethodHandle(MethodHandleRef(10), b.Methodref("Foo", "bar", "()V"))
+	_, err := b.ConstantPool().GetMethodHandle(handle)
+	if e, ok := err.(*ConstantError); !ok || e.Index != handle || e.Kind != ConstantInvalidReferenceKind || e.Got != 10 {
+		t.Errorf("GetMethodHandle() error = %#v, want an invalid reference kind", err)
+	}
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// ParseError is returned when a class file cannot be parsed.
+type ParseError struct {
+	// Offset is the byte offset in the class file of the failing read,
+	// or of the start of an attribute which failed to decode.
+	Offset int64
+	// Path is the structure being parsed, like "method[3].attribute[1]".
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %v", e.Path, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// LimitError is the cause of a ParseError when a class file exceeds a limit of Limits,
+// or the error of reading a class file or an archive exceeding a limit of package classpath.
+type LimitError struct {
+	Limit LimitKind
+	// Value is the size, the count or the depth exceeding Max,
+	// or 0 for the size of data which is not read beyond the limit.
+	Value int64
+	Max   int64
+}
+
+// LimitKind is the kind of a limit.
+type LimitKind int
+
+const (
+	LimitAttributeSize LimitKind = iota
+	LimitConstantPoolEntries
+	LimitTotalBytes
+	// LimitElementValueDepth is the nesting of element values in annotations, up to MaxElementValueDepth.
+	LimitElementValueDepth
+	// LimitArchiveDepth and LimitArchiveSize are the depth and the size of archives nested in archives,
+	// enforced by package classpath.
+	LimitArchiveDepth
+	LimitArchiveSize
+)
+
+var limitNames = map[LimitKind]string{
+	LimitAttributeSize:       "attribute size",
+	LimitConstantPoolEntries: "constant pool entries",
+	LimitTotalBytes:          "class file size",
+	LimitElementValueDepth:   "element value nesting depth",
+	LimitArchiveDepth:        "archive nesting depth",
+	LimitArchiveSize:         "nested archive size",
+}
+
+func (e *LimitError) Error() string {
+	if e.Value == 0 {
+		return fmt.Sprintf("%s exceeds the limit %d", limitNames[e.Limit], e.Max)
+	}
+	return fmt.Sprintf("%s %d exceeds the limit %d", limitNames[e.Limit], e.Value, e.Max)
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"fmt"
 	"strings"
+
+	"go-javap/descriptor"
 )
 
 type (
@@ -10,7 +12,7 @@ type (
 		AccessFlags     FieldAccessFlags
 		NameIndex       uint16
 		DescriptorIndex uint16
-		Attributes      []AttributeInfo
+		Attributes      Attributes
 	}
 
 	FieldAccessFlags uint16
@@ -32,6 +34,25 @@ func (f FieldInfo) String() string {
 	return fmt.Sprintf("Field[flags=%s, nameIndex=%d, descriptorIndex=%d]", f.AccessFlags, f.NameIndex, f.DescriptorIndex)
 }
 
+// ConstantValueIndex returns the constant pool index of the ConstantValue attribute.
+func (f FieldInfo) ConstantValueIndex() (uint16, bool) {
+	for _, a := range f.Attributes {
+		if c, ok := a.(*ConstantValueAttribute); ok {
+			return c.ConstantValueIndex, true
+		}
+	}
+	return 0, false
+}
+
+// Descriptor parses the descriptor of the field.
+func (f FieldInfo) Descriptor(pool ConstantPool) (descriptor.Type, error) {
+	return descriptor.ParseField(pool.GetUTF8(f.DescriptorIndex))
+}
+
+func (f FieldInfo) Signature(pool ConstantPool) string {
+	return findSignature(f.Attributes, pool)
+}
+
 func (a FieldAccessFlags) Public() bool {
 	return a.is(FieldAccessPublic)
 }
@@ -68,36 +89,32 @@ func (a FieldAccessFlags) Enum() bool {
 	return a.is(FieldAccessEnum)
 }
 
+// fieldFlagNames are the names of the flags in the order they are written.
+var fieldFlagNames = []flagName{
+	{FieldAccessPublic, "public"},
+	{FieldAccessPrivate, "private"},
+	{FieldAccessProtected, "protected"},
+	{FieldAccessStatic, "static"},
+	{FieldAccessFinal, "final"},
+	{FieldAccessVolatile, "volatile"},
+	{FieldAccessTransient, "transient"},
+	{FieldAccessSynthetic, "synthetic"},
+	{FieldAccessEnum, "enum"},
+}
+
 func (a FieldAccessFlags) String() string {
-	flags := make([]string, 0)
-	if a.Public() {
-		flags = append(flags, "public")
-	}
-	if a.Private() {
-		flags = append(flags, "private")
-	}
-	if a.Protected() {
-		flags = append(flags, "protected")
-	}
-	if a.Static() {
-		flags = append(flags, "static")
-	}
-	if a.Final() {
-		flags = append(flags, "final")
-	}
-	if a.Volatile() {
-		flags = append(flags, "volatile")
-	}
-	if a.Transient() {
-		flags = append(flags, "transient")
-	}
-	if a.Synthetic() {
-		flags = append(flags, "synthetic")
-	}
-	if a.Enum() {
-		flags = append(flags, "enum")
-	}
-	return fmt.Sprintf("AccessFlags[%s]", strings.Join(flags, ", "))
+	return fmt.Sprintf("AccessFlags[%s]", strings.Join(flagNames(uint16(a), fieldFlagNames), ", "))
+}
+
+// Names returns the lower case names of the flags like public, in the order they are written.
+func (a FieldAccessFlags) Names() []string {
+	return flagNames(uint16(a), fieldFlagNames)
+}
+
+// FieldAccessFlag returns the field flag with the name returned by FieldAccessFlags.Names.
+func FieldAccessFlag(name string) (FieldAccessFlags, bool) {
+	flag, ok := flagByName(name, fieldFlagNames)
+	return FieldAccessFlags(flag), ok
 }
 
 func (a FieldAccessFlags) is(n uint16) bool {
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatFloat renders a float (bitSize 32) or double like Float.toString and Double.toString:
+// the fewest digits which read back as v, in decimal notation from 10^-3 to 10^7 and
+// in scientific notation like 1.0E10 otherwise.
+func FormatFloat(v float64, bitSize int) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	case v == 0 && math.Signbit(v):
+		return "-0.0"
+	}
+	if abs := math.Abs(v); abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		s := strconv.FormatFloat(v, 'f', -1, bitSize)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	s := strconv.FormatFloat(v, 'e', -1, bitSize)
+	if !strings.Contains(s, ".") {
+		// Java prints at least two digits, choosing the closest like 1.4E-45 for Float.MIN_VALUE.
+		s = strconv.FormatFloat(v, 'e', 1, bitSize)
+	}
+	i := strings.IndexByte(s, 'e')
+	mantissa, exponent := s[:i], s[i+1:]
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(exp)
+}
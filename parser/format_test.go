@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v       float64
+		bitSize int
+		want    string
+	}{
+		{1.5, 32, "1.5"},
+		{100, 32, "100.0"},
+		{0, 64, "0.0"},
+		{math.Copysign(0, -1), 64, "-0.0"},
+		{1e10, 32, "1.0E10"},
+		{1e7, 64, "1.0E7"},
+		{9999999, 64, "9999999.0"},
+		{0.001, 64, "0.001"},
+		{0.0001, 64, "1.0E-4"},
+		{-1.25e-5, 64, "-1.25E-5"},
+		{float64(float32(0.1)), 32, "0.1"},
+		{float64(float32(0.1)), 64, "0.10000000149011612"},
+		{float64(math.MaxFloat32), 32, "3.4028235E38"},
+		{float64(math.SmallestNonzeroFloat32), 32, "1.4E-45"},
+		{math.MaxFloat64, 64, "1.7976931348623157E308"},
+		{math.SmallestNonzeroFloat64, 64, "4.9E-324"},
+		{math.Inf(1), 32, "Infinity"},
+		{math.Inf(-1), 64, "-Infinity"},
+		{math.NaN(), 64, "NaN"},
+	}
+	for _, tt := range tests {
+		if got := FormatFloat(tt.v, tt.bitSize); got != tt.want {
+			t.Errorf("FormatFloat(%v, %d) = %q, want %q", tt.v, tt.bitSize, got, tt.want)
+		}
+	}
+}
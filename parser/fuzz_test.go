@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// FuzzRead reads arbitrary class files, which must fail with an error rather than panic,
+// and uses what is read like the commands do.
+func FuzzRead(f *testing.F) {
+	f.Add(minimalClass)
+	f.Add(skipClass(f))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		limits := Limits{MaxAttributeSize: 1 << 16, MaxConstantPoolEntries: 1 << 12, MaxTotalBytes: 1 << 20}
+		for _, opts := range []ParseOptions{
+			{Limits: limits},
+			{SkipCode: true, Limits: limits},
+			{SkipMembers: true},
+		} {
+			ReadWithOptions(bytes.NewReader(data), opts)
+		}
+		c, err := ReadBytesWithOptions(data, ParseOptions{Limits: limits})
+		if err != nil {
+			return
+		}
+		class := NewClass(c)
+		class.Name()
+		class.SuperClassName()
+		class.Interfaces()
+		class.Signature()
+		class.Fields()
+		class.Methods()
+		for _, m := range c.Methods {
+			if code := m.Code(); code != nil {
+				DecodeInstructions(code.Code, c.ConstantPool)
+			}
+		}
+		Write(ioutil.Discard, c)
+		json.Marshal(c)
+	})
+}
@@ -0,0 +1,352 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	Instruction struct {
+		Offset int
+		Opcode Opcode
+		// Wide is set when the instruction was prefixed by the wide opcode.
+		Wide bool `json:",omitempty"`
+		// Index is the local variable or constant pool index operand.
+		Index uint16 `json:",omitempty"`
+		// Value is the immediate operand: the pushed value of bipush/sipush,
+		// the increment of iinc, the count of invokeinterface, the dimensions
+		// of multianewarray or the element type of newarray.
+		Value int32 `json:",omitempty"`
+		// Branch is the jump offset relative to Offset.
+		Branch   int32        `json:",omitempty"`
+		Switch   *SwitchTable `json:",omitempty"`
+		Constant ConstantInfo `json:",omitempty"`
+	}
+
+	SwitchTable struct {
+		Default int32
+		// Low and High are only used by tableswitch.
+		Low  int32
+		High int32
+		// Keys are only used by lookupswitch.
+		Keys    []int32
+		Offsets []int32
+	}
+
+	ArrayType uint8
+)
+
+const (
+	ArrayTypeBoolean ArrayType = 4
+	ArrayTypeChar    ArrayType = 5
+	ArrayTypeFloat   ArrayType = 6
+	ArrayTypeDouble  ArrayType = 7
+	ArrayTypeByte    ArrayType = 8
+	ArrayTypeShort   ArrayType = 9
+	ArrayTypeInt     ArrayType = 10
+	ArrayTypeLong    ArrayType = 11
+)
+
+func (t ArrayType) String() string {
+	switch t {
+	case ArrayTypeBoolean:
+		return "boolean"
+	case ArrayTypeChar:
+		return "char"
+	case ArrayTypeFloat:
+		return "float"
+	case ArrayTypeDouble:
+		return "double"
+	case ArrayTypeByte:
+		return "byte"
+	case ArrayTypeShort:
+		return "short"
+	case ArrayTypeInt:
+		return "int"
+	case ArrayTypeLong:
+		return "long"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
+// DecodeInstructions decodes the bytecode of a Code attribute.
+// Constant pool operands are resolved against pool, which may be nil to skip resolution.
+func DecodeInstructions(code []byte, pool ConstantPool) ([]Instruction, error) {
+	r := &codeReader{code: code}
+	instructions := make([]Instruction, 0)
+	for r.pos < len(code) {
+		inst, err := r.readInstruction()
+		if err != nil {
+			return nil, err
+		}
+		if pool != nil && inst.hasConstant() {
+			info, err := pool.get(inst.Index)
+			if err != nil {
+				return nil, fmt.Errorf("%v at offset %d", err, inst.Offset)
+			}
+			inst.Constant = info
+		}
+		instructions = append(instructions, inst)
+	}
+	return instructions, nil
+}
+
+// Targets returns the absolute offsets the instruction may jump to.
+func (i Instruction) Targets() []int {
+	switch opcodeInfos[i.Opcode].operand {
+	case OperandBranch16, OperandBranch32:
+		return []int{i.Offset + int(i.Branch)}
+	case OperandTableSwitch, OperandLookupSwitch:
+		targets := make([]int, 0, len(i.Switch.Offsets)+1)
+		for _, offset := range i.Switch.Offsets {
+			targets = append(targets, i.Offset+int(offset))
+		}
+		return append(targets, i.Offset+int(i.Switch.Default))
+	}
+	return nil
+}
+
+func (i Instruction) hasConstant() bool {
+	switch opcodeInfos[i.Opcode].operand {
+	case OperandConstant8, OperandConstant16, OperandInvokeInterface, OperandInvokeDynamic, OperandMultiANewArray:
+		return true
+	}
+	return false
+}
+
+func (i Instruction) String() string {
+	var operands string
+	switch opcodeInfos[i.Opcode].operand {
+	case OperandByte, OperandShort:
+		operands = fmt.Sprint(i.Value)
+	case OperandLocal:
+		operands = fmt.Sprint(i.Index)
+	case OperandConstant8, OperandConstant16, OperandInvokeDynamic:
+		operands = fmt.Sprintf("#%d", i.Index)
+	case OperandBranch16, OperandBranch32:
+		operands = fmt.Sprint(i.Offset + int(i.Branch))
+	case OperandIinc:
+		operands = fmt.Sprintf("%d, %d", i.Index, i.Value)
+	case OperandInvokeInterface, OperandMultiANewArray:
+		operands = fmt.Sprintf("#%d, %d", i.Index, i.Value)
+	case OperandNewArray:
+		operands = ArrayType(i.Value).String()
+	case OperandTableSwitch, OperandLookupSwitch:
+		targets := i.Targets()
+		cases := make([]string, 0, len(targets))
+		for j, target := range targets[:len(targets)-1] {
+			var key int32
+			if i.Opcode == OpcodeTableswitch {
+				key = i.Switch.Low + int32(j)
+			} else {
+				key = i.Switch.Keys[j]
+			}
+			cases = append(cases, fmt.Sprintf("%d: %d", key, target))
+		}
+		cases = append(cases, fmt.Sprintf("default: %d", targets[len(targets)-1]))
+		operands = fmt.Sprintf("{ %s }", strings.Join(cases, ", "))
+	}
+	if operands == "" {
+		return fmt.Sprintf("%d: %s", i.Offset, i.Opcode)
+	}
+	return fmt.Sprintf("%d: %s %s", i.Offset, i.Opcode, operands)
+}
+
+type codeReader struct {
+	code []byte
+	pos  int
+}
+
+func (r *codeReader) readInstruction() (Instruction, error) {
+	inst := Instruction{Offset: r.pos}
+	op, err := r.u1()
+	if err != nil {
+		return inst, err
+	}
+	inst.Opcode = Opcode(op)
+	info := opcodeInfos[inst.Opcode]
+	if info.name == "" {
+		return inst, fmt.Errorf("unknown opcode 0x%02X at offset %d", op, inst.Offset)
+	}
+	switch info.operand {
+	case OperandNone:
+	case OperandByte:
+		v, err := r.u1()
+		inst.Value = int32(int8(v))
+		return inst, err
+	case OperandShort:
+		v, err := r.u2()
+		inst.Value = int32(int16(v))
+		return inst, err
+	case OperandLocal, OperandConstant8:
+		v, err := r.u1()
+		inst.Index = uint16(v)
+		return inst, err
+	case OperandConstant16:
+		inst.Index, err = r.u2()
+		return inst, err
+	case OperandBranch16:
+		v, err := r.u2()
+		inst.Branch = int32(int16(v))
+		return inst, err
+	case OperandBranch32:
+		v, err := r.u4()
+		inst.Branch = int32(v)
+		return inst, err
+	case OperandIinc:
+		index, err := r.u1()
+		if err != nil {
+			return inst, err
+		}
+		v, err := r.u1()
+		inst.Index = uint16(index)
+		inst.Value = int32(int8(v))
+		return inst, err
+	case OperandInvokeInterface:
+		if inst.Index, err = r.u2(); err != nil {
+			return inst, err
+		}
+		count, err := r.u1()
+		if err != nil {
+			return inst, err
+		}
+		inst.Value = int32(count)
+		_, err = r.u1()
+		return inst, err
+	case OperandInvokeDynamic:
+		if inst.Index, err = r.u2(); err != nil {
+			return inst, err
+		}
+		_, err = r.u2()
+		return inst, err
+	case OperandMultiANewArray:
+		if inst.Index, err = r.u2(); err != nil {
+			return inst, err
+		}
+		dimensions, err := r.u1()
+		inst.Value = int32(dimensions)
+		return inst, err
+	case OperandNewArray:
+		v, err := r.u1()
+		inst.Value = int32(v)
+		return inst, err
+	case OperandTableSwitch, OperandLookupSwitch:
+		return r.readSwitch(inst)
+	case OperandWide:
+		return r.readWide(inst)
+	}
+	return inst, nil
+}
+
+func (r *codeReader) readWide(inst Instruction) (Instruction, error) {
+	op, err := r.u1()
+	if err != nil {
+		return inst, err
+	}
+	inst.Opcode = Opcode(op)
+	inst.Wide = true
+	switch opcodeInfos[inst.Opcode].operand {
+	case OperandLocal:
+		inst.Index, err = r.u2()
+		return inst, err
+	case OperandIinc:
+		if inst.Index, err = r.u2(); err != nil {
+			return inst, err
+		}
+		v, err := r.u2()
+		inst.Value = int32(int16(v))
+		return inst, err
+	}
+	return inst, fmt.Errorf("opcode %s cannot be modified by wide at offset %d", inst.Opcode, inst.Offset)
+}
+
+func (r *codeReader) readSwitch(inst Instruction) (Instruction, error) {
+	// operands are aligned to a multiple of 4 bytes from the start of the code
+	for r.pos%4 != 0 {
+		if _, err := r.u1(); err != nil {
+			return inst, err
+		}
+	}
+	s := &SwitchTable{}
+	v, err := r.u4()
+	if err != nil {
+		return inst, err
+	}
+	s.Default = int32(v)
+	if inst.Opcode == OpcodeTableswitch {
+		low, err := r.u4()
+		if err != nil {
+			return inst, err
+		}
+		high, err := r.u4()
+		if err != nil {
+			return inst, err
+		}
+		s.Low, s.High = int32(low), int32(high)
+		if s.Low > s.High {
+			return inst, fmt.Errorf("tableswitch low %d is greater than high %d at offset %d", s.Low, s.High, inst.Offset)
+		}
+		n := int64(s.High) - int64(s.Low) + 1
+		if n*4 > int64(len(r.code)-r.pos) {
+			return inst, fmt.Errorf("tableswitch at offset %d exceeds code length", inst.Offset)
+		}
+		s.Offsets = make([]int32, n)
+		for i := range s.Offsets {
+			v, err := r.u4()
+			if err != nil {
+				return inst, err
+			}
+			s.Offsets[i] = int32(v)
+		}
+	} else {
+		npairs, err := r.u4()
+		if err != nil {
+			return inst, err
+		}
+		if int64(npairs)*8 > int64(len(r.code)-r.pos) {
+			return inst, fmt.Errorf("lookupswitch at offset %d exceeds code length", inst.Offset)
+		}
+		s.Keys = make([]int32, npairs)
+		s.Offsets = make([]int32, npairs)
+		for i := range s.Keys {
+			key, err := r.u4()
+			if err != nil {
+				return inst, err
+			}
+			offset, err := r.u4()
+			if err != nil {
+				return inst, err
+			}
+			s.Keys[i], s.Offsets[i] = int32(key), int32(offset)
+		}
+	}
+	inst.Switch = s
+	return inst, nil
+}
+
+func (r *codeReader) u1() (uint8, error) {
+	if r.pos >= len(r.code) {
+		return 0, fmt.Errorf("unexpected end of code at offset %d", r.pos)
+	}
+	v := r.code[r.pos]
+	r.pos++
+	return v, nil
+}
+
+func (r *codeReader) u2() (uint16, error) {
+	if r.pos+2 > len(r.code) {
+		return 0, fmt.Errorf("unexpected end of code at offset %d", r.pos)
+	}
+	v := uint16(r.code[r.pos])<<8 | uint16(r.code[r.pos+1])
+	r.pos += 2
+	return v, nil
+}
+
+func (r *codeReader) u4() (uint32, error) {
+	if r.pos+4 > len(r.code) {
+		return 0, fmt.Errorf("unexpected end of code at offset %d", r.pos)
+	}
+	v := uint32(r.code[r.pos])<<24 | uint32(r.code[r.pos+1])<<16 | uint32(r.code[r.pos+2])<<8 | uint32(r.code[r.pos+3])
+	r.pos += 4
+	return v, nil
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeInstructions(t *testing.T) {
+	pool := ConstantPool{
+		ConstantClassInfo{2},
+		ConstantUtf8Info{[]byte("java/lang/Object")},
+	}
+	tests := []struct {
+		name    string
+		code    []byte
+		want    []Instruction
+		wantErr bool
+	}{
+		{
+			name: "no operands",
+			code: []byte{0x2A, 0xB1},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeAload0},
+				{Offset: 1, Opcode: OpcodeReturn},
+			},
+		},
+		{
+			name: "signed immediates",
+			code: []byte{0x10, 0xFF, 0x11, 0x80, 0x00},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeBipush, Value: -1},
+				{Offset: 2, Opcode: OpcodeSipush, Value: -32768},
+			},
+		},
+		{
+			name: "constant pool reference",
+			code: []byte{0xBB, 0x00, 0x01},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeNew, Index: 1, Constant: ConstantClassInfo{2}},
+			},
+		},
+		{
+			name: "branch",
+			code: []byte{0x00, 0xA7, 0xFF, 0xFF},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeNop},
+				{Offset: 1, Opcode: OpcodeGoto, Branch: -1},
+			},
+		},
+		{
+			name: "wide iinc",
+			code: []byte{0xC4, 0x84, 0x01, 0x00, 0xFF, 0xFE},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeIinc, Wide: true, Index: 256, Value: -2},
+			},
+		},
+		{
+			name: "tableswitch with padding",
+			code: []byte{
+				0x1A, 0xAA, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x1B,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x17,
+				0x00, 0x00, 0x00, 0x19,
+			},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeIload0},
+				{Offset: 1, Opcode: OpcodeTableswitch, Switch: &SwitchTable{Default: 27, Low: 0, High: 1, Offsets: []int32{23, 25}}},
+			},
+		},
+		{
+			name: "lookupswitch",
+			code: []byte{
+				0xAB, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x14,
+				0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x0A,
+				0x00, 0x00, 0x00, 0x12,
+			},
+			want: []Instruction{
+				{Offset: 0, Opcode: OpcodeLookupswitch, Switch: &SwitchTable{Default: 20, Keys: []int32{10}, Offsets: []int32{18}}},
+			},
+		},
+		{
+			name:    "truncated operand",
+			code:    []byte{0x11, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "unknown opcode",
+			code:    []byte{0xCB},
+			wantErr: true,
+		},
+		{
+			name:    "invalid constant index",
+			code:    []byte{0x12, 0x05},
+			wantErr: true,
+		},
+		{
+			name:    "wide with invalid opcode",
+			code:    []byte{0xC4, 0x00},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeInstructions(tt.code, pool)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeInstructions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeInstructions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,702 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type (
+	classFileJSON struct {
+		MinorVersion uint16
+		MajorVersion uint16
+		ConstantPool ConstantPool
+		AccessFlags  AccessFlags
+		ThisClass    uint16
+		SuperClass   uint16
+		Interfaces   []uint16
+		Fields       []fieldJSON
+		Methods      []methodJSON
+		Attributes   Attributes
+	}
+
+	// fieldJSON and methodJSON add the resolved name and descriptor, which are ignored when read.
+	fieldJSON struct {
+		Name       string
+		Descriptor string
+		FieldInfo
+	}
+
+	methodJSON struct {
+		Name       string
+		Descriptor string
+		MethodInfo
+	}
+
+	constantJSON struct {
+		Index                    uint16
+		Tag                      string
+		ClassIndex               *uint16 `json:",omitempty"`
+		NameAndTypeIndex         *uint16 `json:",omitempty"`
+		NameIndex                *uint16 `json:",omitempty"`
+		DescriptorIndex          *uint16 `json:",omitempty"`
+		StringIndex              *uint16 `json:",omitempty"`
+		ReferenceKind            string  `json:",omitempty"`
+		ReferenceIndex           *uint16 `json:",omitempty"`
+		BootstrapMethodAttrIndex *uint16 `json:",omitempty"`
+		Value                    json.RawMessage
+		// Bytes is set for a Utf8 constant which is not the Modified UTF-8 encoding of Value.
+		Bytes []byte `json:",omitempty"`
+		// Bits is set for a NaN, whose payload Value cannot hold.
+		Bits string `json:",omitempty"`
+	}
+
+	// codeAttribute has the fields of CodeAttribute without its methods.
+	codeAttribute CodeAttribute
+)
+
+// attributeTypes are the typed attributes by name, for reading them from JSON.
+var attributeTypes = map[string]Attribute{
+	"ConstantValue":                        &ConstantValueAttribute{},
+	"Code":                                 &CodeAttribute{},
+	"StackMapTable":                        &StackMapTableAttribute{},
+	"Exceptions":                           &ExceptionsAttribute{},
+	"InnerClasses":                         &InnerClassesAttribute{},
+	"EnclosingMethod":                      &EnclosingMethodAttribute{},
+	"Synthetic":                            &SyntheticAttribute{},
+	"Signature":                            &SignatureAttribute{},
+	"SourceFile":                           &SourceFileAttribute{},
+	"SourceDebugExtension":                 &SourceDebugExtensionAttribute{},
+	"LineNumberTable":                      &LineNumberTableAttribute{},
+	"LocalVariableTable":                   &LocalVariableTableAttribute{},
+	"LocalVariableTypeTable":               &LocalVariableTypeTableAttribute{},
+	"Deprecated":                           &DeprecatedAttribute{},
+	"RuntimeVisibleAnnotations":            &RuntimeVisibleAnnotationsAttribute{},
+	"RuntimeInvisibleAnnotations":          &RuntimeInvisibleAnnotationsAttribute{},
+	"RuntimeVisibleParameterAnnotations":   &RuntimeVisibleParameterAnnotationsAttribute{},
+	"RuntimeInvisibleParameterAnnotations": &RuntimeInvisibleParameterAnnotationsAttribute{},
+	"RuntimeVisibleTypeAnnotations":        &RuntimeVisibleTypeAnnotationsAttribute{},
+	"RuntimeInvisibleTypeAnnotations":      &RuntimeInvisibleTypeAnnotationsAttribute{},
+	"AnnotationDefault":                    &AnnotationDefaultAttribute{},
+	"BootstrapMethods":                     &BootstrapMethodsAttribute{},
+	"MethodParameters":                     &MethodParametersAttribute{},
+	"Module":                               &ModuleAttribute{},
+	"ModulePackages":                       &ModulePackagesAttribute{},
+	"ModuleMainClass":                      &ModuleMainClassAttribute{},
+	"NestHost":                             &NestHostAttribute{},
+	"NestMembers":                          &NestMembersAttribute{},
+	"Record":                               &RecordAttribute{},
+	"PermittedSubclasses":                  &PermittedSubclassesAttribute{},
+}
+
+// attributeTypeNames are the names of the types in attributeTypes.
+var attributeTypeNames = map[reflect.Type]string{}
+
+func init() {
+	for name, a := range attributeTypes {
+		attributeTypeNames[reflect.TypeOf(a).Elem()] = name
+	}
+}
+
+// newAttribute returns a new typed attribute for name, or false if it has no type.
+func newAttribute(name string, h AttributeHeader) (Attribute, bool) {
+	a, ok := attributeTypes[name]
+	if !ok {
+		return nil, false
+	}
+	v := reflect.New(reflect.TypeOf(a).Elem())
+	v.Elem().FieldByName("AttributeHeader").Set(reflect.ValueOf(h))
+	return v.Interface().(Attribute), true
+}
+
+// MarshalJSON writes the class file as a JSON object with the fields of ClassFile:
+//
+//	{
+//	  "MinorVersion": 0,
+//	  "MajorVersion": 55,
+//	  "ConstantPool": [
+//	    {"Index": 1, "Tag": "Fieldref", "ClassIndex": 2, "NameAndTypeIndex": 3, "Value": "Foo.bar:I"},
+//	    {"Index": 2, "Tag": "Class", "NameIndex": 4, "Value": "Foo"},
+//	    ...
+//	  ],
+//	  "AccessFlags": ["public", "super"],
+//	  "ThisClass": 2,
+//	  ...
+//	  "Methods": [{"Name": "run", "Descriptor": "()V", "AccessFlags": ["public"], "NameIndex": 7, ...}],
+//	  "Attributes": [{"Attribute": "SourceFile", "NameIndex": 10, "SourceFileIndex": 11}]
+//	}
+//
+// The JSON differs from the Go structure in that
+//   - access flags are arrays of names like ["public", "final"], with unnamed bits like "0x0100"
+//   - the constant pool is an array of entries with their Index, their Tag like "Methodref",
+//     their index fields and their resolved Value, leaving out the second slots of Long and
+//     Double constants. A MethodHandle has its ReferenceKind like "invokeStatic". A Float or
+//     Double without a JSON number has the Value "NaN", "Infinity" or "-Infinity", with the
+//     Bits of a NaN like "0x7FC00001". A Utf8 constant which does not encode its Value has its
+//     Bytes in base64
+//   - attributes have their name as Attribute with the fields of their type, or their
+//     NameIndex and raw bytes as Info in base64 if their type is not known
+//   - fields and methods have their resolved Name and Descriptor, and Code attributes
+//     their decoded Instructions, or null if the code cannot be decoded.
+func (c ClassFile) MarshalJSON() ([]byte, error) {
+	v := classFileJSON{
+		MinorVersion: c.MinorVersion,
+		MajorVersion: c.MajorVersion,
+		ConstantPool: c.ConstantPool,
+		AccessFlags:  c.AccessFlags,
+		ThisClass:    c.ThisClass,
+		SuperClass:   c.SuperClass,
+		Interfaces:   c.Interfaces,
+		Fields:       make([]fieldJSON, len(c.Fields)),
+		Methods:      make([]methodJSON, len(c.Methods)),
+		Attributes:   c.Attributes,
+	}
+	if v.Interfaces == nil {
+		v.Interfaces = []uint16{}
+	}
+	for i, f := range c.Fields {
+		v.Fields[i] = fieldJSON{c.ConstantPool.GetUTF8(f.NameIndex), c.ConstantPool.GetUTF8(f.DescriptorIndex), f}
+	}
+	for i, m := range c.Methods {
+		v.Methods[i] = methodJSON{c.ConstantPool.GetUTF8(m.NameIndex), c.ConstantPool.GetUTF8(m.DescriptorIndex), m}
+	}
+	return marshalJSON(v)
+}
+
+// UnmarshalJSON reads the JSON written by MarshalJSON. It uses the indexes and the Code bytes,
+// ignoring the resolved values: the Name and Descriptor of fields and methods, the Instructions
+// of Code attributes, and the Value of constants other than Utf8, Integer, Float, Long and Double.
+// Constants need consecutive indexes, counting two for Long and Double, and the index fields
+// of their tag. Attributes with an Attribute name are read as its type, the others from Info.
+func (c *ClassFile) UnmarshalJSON(data []byte) error {
+	var v classFileJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = ClassFile{
+		MinorVersion: v.MinorVersion,
+		MajorVersion: v.MajorVersion,
+		ConstantPool: v.ConstantPool,
+		AccessFlags:  v.AccessFlags,
+		ThisClass:    v.ThisClass,
+		SuperClass:   v.SuperClass,
+		Interfaces:   v.Interfaces,
+		Fields:       make([]FieldInfo, len(v.Fields)),
+		Methods:      make([]MethodInfo, len(v.Methods)),
+		Attributes:   v.Attributes,
+	}
+	for i, f := range v.Fields {
+		c.Fields[i] = f.FieldInfo
+	}
+	for i, m := range v.Methods {
+		c.Methods[i] = m.MethodInfo
+	}
+	return nil
+}
+
+func (p ConstantPool) MarshalJSON() ([]byte, error) {
+	entries := make([]constantJSON, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		index := uint16(i + 1)
+		e := constantJSON{Index: index, Tag: constantTagNames[constantTag(p[i])]}
+		var value interface{}
+		switch info := p[i].(type) {
+		case ConstantUtf8Info:
+			s, err := info.Value()
+			if err != nil || !bytes.Equal(EncodeModifiedUTF8(s), info.Bytes) {
+				e.Bytes = info.Bytes
+			}
+			value = s
+		case ConstantIntegerInfo:
+			value = info.Value
+		case ConstantFloatInfo:
+			value = jsonFloat(float64(info.Value), info.Value)
+			if math.IsNaN(float64(info.Value)) {
+				e.Bits = fmt.Sprintf("0x%08X", math.Float32bits(info.Value))
+			}
+		case ConstantLongInfo:
+			value = info.Value
+			i++
+		case ConstantDoubleInfo:
+			value = jsonFloat(info.Value, info.Value)
+			if math.IsNaN(info.Value) {
+				e.Bits = fmt.Sprintf("0x%016X", math.Float64bits(info.Value))
+			}
+			i++
+		case ConstantClassInfo:
+			e.NameIndex = &info.NameIndex
+			value = p.GetUTF8(info.NameIndex)
+		case ConstantStringInfo:
+			e.StringIndex = &info.StringIndex
+			value = p.GetUTF8(info.StringIndex)
+		case ConstantFieldrefInfo:
+			e.ClassIndex, e.NameAndTypeIndex = &info.ClassIndex, &info.NameAndTypeIndex
+			value = p.memberString(index)
+		case ConstantMethodrefInfo:
+			e.ClassIndex, e.NameAndTypeIndex = &info.ClassIndex, &info.NameAndTypeIndex
+			value = p.memberString(index)
+		case ConstantInterfaceMethodrefInfo:
+			e.ClassIndex, e.NameAndTypeIndex = &info.ClassIndex, &info.NameAndTypeIndex
+			value = p.memberString(index)
+		case ConstantNameAndTypeInfo:
+			e.NameIndex, e.DescriptorIndex = &info.NameIndex, &info.DescriptorIndex
+			value = p.nameAndTypeString(index)
+		case ConstantMethodHandleInfo:
+			e.ReferenceKind = info.ReferenceKind.String()
+			if e.ReferenceKind == "unknown" {
+				e.ReferenceKind = strconv.Itoa(int(info.ReferenceKind))
+			}
+			e.ReferenceIndex = &info.ReferenceIndex
+			value = p.memberString(info.ReferenceIndex)
+		case ConstantMethodTypeInfo:
+			e.DescriptorIndex = &info.DescriptorIndex
+			value = p.GetUTF8(info.DescriptorIndex)
+		case ConstantDynamicInfo:
+			e.BootstrapMethodAttrIndex, e.NameAndTypeIndex = &info.BootstrapMethodAttrIndex, &info.NameAndTypeIndex
+			value = fmt.Sprintf("#%d:%s", info.BootstrapMethodAttrIndex, p.nameAndTypeString(info.NameAndTypeIndex))
+		case ConstantInvokeDynamicInfo:
+			e.BootstrapMethodAttrIndex, e.NameAndTypeIndex = &info.BootstrapMethodAttrIndex, &info.NameAndTypeIndex
+			value = fmt.Sprintf("#%d:%s", info.BootstrapMethodAttrIndex, p.nameAndTypeString(info.NameAndTypeIndex))
+		case ConstantModuleInfo:
+			e.NameIndex = &info.NameIndex
+			value = p.GetUTF8(info.NameIndex)
+		case ConstantPackageInfo:
+			e.NameIndex = &info.NameIndex
+			value = p.GetUTF8(info.NameIndex)
+		default:
+			return nil, fmt.Errorf("constant #%d has unknown type %T", index, info)
+		}
+		b, err := marshalJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		e.Value = b
+		entries = append(entries, e)
+	}
+	return marshalJSON(entries)
+}
+
+// jsonFloat returns v, typed as float32 or float64 by typed, or a string for the values JSON has no number for.
+func jsonFloat(v float64, typed interface{}) interface{} {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return typed
+}
+
+// memberString renders a Fieldref, Methodref or InterfaceMethodref like java/lang/Object.<init>:()V.
+func (p ConstantPool) memberString(index uint16) string {
+	info, err := p.lookup(index, ConstantFieldref, ConstantMethodref, ConstantInterfaceMethodref)
+	if err != nil {
+		return ""
+	}
+	var classIndex, nameAndTypeIndex uint16
+	switch ref := info.(type) {
+	case ConstantFieldrefInfo:
+		classIndex, nameAndTypeIndex = ref.ClassIndex, ref.NameAndTypeIndex
+	case ConstantMethodrefInfo:
+		classIndex, nameAndTypeIndex = ref.ClassIndex, ref.NameAndTypeIndex
+	case ConstantInterfaceMethodrefInfo:
+		classIndex, nameAndTypeIndex = ref.ClassIndex, ref.NameAndTypeIndex
+	}
+	return p.GetClass(classIndex) + "." + p.nameAndTypeString(nameAndTypeIndex)
+}
+
+func (p ConstantPool) nameAndTypeString(index uint16) string {
+	nat, err := p.GetNameAndType(index)
+	if err != nil {
+		return ""
+	}
+	return nat.Name + ":" + nat.Descriptor
+}
+
+func (p *ConstantPool) UnmarshalJSON(data []byte) error {
+	var entries []constantJSON
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	pool := make(ConstantPool, 0, len(entries))
+	for _, e := range entries {
+		if int(e.Index) != len(pool)+1 {
+			return fmt.Errorf("constant #%d where #%d is expected", e.Index, len(pool)+1)
+		}
+		info, err := e.info()
+		if err != nil {
+			return fmt.Errorf("constant #%d: %v", e.Index, err)
+		}
+		pool = append(pool, info)
+		if isWideConstant(info) {
+			pool = append(pool, ConstantUnusableInfo{})
+		}
+	}
+	*p = pool
+	return nil
+}
+
+func (e constantJSON) info() (ConstantInfo, error) {
+	var err error
+	index := func(v *uint16, name string) uint16 {
+		if v == nil {
+			if err == nil {
+				err = fmt.Errorf("%s constant without %s", e.Tag, name)
+			}
+			return 0
+		}
+		return *v
+	}
+	var info ConstantInfo
+	switch e.Tag {
+	case "Utf8":
+		if e.Bytes != nil {
+			info = ConstantUtf8Info{e.Bytes}
+			break
+		}
+		var s string
+		err = json.Unmarshal(e.Value, &s)
+		info = ConstantUtf8Info{EncodeModifiedUTF8(s)}
+	case "Integer":
+		var v int32
+		err = json.Unmarshal(e.Value, &v)
+		info = ConstantIntegerInfo{v}
+	case "Float":
+		var v float64
+		v, err = e.float(32)
+		info = ConstantFloatInfo{float32(v)}
+	case "Long":
+		var v int64
+		err = json.Unmarshal(e.Value, &v)
+		info = ConstantLongInfo{v}
+	case "Double":
+		var v float64
+		v, err = e.float(64)
+		info = ConstantDoubleInfo{v}
+	case "Class":
+		info = ConstantClassInfo{index(e.NameIndex, "NameIndex")}
+	case "String":
+		info = ConstantStringInfo{index(e.StringIndex, "StringIndex")}
+	case "Fieldref":
+		info = ConstantFieldrefInfo{index(e.ClassIndex, "ClassIndex"), index(e.NameAndTypeIndex, "NameAndTypeIndex")}
+	case "Methodref":
+		info = ConstantMethodrefInfo{index(e.ClassIndex, "ClassIndex"), index(e.NameAndTypeIndex, "NameAndTypeIndex")}
+	case "InterfaceMethodref":
+		info = ConstantInterfaceMethodrefInfo{index(e.ClassIndex, "ClassIndex"), index(e.NameAndTypeIndex, "NameAndTypeIndex")}
+	case "NameAndType":
+		info = ConstantNameAndTypeInfo{index(e.NameIndex, "NameIndex"), index(e.DescriptorIndex, "DescriptorIndex")}
+	case "MethodHandle":
+		kind, ok := methodHandleRefByName(e.ReferenceKind)
+		if !ok {
+			return nil, fmt.Errorf("unknown reference kind %q", e.ReferenceKind)
+		}
+		info = ConstantMethodHandleInfo{kind, index(e.ReferenceIndex, "ReferenceIndex")}
+	case "MethodType":
+		info = ConstantMethodTypeInfo{index(e.DescriptorIndex, "DescriptorIndex")}
+	case "Dynamic":
+		info = ConstantDynamicInfo{index(e.BootstrapMethodAttrIndex, "BootstrapMethodAttrIndex"), index(e.NameAndTypeIndex, "NameAndTypeIndex")}
+	case "InvokeDynamic":
+		info = ConstantInvokeDynamicInfo{index(e.BootstrapMethodAttrIndex, "BootstrapMethodAttrIndex"), index(e.NameAndTypeIndex, "NameAndTypeIndex")}
+	case "Module":
+		info = ConstantModuleInfo{index(e.NameIndex, "NameIndex")}
+	case "Package":
+		info = ConstantPackageInfo{index(e.NameIndex, "NameIndex")}
+	default:
+		return nil, fmt.Errorf("unknown tag %q", e.Tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// float reads the value of a Float (bitSize 32) or Double constant, using Bits for a NaN.
+func (e constantJSON) float(bitSize int) (float64, error) {
+	var s string
+	if json.Unmarshal(e.Value, &s) == nil {
+		switch s {
+		case "NaN":
+			if e.Bits == "" {
+				return math.NaN(), nil
+			}
+			bits, err := strconv.ParseUint(e.Bits, 0, bitSize)
+			if err != nil {
+				return 0, err
+			}
+			if bitSize == 32 {
+				return float64(math.Float32frombits(uint32(bits))), nil
+			}
+			return math.Float64frombits(bits), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return 0, fmt.Errorf("invalid %s value %q", e.Tag, s)
+	}
+	if bitSize == 32 {
+		var v float32
+		err := json.Unmarshal(e.Value, &v)
+		return float64(v), err
+	}
+	var v float64
+	err := json.Unmarshal(e.Value, &v)
+	return v, err
+}
+
+func methodHandleRefByName(name string) (MethodHandleRef, bool) {
+	for kind := MethodHandleRefGetField; kind <= MethodHandleRefInvokeInterface; kind++ {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+	n, err := strconv.ParseUint(name, 10, 8)
+	return MethodHandleRef(n), err == nil
+}
+
+// MarshalJSON writes the typed attributes as their fields with the name as Attribute,
+// and the others as their NameIndex and raw Info bytes.
+func (a Attributes) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, attribute := range a {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := marshalAttribute(attribute)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+func marshalAttribute(a Attribute) ([]byte, error) {
+	t := reflect.TypeOf(a)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name, ok := attributeTypeNames[t]
+	if !ok {
+		info, err := encodeAttribute(a)
+		if err != nil {
+			return nil, err
+		}
+		return marshalJSON(struct {
+			NameIndex uint16
+			Info      []byte
+		}{a.AttributeNameIndex(), info})
+	}
+	head, err := marshalJSON(struct {
+		Attribute string
+		NameIndex uint16
+	}{name, a.AttributeNameIndex()})
+	if err != nil {
+		return nil, err
+	}
+	fields, err := marshalJSON(a)
+	if err != nil {
+		return nil, err
+	}
+	if string(fields) == "{}" {
+		return head, nil
+	}
+	// Merge the objects, replacing the closing brace of head by a comma.
+	head[len(head)-1] = ','
+	return append(head, fields[1:]...), nil
+}
+
+func (a *Attributes) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	if raws == nil {
+		*a = nil
+		return nil
+	}
+	attributes := make(Attributes, len(raws))
+	for i, raw := range raws {
+		var head struct {
+			Attribute string
+			NameIndex uint16
+			Info      []byte
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return err
+		}
+		if head.Attribute == "" {
+			if head.Info == nil {
+				head.Info = []byte{}
+			}
+			attributes[i] = &AttributeInfo{head.NameIndex, head.Info}
+			continue
+		}
+		attribute, ok := newAttribute(head.Attribute, AttributeHeader{head.NameIndex})
+		if !ok {
+			return fmt.Errorf("unknown attribute %s", head.Attribute)
+		}
+		if err := json.Unmarshal(raw, attribute); err != nil {
+			return fmt.Errorf("%s attribute: %v", head.Attribute, err)
+		}
+		attributes[i] = attribute
+	}
+	*a = attributes
+	return nil
+}
+
+// MarshalJSON adds the decoded Instructions, or null if the code cannot be decoded.
+func (c CodeAttribute) MarshalJSON() ([]byte, error) {
+	instructions, err := DecodeInstructions(c.Code, nil)
+	if err != nil {
+		instructions = nil
+	}
+	return marshalJSON(struct {
+		codeAttribute
+		Instructions []Instruction
+	}{codeAttribute(c), instructions})
+}
+
+func (o Opcode) MarshalJSON() ([]byte, error) {
+	return marshalJSON(o.String())
+}
+
+func (o *Opcode) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	opcode, ok := OpcodeByName(name)
+	if !ok {
+		return fmt.Errorf("unknown opcode %q", name)
+	}
+	*o = opcode
+	return nil
+}
+
+func (t ElementValueTag) MarshalJSON() ([]byte, error) {
+	return marshalJSON(string(rune(t)))
+}
+
+func (t *ElementValueTag) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if len(s) != 1 {
+		return fmt.Errorf("invalid element value tag %q", s)
+	}
+	*t = ElementValueTag(s[0])
+	return nil
+}
+
+func (t VerificationType) MarshalJSON() ([]byte, error) {
+	if t > VerificationUninitialized {
+		return marshalJSON(uint8(t))
+	}
+	return marshalJSON(t.String())
+}
+
+func (t *VerificationType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n uint8
+		if json.Unmarshal(data, &n) != nil {
+			return err
+		}
+		*t = VerificationType(n)
+		return nil
+	}
+	for v := VerificationTop; v <= VerificationUninitialized; v++ {
+		if v.String() == s {
+			*t = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown verification type %q", s)
+}
+
+func (a AccessFlags) MarshalJSON() ([]byte, error) {
+	return marshalFlags(uint16(a), classFlagNames)
+}
+
+func (a *AccessFlags) UnmarshalJSON(data []byte) error {
+	flags, err := unmarshalFlags(data, classFlagNames)
+	*a = AccessFlags(flags)
+	return err
+}
+
+func (a FieldAccessFlags) MarshalJSON() ([]byte, error) {
+	return marshalFlags(uint16(a), fieldFlagNames)
+}
+
+func (a *FieldAccessFlags) UnmarshalJSON(data []byte) error {
+	flags, err := unmarshalFlags(data, fieldFlagNames)
+	*a = FieldAccessFlags(flags)
+	return err
+}
+
+func (a MethodAccessFlags) MarshalJSON() ([]byte, error) {
+	return marshalFlags(uint16(a), methodFlagNames)
+}
+
+func (a *MethodAccessFlags) UnmarshalJSON(data []byte) error {
+	flags, err := unmarshalFlags(data, methodFlagNames)
+	*a = MethodAccessFlags(flags)
+	return err
+}
+
+// marshalFlags writes the names of flags, and the bits without a name like "0x0100".
+func marshalFlags(flags uint16, names []flagName) ([]byte, error) {
+	s := flagNames(flags, names)
+	for _, n := range names {
+		flags &^= n.flag
+	}
+	if flags != 0 {
+		s = append(s, fmt.Sprintf("0x%04X", flags))
+	}
+	return marshalJSON(s)
+}
+
+func unmarshalFlags(data []byte, names []flagName) (uint16, error) {
+	var s []string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, err
+	}
+	var flags uint16
+	for _, name := range s {
+		if flag, ok := flagByName(name, names); ok {
+			flags |= flag
+			continue
+		}
+		if !strings.HasPrefix(name, "0x") {
+			return 0, fmt.Errorf("unknown access flag %q", name)
+		}
+		bits, err := strconv.ParseUint(name, 0, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid access flags %q", name)
+		}
+		flags |= uint16(bits)
+	}
+	return flags, nil
+}
+
+// marshalJSON is json.Marshal without escaping <, > and &, which are common in names like <init>.
+// Marshalers calling json.Marshal would escape them even for an Encoder with SetEscapeHTML(false).
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
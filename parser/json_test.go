@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestClassFile_JSON(t *testing.T) {
+	b := NewClassBuilder("com/acme/Foo")
+	pool := b.Pool()
+	init := pool.Methodref("java/lang/Object", "<init>", "()V")
+	pool.Float(math.Float32frombits(0x7FC00001))
+	pool.Double(math.Copysign(0, -1))
+	pool.Double(math.Inf(-1))
+	pool.Long(math.MaxInt64)
+	pool.MethodHandle(MethodHandleRefInvokeSpecial, init)
+	pool.String("caf\u00e9 \U0001F600")
+	c, err := b.Super("java/lang/Object").
+		Access(AccessPublic|AccessSuper|0x0100).
+		AddField(FieldAccessPrivate|FieldAccessFinal, "name", "Ljava/lang/String;", &SyntheticAttribute{b.header("Synthetic")}).
+		AddMethod(MethodAccessPublic, "<init>", "()V", b.Code(1, 1, []byte{0x2A, 0xB7, byte(init >> 8), byte(init), 0xB1})).
+		AddAttribute(b.SourceFile("Foo.java"), &AttributeInfo{b.Pool().Utf8("com.acme.Vendor"), []byte{1, 2, 3}}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Utf8 constant which is not valid Modified UTF-8 is kept as its bytes.
+	c.ConstantPool = append(c.ConstantPool, ConstantUtf8Info{[]byte{0xC0}})
+
+	data, err := marshalJSON(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"AccessFlags":["public","super","0x0100"]`,
+		`{"Index":2,"Tag":"Class","NameIndex":1,"Value":"com/acme/Foo"}`,
+		`"Tag":"Methodref","ClassIndex":4,"NameAndTypeIndex":7,"Value":"java/lang/Object.<init>:()V"`,
+		`"Tag":"Float","Value":"NaN","Bits":"0x7FC00001"`,
+		`"Tag":"Double","Value":-0}`,
+		`"Tag":"Long","Value":9223372036854775807}`,
+		`"Tag":"MethodHandle","ReferenceKind":"invokeSpecial","ReferenceIndex":8`,
+		`"Name":"name","Descriptor":"Ljava/lang/String;","AccessFlags":["private","final"]`,
+		`"Attributes":[{"Attribute":"Synthetic","NameIndex":`,
+		`"Instructions":[{"Offset":0,"Opcode":"aload_0"},{"Offset":1,"Opcode":"invokespecial","Index":8},{"Offset":4,"Opcode":"return"}]`,
+		`{"Attribute":"SourceFile","NameIndex":`,
+		`,"Info":"AQID"}`,
+		"\"Tag\":\"Utf8\",\"Value\":\"\uFFFD\",\"Bytes\":\"wA==\"}",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON does not contain %s:\n%s", want, data)
+		}
+	}
+
+	var again ClassFile
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	var want, got bytes.Buffer
+	if err := Write(&want, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&got, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("class file read back from JSON differs")
+	}
+}
+
+func TestClassFile_UnmarshalJSON_Error(t *testing.T) {
+	tests := []struct {
+		json string
+		msg  string
+	}{
+		{`{"ConstantPool":[{"Index":2,"Tag":"Integer","Value":1}]}`, "constant #2 where #1 is expected"},
+		{`{"ConstantPool":[{"Index":1,"Tag":"Class"}]}`, "Class constant without NameIndex"},
+		{`{"ConstantPool":[{"Index":1,"Tag":"Bogus"}]}`, `unknown tag "Bogus"`},
+		{`{"AccessFlags":["public","sealed"]}`, `unknown access flag "sealed"`},
+		{`{"Attributes":[{"Attribute":"Frobnicate"}]}`, "unknown attribute Frobnicate"},
+	}
+	for _, tt := range tests {
+		var c ClassFile
+		if err := json.Unmarshal([]byte(tt.json), &c); err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("Unmarshal(%s) error = %v, want %s", tt.json, err, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"go-javap/descriptor"
+)
+
+type (
+	// Field is a field of a class with its constant pool references resolved.
+	Field struct {
+		Name        string
+		Descriptor  string
+		Signature   string
+		AccessFlags FieldAccessFlags
+		// ConstantValue is the value of the ConstantValue attribute typed by the field descriptor
+		// (bool, int8, uint16, int16, int32, int64, float32, float64 or string), or nil if there is none.
+		ConstantValue interface{}
+		Annotations   []Annotation
+		Attributes    []Attribute
+	}
+
+	// Method is a method of a class with its constant pool references resolved.
+	Method struct {
+		Name        string
+		Descriptor  string
+		Signature   string
+		AccessFlags MethodAccessFlags
+		// Exceptions are the internal names of the classes in the throws clause.
+		Exceptions  []string
+		Annotations []Annotation
+		// ParameterNames are taken from the MethodParameters attribute, or the LocalVariableTable
+		// if the class was compiled with debug information. It is nil if the names are unknown.
+		ParameterNames []string
+		// ParameterAnnotations are the annotations of each parameter, or nil if there are none.
+		// Their number may differ from the parameters of the descriptor for the synthetic
+		// parameters of some compilers.
+		ParameterAnnotations [][]Annotation
+		// AnnotationDefault is the default value of an element of an annotation type, as an
+		// AnnotationElement value, or nil if there is none.
+		AnnotationDefault interface{}
+		// Code is nil for abstract and native methods.
+		Code       *CodeAttribute
+		Attributes []Attribute
+	}
+)
+
+func (c *Class) Fields() []Field {
+	fields := make([]Field, len(c.classFile.Fields))
+	for i, f := range c.classFile.Fields {
+		fields[i] = c.field(f)
+	}
+	return fields
+}
+
+func (c *Class) Methods() []Method {
+	methods := make([]Method, len(c.classFile.Methods))
+	for i, m := range c.classFile.Methods {
+		methods[i] = c.method(m)
+	}
+	return methods
+}
+
+// FieldByName returns the field with the given name, or nil if there is none.
+func (c *Class) FieldByName(name string) *Field {
+	pool := c.classFile.ConstantPool
+	for _, f := range c.classFile.Fields {
+		if pool.GetUTF8(f.NameIndex) == name {
+			field := c.field(f)
+			return &field
+		}
+	}
+	return nil
+}
+
+// Method returns the method with the given name and descriptor, or nil if there is none.
+func (c *Class) Method(name, descriptor string) *Method {
+	pool := c.classFile.ConstantPool
+	for _, m := range c.classFile.Methods {
+		if pool.GetUTF8(m.NameIndex) == name && pool.GetUTF8(m.DescriptorIndex) == descriptor {
+			method := c.method(m)
+			return &method
+		}
+	}
+	return nil
+}
+
+// MethodsByName returns the methods with the given name, which are overloads of each other.
+func (c *Class) MethodsByName(name string) []Method {
+	pool := c.classFile.ConstantPool
+	methods := make([]Method, 0)
+	for _, m := range c.classFile.Methods {
+		if pool.GetUTF8(m.NameIndex) == name {
+			methods = append(methods, c.method(m))
+		}
+	}
+	return methods
+}
+
+// field resolves the view of a field of the class.
+func (c *Class) field(f FieldInfo) Field {
+	pool := c.classFile.ConstantPool
+	field := Field{
+		Name:        pool.GetUTF8(f.NameIndex),
+		Descriptor:  pool.GetUTF8(f.DescriptorIndex),
+		Signature:   f.Signature(pool),
+		AccessFlags: f.AccessFlags,
+		Annotations: resolveAnnotations(f.Attributes, pool),
+		Attributes:  f.Attributes,
+	}
+	if index, ok := f.ConstantValueIndex(); ok {
+		field.ConstantValue = constantValue(pool, index, field.Descriptor)
+	}
+	return field
+}
+
+// method resolves the view of a method of the class.
+func (c *Class) method(m MethodInfo) Method {
+	pool := c.classFile.ConstantPool
+	method := Method{
+		Name:                 pool.GetUTF8(m.NameIndex),
+		Descriptor:           pool.GetUTF8(m.DescriptorIndex),
+		Signature:            m.Signature(pool),
+		AccessFlags:          m.AccessFlags,
+		Annotations:          resolveAnnotations(m.Attributes, pool),
+		ParameterNames:       parameterNames(m, pool),
+		ParameterAnnotations: resolveParameterAnnotations(m.Attributes, pool),
+		AnnotationDefault:    resolveAnnotationDefault(m.Attributes, pool),
+		Code:                 m.Code(),
+		Attributes:           m.Attributes,
+	}
+	if exceptions := m.Exceptions(); exceptions != nil {
+		method.Exceptions = make([]string, len(exceptions))
+		for j, e := range exceptions {
+			method.Exceptions[j] = pool.GetClass(e)
+		}
+	}
+	return method
+}
+
+// constantValue resolves a ConstantValue attribute to a Go value matching the field descriptor.
+func constantValue(pool ConstantPool, index uint16, desc string) interface{} {
+	info, err := pool.get(index)
+	if err != nil {
+		return nil
+	}
+	switch info := info.(type) {
+	case ConstantIntegerInfo:
+		if desc == "" {
+			return info.Value
+		}
+		switch descriptor.Kind(desc[0]) {
+		case descriptor.Boolean:
+			return info.Value != 0
+		case descriptor.Byte:
+			return int8(info.Value)
+		case descriptor.Char:
+			return uint16(info.Value)
+		case descriptor.Short:
+			return int16(info.Value)
+		}
+		return info.Value
+	case ConstantLongInfo:
+		return info.Value
+	case ConstantFloatInfo:
+		return info.Value
+	case ConstantDoubleInfo:
+		return info.Value
+	case ConstantStringInfo:
+		return pool.GetUTF8(info.StringIndex)
+	}
+	return nil
+}
+
+func parameterNames(m MethodInfo, pool ConstantPool) []string {
+	for _, a := range m.Attributes {
+		if p, ok := a.(*MethodParametersAttribute); ok {
+			names := make([]string, len(p.Parameters))
+			for i, param := range p.Parameters {
+				if param.NameIndex != 0 {
+					names[i] = pool.GetUTF8(param.NameIndex)
+				}
+			}
+			return names
+		}
+	}
+	code := m.Code()
+	if code == nil {
+		return nil
+	}
+	locals := code.LocalVariableTable()
+	if locals == nil {
+		return nil
+	}
+	desc, err := m.Descriptor(pool)
+	if err != nil {
+		return nil
+	}
+	slot := uint16(0)
+	if !m.AccessFlags.Static() {
+		slot++
+	}
+	names := make([]string, len(desc.Parameters))
+	for i, param := range desc.Parameters {
+		for _, local := range locals {
+			if local.Index == slot && local.StartPC == 0 {
+				names[i] = pool.GetUTF8(local.NameIndex)
+				break
+			}
+		}
+		if names[i] == "" {
+			return nil
+		}
+		slot += uint16(param.Slots())
+	}
+	return names
+}
@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testClass() *Class {
+	utf8 := func(s string) ConstantInfo { return ConstantUtf8Info{[]byte(s)} }
+	pool := ConstantPool{
+		utf8("MAX"),                       // 1
+		utf8("I"),                         // 2
+		ConstantIntegerInfo{10},           // 3
+		utf8("flag"),                      // 4
+		utf8("Z"),                         // 5
+		ConstantIntegerInfo{1},            // 6
+		utf8("items"),                     // 7
+		utf8("Ljava/util/List;"),          // 8
+		utf8("Ljava/util/List<TT;>;"),     // 9
+		utf8("Ljava/lang/Deprecated;"),    // 10
+		utf8("get"),                       // 11
+		utf8("(IJLjava/lang/String;)TT;"), // 12
+		utf8("(IJLjava/lang/String;)Ljava/lang/Object;"), // 13
+		utf8("java/io/IOException"),                      // 14
+		ConstantClassInfo{14},                            // 15
+		utf8("index"),                                    // 16
+		utf8("timeout"),                                  // 17
+		utf8("name"),                                     // 18
+		utf8("this"),                                     // 19
+		utf8("of"),                                       // 20
+		utf8("(Ljava/lang/String;)V"),                    // 21
+		utf8("Ljavax/ws/rs/Path;"),                       // 22
+		utf8("value"),                                    // 23
+		utf8("/users"),                                   // 24
+		utf8("Ljavax/ws/rs/QueryParam;"),                 // 25
+		utf8("Ljavax/inject/Singleton;"),                 // 26
+		utf8("limit"),                                    // 27
+		utf8("()I"),                                      // 28
+		ConstantIntegerInfo{100},                         // 29
+	}
+	deprecated := &RuntimeVisibleAnnotationsAttribute{Annotations: []AnnotationInfo{{TypeIndex: 10}}}
+	path := &RuntimeInvisibleAnnotationsAttribute{Annotations: []AnnotationInfo{{
+		TypeIndex: 22,
+		ElementValuePairs: []ElementValuePair{
+			{23, ElementValueInfo{Tag: ElementValueArray, Values: []ElementValueInfo{{Tag: ElementValueString, ConstValueIndex: 24}}}},
+		},
+	}}}
+	query := &RuntimeVisibleParameterAnnotationsAttribute{ParameterAnnotations: [][]AnnotationInfo{{{
+		TypeIndex:         25,
+		ElementValuePairs: []ElementValuePair{{23, ElementValueInfo{Tag: ElementValueString, ConstValueIndex: 18}}},
+	}}}}
+	return &Class{&ClassFile{
+		ConstantPool: pool,
+		Attributes:   []Attribute{&RuntimeVisibleAnnotationsAttribute{Annotations: []AnnotationInfo{{TypeIndex: 26}}}},
+		Fields: []FieldInfo{
+			{FieldAccessPublic | FieldAccessStatic | FieldAccessFinal, 1, 2, []Attribute{&ConstantValueAttribute{ConstantValueIndex: 3}}},
+			{FieldAccessStatic | FieldAccessFinal, 4, 5, []Attribute{&ConstantValueAttribute{ConstantValueIndex: 6}}},
+			{FieldAccessPrivate, 7, 8, []Attribute{&SignatureAttribute{SignatureIndex: 9}, deprecated}},
+		},
+		Methods: []MethodInfo{
+			{MethodAccessPublic, 11, 13, []Attribute{
+				&SignatureAttribute{SignatureIndex: 12},
+				&ExceptionsAttribute{ExceptionIndexTable: []uint16{15}},
+				&CodeAttribute{Attributes: []Attribute{&LocalVariableTableAttribute{LocalVariableTable: []LocalVariableTableEntry{
+					{0, 10, 19, 8, 0},
+					{0, 10, 16, 2, 1},
+					{0, 10, 17, 2, 2},
+					{0, 10, 18, 8, 4},
+				}}}},
+			}},
+			{MethodAccessPublic | MethodAccessStatic, 20, 21, []Attribute{
+				&MethodParametersAttribute{Parameters: []MethodParameter{{18, 0}}},
+				path,
+				query,
+			}},
+			{MethodAccessPublic | MethodAccessAbstract, 20, 13, nil},
+			{MethodAccessPublic | MethodAccessAbstract, 27, 28, []Attribute{&AnnotationDefaultAttribute{DefaultValue: ElementValueInfo{Tag: ElementValueInt, ConstValueIndex: 29}}}},
+		},
+	}}
+}
+
+func TestClass_Fields(t *testing.T) {
+	want := []Field{
+		{Name: "MAX", Descriptor: "I", AccessFlags: FieldAccessPublic | FieldAccessStatic | FieldAccessFinal, ConstantValue: int32(10), Annotations: []Annotation{}},
+		{Name: "flag", Descriptor: "Z", AccessFlags: FieldAccessStatic | FieldAccessFinal, ConstantValue: true, Annotations: []Annotation{}},
+		{Name: "items", Descriptor: "Ljava/util/List;", Signature: "Ljava/util/List<TT;>;", AccessFlags: FieldAccessPrivate,
+			Annotations: []Annotation{{Type: "Ljava/lang/Deprecated;", Visible: true, Elements: []AnnotationElement{}}}},
+	}
+	fields := testClass().Fields()
+	if len(fields) != len(want) {
+		t.Fatalf("Fields() returned %d fields, want %d", len(fields), len(want))
+	}
+	for i, f := range fields {
+		f.Attributes = nil
+		if !reflect.DeepEqual(f, want[i]) {
+			t.Errorf("Fields()[%d] = %#v, want %#v", i, f, want[i])
+		}
+	}
+}
+
+func TestClass_Methods(t *testing.T) {
+	c := testClass()
+	get := c.Method("get", "(IJLjava/lang/String;)Ljava/lang/Object;")
+	if get == nil {
+		t.Fatal("Method(get) = nil")
+	}
+	if get.Signature != "(IJLjava/lang/String;)TT;" {
+		t.Errorf("Signature = %q", get.Signature)
+	}
+	if want := []string{"java/io/IOException"}; !reflect.DeepEqual(get.Exceptions, want) {
+		t.Errorf("Exceptions = %v, want %v", get.Exceptions, want)
+	}
+	if want := []string{"index", "timeout", "name"}; !reflect.DeepEqual(get.ParameterNames, want) {
+		t.Errorf("ParameterNames = %v, want %v", get.ParameterNames, want)
+	}
+	if get.Code == nil {
+		t.Error("Code = nil")
+	}
+
+	of := c.Method("of", "(Ljava/lang/String;)V")
+	if of == nil {
+		t.Fatal("Method(of) = nil")
+	}
+	if want := []string{"name"}; !reflect.DeepEqual(of.ParameterNames, want) {
+		t.Errorf("ParameterNames = %v, want %v", of.ParameterNames, want)
+	}
+	wantAnnotations := []Annotation{{
+		Type:     "Ljavax/ws/rs/Path;",
+		Elements: []AnnotationElement{{"value", []interface{}{"/users"}}},
+	}}
+	if !reflect.DeepEqual(of.Annotations, wantAnnotations) {
+		t.Errorf("Annotations = %#v, want %#v", of.Annotations, wantAnnotations)
+	}
+	wantParameters := [][]Annotation{{{
+		Type:     "Ljavax/ws/rs/QueryParam;",
+		Visible:  true,
+		Elements: []AnnotationElement{{"value", "name"}},
+	}}}
+	if !reflect.DeepEqual(of.ParameterAnnotations, wantParameters) {
+		t.Errorf("ParameterAnnotations = %#v, want %#v", of.ParameterAnnotations, wantParameters)
+	}
+	if get.ParameterAnnotations != nil || get.AnnotationDefault != nil {
+		t.Errorf("get has parameter annotations %v or default %v", get.ParameterAnnotations, get.AnnotationDefault)
+	}
+	if limit := c.Method("limit", "()I"); limit == nil || limit.AnnotationDefault != int32(100) {
+		t.Errorf("Method(limit) = %v, want a default of 100", limit)
+	}
+
+	if overloads := c.MethodsByName("of"); len(overloads) != 2 {
+		t.Errorf("MethodsByName(of) returned %d methods, want 2", len(overloads))
+	}
+	if m := c.Method("get", "()V"); m != nil {
+		t.Errorf("Method(get, ()V) = %v, want nil", m)
+	}
+	if abstract := c.Method("of", "(IJLjava/lang/String;)Ljava/lang/Object;"); abstract.Code != nil || abstract.ParameterNames != nil {
+		t.Errorf("abstract method has code or parameter names")
+	}
+}
+
+func TestClass_FieldByName(t *testing.T) {
+	c := testClass()
+	if f := c.FieldByName("flag"); f == nil || f.Descriptor != "Z" {
+		t.Errorf("FieldByName(flag) = %v", f)
+	}
+	if f := c.FieldByName("missing"); f != nil {
+		t.Errorf("FieldByName(missing) = %v, want nil", f)
+	}
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"fmt"
 	"strings"
+
+	"go-javap/descriptor"
 )
 
 type (
@@ -10,7 +12,7 @@ type (
 		AccessFlags     MethodAccessFlags
 		NameIndex       uint16
 		DescriptorIndex uint16
-		Attributes      []AttributeInfo
+		Attributes      Attributes
 	}
 
 	MethodAccessFlags uint16
@@ -35,6 +37,35 @@ func (f MethodInfo) String() string {
 	return fmt.Sprintf("Method[flags=%s, nameIndex=%d, descriptorIndex=%d]", f.AccessFlags, f.NameIndex, f.DescriptorIndex)
 }
 
+// Code returns the Code attribute of the method, or nil for abstract and native methods.
+func (f MethodInfo) Code() *CodeAttribute {
+	for _, a := range f.Attributes {
+		if c, ok := a.(*CodeAttribute); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// Exceptions returns the constant pool indexes of the classes declared in the throws clause.
+func (f MethodInfo) Exceptions() []uint16 {
+	for _, a := range f.Attributes {
+		if e, ok := a.(*ExceptionsAttribute); ok {
+			return e.ExceptionIndexTable
+		}
+	}
+	return nil
+}
+
+// Descriptor parses the descriptor of the method.
+func (f MethodInfo) Descriptor(pool ConstantPool) (descriptor.Method, error) {
+	return descriptor.ParseMethod(pool.GetUTF8(f.DescriptorIndex))
+}
+
+func (f MethodInfo) Signature(pool ConstantPool) string {
+	return findSignature(f.Attributes, pool)
+}
+
 func (a MethodAccessFlags) Public() bool {
 	return a.is(MethodAccessPublic)
 }
@@ -83,45 +114,35 @@ func (a MethodAccessFlags) Synthetic() bool {
 	return a.is(MethodAccessSynthetic)
 }
 
+// methodFlagNames are the names of the flags in the order they are written.
+var methodFlagNames = []flagName{
+	{MethodAccessPublic, "public"},
+	{MethodAccessPrivate, "private"},
+	{MethodAccessProtected, "protected"},
+	{MethodAccessStatic, "static"},
+	{MethodAccessFinal, "final"},
+	{MethodAccessSynchronized, "synchronized"},
+	{MethodAccessBridge, "bridge"},
+	{MethodAccessVarArgs, "varargs"},
+	{MethodAccessNative, "native"},
+	{MethodAccessAbstract, "abstract"},
+	{MethodAccessStrict, "strict"},
+	{MethodAccessSynthetic, "synthetic"},
+}
+
 func (a MethodAccessFlags) String() string {
-	flags := make([]string, 0)
-	if a.Public() {
-		flags = append(flags, "public")
-	}
-	if a.Private() {
-		flags = append(flags, "private")
-	}
-	if a.Protected() {
-		flags = append(flags, "protected")
-	}
-	if a.Static() {
-		flags = append(flags, "static")
-	}
-	if a.Final() {
-		flags = append(flags, "final")
-	}
-	if a.Synchronized() {
-		flags = append(flags, "synchronized")
-	}
-	if a.Bridge() {
-		flags = append(flags, "bridge")
-	}
-	if a.VarArgs() {
-		flags = append(flags, "varargs")
-	}
-	if a.Native() {
-		flags = append(flags, "native")
-	}
-	if a.Abstract() {
-		flags = append(flags, "abstract")
-	}
-	if a.Strict() {
-		flags = append(flags, "strict")
-	}
-	if a.Synthetic() {
-		flags = append(flags, "synthetic")
-	}
-	return fmt.Sprintf("AccessFlags[%s]", strings.Join(flags, ", "))
+	return fmt.Sprintf("AccessFlags[%s]", strings.Join(flagNames(uint16(a), methodFlagNames), ", "))
+}
+
+// Names returns the lower case names of the flags like public, in the order they are written.
+func (a MethodAccessFlags) Names() []string {
+	return flagNames(uint16(a), methodFlagNames)
+}
+
+// MethodAccessFlag returns the method flag with the name returned by MethodAccessFlags.Names.
+func MethodAccessFlag(name string) (MethodAccessFlags, bool) {
+	flag, ok := flagByName(name, methodFlagNames)
+	return MethodAccessFlags(flag), ok
 }
 
 func (a MethodAccessFlags) is(n uint16) bool {
@@ -0,0 +1,144 @@
+package parser
+
+type (
+	ModuleAttribute struct {
+		AttributeHeader
+		ModuleNameIndex    uint16
+		ModuleFlags        uint16
+		ModuleVersionIndex uint16
+		Requires           []ModuleRequires
+		Exports            []ModuleExports
+		Opens              []ModuleOpens
+		UsesIndex          []uint16
+		Provides           []ModuleProvides
+	}
+
+	ModuleRequires struct {
+		RequiresIndex        uint16
+		RequiresFlags        uint16
+		RequiresVersionIndex uint16
+	}
+
+	ModuleExports struct {
+		ExportsIndex   uint16
+		ExportsFlags   uint16
+		ExportsToIndex []uint16
+	}
+
+	ModuleOpens struct {
+		OpensIndex   uint16
+		OpensFlags   uint16
+		OpensToIndex []uint16
+	}
+
+	ModuleProvides struct {
+		ProvidesIndex     uint16
+		ProvidesWithIndex []uint16
+	}
+
+	ModulePackagesAttribute struct {
+		AttributeHeader
+		PackageIndex []uint16
+	}
+
+	ModuleMainClassAttribute struct {
+		AttributeHeader
+		MainClassIndex uint16
+	}
+)
+
+func init() {
+	RegisterAttribute("Module", decodeModule)
+	RegisterAttribute("ModulePackages", decodeModulePackages)
+	RegisterAttribute("ModuleMainClass", decodeModuleMainClass)
+}
+
+func decodeModule(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &ModuleAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	a.ModuleNameIndex = r.u2()
+	a.ModuleFlags = r.u2()
+	a.ModuleVersionIndex = r.u2()
+	if count := r.u2(); r.check(int(count) * 6) {
+		a.Requires = make([]ModuleRequires, count)
+		for i := range a.Requires {
+			a.Requires[i] = ModuleRequires{r.u2(), r.u2(), r.u2()}
+		}
+	}
+	if count := r.u2(); r.check(int(count) * 6) {
+		a.Exports = make([]ModuleExports, count)
+		for i := range a.Exports {
+			a.Exports[i] = ModuleExports{r.u2(), r.u2(), r.u2s()}
+		}
+	}
+	if count := r.u2(); r.check(int(count) * 6) {
+		a.Opens = make([]ModuleOpens, count)
+		for i := range a.Opens {
+			a.Opens[i] = ModuleOpens{r.u2(), r.u2(), r.u2s()}
+		}
+	}
+	a.UsesIndex = r.u2s()
+	if count := r.u2(); r.check(int(count) * 4) {
+		a.Provides = make([]ModuleProvides, count)
+		for i := range a.Provides {
+			a.Provides[i] = ModuleProvides{r.u2(), r.u2s()}
+		}
+	}
+	return a, r.finish()
+}
+
+func decodeModulePackages(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &ModulePackagesAttribute{AttributeHeader{nameIndex}, r.u2s()}
+	return a, r.finish()
+}
+
+func decodeModuleMainClass(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &ModuleMainClassAttribute{AttributeHeader{nameIndex}, r.u2()}
+	return a, r.finish()
+}
+
+func (a ModuleAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.ModuleNameIndex)
+	w.u2(a.ModuleFlags)
+	w.u2(a.ModuleVersionIndex)
+	w.count(len(a.Requires), "requires")
+	for _, r := range a.Requires {
+		w.u2(r.RequiresIndex)
+		w.u2(r.RequiresFlags)
+		w.u2(r.RequiresVersionIndex)
+	}
+	w.count(len(a.Exports), "exports")
+	for _, e := range a.Exports {
+		w.u2(e.ExportsIndex)
+		w.u2(e.ExportsFlags)
+		w.u2s(e.ExportsToIndex)
+	}
+	w.count(len(a.Opens), "opens")
+	for _, o := range a.Opens {
+		w.u2(o.OpensIndex)
+		w.u2(o.OpensFlags)
+		w.u2s(o.OpensToIndex)
+	}
+	w.u2s(a.UsesIndex)
+	w.count(len(a.Provides), "provides")
+	for _, p := range a.Provides {
+		w.u2(p.ProvidesIndex)
+		w.u2s(p.ProvidesWithIndex)
+	}
+	return w.finish()
+}
+
+func (a ModulePackagesAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2s(a.PackageIndex)
+	return w.finish()
+}
+
+func (a ModuleMainClassAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.u2(a.MainClassIndex)
+	return w.finish()
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeModifiedUTF8 decodes the Modified UTF-8 of Utf8 constants, which encodes NUL in two bytes
+// and characters outside the Basic Multilingual Plane as two encoded UTF-16 surrogates.
+// Surrogate pairs are combined into one rune and unpaired surrogates become U+FFFD.
+// Malformed bytes are reported by the error and decoded as U+FFFD.
+func DecodeModifiedUTF8(b []byte) (string, error) {
+	ascii := true
+	for _, c := range b {
+		if c == 0 || c >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(b), nil
+	}
+
+	var err error
+	s := make([]byte, 0, len(b))
+	high := rune(-1)
+	for i := 0; i < len(b); {
+		c, n := decodeModifiedUTF8Char(b[i:])
+		if n == 0 {
+			if err == nil {
+				err = fmt.Errorf("malformed Modified UTF-8 at byte %d", i)
+			}
+			c, n = utf8.RuneError, 1
+		}
+		i += n
+		if high >= 0 {
+			if utf16.IsSurrogate(c) && c >= 0xDC00 {
+				s = appendRune(s, utf16.DecodeRune(high, c))
+				high = -1
+				continue
+			}
+			s = appendRune(s, utf8.RuneError)
+			high = -1
+		}
+		if utf16.IsSurrogate(c) && c < 0xDC00 {
+			high = c
+			continue
+		}
+		s = appendRune(s, c)
+	}
+	if high >= 0 {
+		s = appendRune(s, utf8.RuneError)
+	}
+	return string(s), err
+}
+
+// decodeModifiedUTF8Char decodes the character at the start of b, returning 0 bytes if malformed.
+func decodeModifiedUTF8Char(b []byte) (rune, int) {
+	switch c := b[0]; {
+	case c != 0 && c < utf8.RuneSelf:
+		return rune(c), 1
+	case c&0xE0 == 0xC0:
+		if len(b) >= 2 && b[1]&0xC0 == 0x80 {
+			return rune(c&0x1F)<<6 | rune(b[1]&0x3F), 2
+		}
+	case c&0xF0 == 0xE0:
+		if len(b) >= 3 && b[1]&0xC0 == 0x80 && b[2]&0xC0 == 0x80 {
+			return rune(c&0x0F)<<12 | rune(b[1]&0x3F)<<6 | rune(b[2]&0x3F), 3
+		}
+	}
+	return 0, 0
+}
+
+// appendRune appends the UTF-8 encoding of c, which is U+FFFD for surrogates.
+func appendRune(b []byte, c rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], c)
+	return append(b, buf[:n]...)
+}
+
+// EncodeModifiedUTF8 encodes s in the Modified UTF-8 of Utf8 constants.
+// Invalid UTF-8 in s is encoded as U+FFFD.
+func EncodeModifiedUTF8(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, c := range s {
+		switch {
+		case c != 0 && c < utf8.RuneSelf:
+			b = append(b, byte(c))
+		case c < 0x800:
+			b = append(b, 0xC0|byte(c>>6), 0x80|byte(c&0x3F))
+		case c < 0x10000:
+			b = appendModifiedUTF8Char3(b, c)
+		default:
+			high, low := utf16.EncodeRune(c)
+			b = appendModifiedUTF8Char3(appendModifiedUTF8Char3(b, high), low)
+		}
+	}
+	return b
+}
+
+func appendModifiedUTF8Char3(b []byte, c rune) []byte {
+	return append(b, 0xE0|byte(c>>12), 0x80|byte(c>>6&0x3F), 0x80|byte(c&0x3F))
+}
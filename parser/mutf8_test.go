@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeModifiedUTF8(t *testing.T) {
+	tests := []struct {
+		name    string
+		bytes   []byte
+		want    string
+		wantErr bool
+	}{
+		{"ascii", []byte("java/lang/Object"), "java/lang/Object", false},
+		{"NUL", []byte{'a', 0xC0, 0x80, 'b'}, "a\x00b", false},
+		{"two bytes", []byte{0xC3, 0xA9}, "é", false},
+		{"three bytes", []byte{0xE2, 0x82, 0xAC}, "€", false},
+		{"surrogate pair", []byte{0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}, "😀", false},
+		{"unpaired high surrogate", []byte{0xED, 0xA0, 0xBD, 'x'}, "�x", false},
+		{"unpaired low surrogate", []byte{'x', 0xED, 0xB8, 0x80}, "x�", false},
+		{"high surrogate at end", []byte{0xED, 0xA0, 0xBD}, "�", false},
+		{"raw NUL", []byte{'a', 0x00}, "a�", true},
+		{"truncated", []byte{'a', 0xE2, 0x82}, "a��", true},
+		{"four byte UTF-8", []byte{0xF0, 0x9F, 0x98, 0x80}, "����", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeModifiedUTF8(tt.bytes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeModifiedUTF8() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeModifiedUTF8() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeModifiedUTF8(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []byte
+	}{
+		{"Object", []byte("Object")},
+		{"a\x00b", []byte{'a', 0xC0, 0x80, 'b'}},
+		{"é€", []byte{0xC3, 0xA9, 0xE2, 0x82, 0xAC}},
+		{"😀", []byte{0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}},
+		{"\xff", []byte{0xEF, 0xBF, 0xBD}},
+	}
+	for _, tt := range tests {
+		got := EncodeModifiedUTF8(tt.s)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("EncodeModifiedUTF8(%q) = % X, want % X", tt.s, got, tt.want)
+		}
+		if s, err := DecodeModifiedUTF8(got); err != nil || (s != tt.s && tt.s != "\xff") {
+			t.Errorf("DecodeModifiedUTF8(EncodeModifiedUTF8(%q)) = %q, %v", tt.s, s, err)
+		}
+	}
+}
+
+func TestUtf8Constant_RoundTrip(t *testing.T) {
+	b := NewClassBuilder("Foo")
+	index := b.Pool().Utf8("x\x00😀")
+	c, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An unpaired surrogate cannot be built from a Go string, but is kept as read.
+	unpaired := []byte{0xED, 0xA0, 0xBD}
+	c.ConstantPool = append(c.ConstantPool, ConstantUtf8Info{unpaired})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := read.ConstantPool.GetUTF8(index); s != "x\x00😀" {
+		t.Errorf("GetUTF8() = %q", s)
+	}
+	last := read.ConstantPool[len(read.ConstantPool)-1].(ConstantUtf8Info)
+	if !bytes.Equal(last.Bytes, unpaired) {
+		t.Errorf("Bytes = % X, want % X", last.Bytes, unpaired)
+	}
+	if s := read.ConstantPool.GetUTF8(uint16(len(read.ConstantPool))); s != "�" {
+		t.Errorf("GetUTF8() of an unpaired surrogate = %q", s)
+	}
+}
+
+func TestReader_ReadModifiedUTF8(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte{0xC0, 0x80, 0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80, 'z'}))
+	s, err := r.ReadModifiedUTF8(8)
+	if err != nil || s != "\x00😀" {
+		t.Errorf("ReadModifiedUTF8() = %q, %v", s, err)
+	}
+	if r.Offset() != 8 {
+		t.Errorf("Offset() = %d, want 8", r.Offset())
+	}
+}
@@ -0,0 +1,476 @@
+package parser
+
+import "fmt"
+
+type (
+	Opcode uint8
+
+	// OperandKind tells how the operands of an opcode are encoded.
+	OperandKind int
+
+	opcodeInfo struct {
+		name    string
+		operand OperandKind
+	}
+)
+
+const (
+	OperandNone OperandKind = iota
+	OperandByte
+	OperandShort
+	OperandLocal
+	OperandConstant8
+	OperandConstant16
+	OperandBranch16
+	OperandBranch32
+	OperandIinc
+	OperandInvokeInterface
+	OperandInvokeDynamic
+	OperandMultiANewArray
+	OperandNewArray
+	OperandTableSwitch
+	OperandLookupSwitch
+	OperandWide
+)
+
+const (
+	OpcodeNop             Opcode = 0x00
+	OpcodeAconstNull      Opcode = 0x01
+	OpcodeIconstM1        Opcode = 0x02
+	OpcodeIconst0         Opcode = 0x03
+	OpcodeIconst1         Opcode = 0x04
+	OpcodeIconst2         Opcode = 0x05
+	OpcodeIconst3         Opcode = 0x06
+	OpcodeIconst4         Opcode = 0x07
+	OpcodeIconst5         Opcode = 0x08
+	OpcodeLconst0         Opcode = 0x09
+	OpcodeLconst1         Opcode = 0x0A
+	OpcodeFconst0         Opcode = 0x0B
+	OpcodeFconst1         Opcode = 0x0C
+	OpcodeFconst2         Opcode = 0x0D
+	OpcodeDconst0         Opcode = 0x0E
+	OpcodeDconst1         Opcode = 0x0F
+	OpcodeBipush          Opcode = 0x10
+	OpcodeSipush          Opcode = 0x11
+	OpcodeLdc             Opcode = 0x12
+	OpcodeLdcW            Opcode = 0x13
+	OpcodeLdc2W           Opcode = 0x14
+	OpcodeIload           Opcode = 0x15
+	OpcodeLload           Opcode = 0x16
+	OpcodeFload           Opcode = 0x17
+	OpcodeDload           Opcode = 0x18
+	OpcodeAload           Opcode = 0x19
+	OpcodeIload0          Opcode = 0x1A
+	OpcodeIload1          Opcode = 0x1B
+	OpcodeIload2          Opcode = 0x1C
+	OpcodeIload3          Opcode = 0x1D
+	OpcodeLload0          Opcode = 0x1E
+	OpcodeLload1          Opcode = 0x1F
+	OpcodeLload2          Opcode = 0x20
+	OpcodeLload3          Opcode = 0x21
+	OpcodeFload0          Opcode = 0x22
+	OpcodeFload1          Opcode = 0x23
+	OpcodeFload2          Opcode = 0x24
+	OpcodeFload3          Opcode = 0x25
+	OpcodeDload0          Opcode = 0x26
+	OpcodeDload1          Opcode = 0x27
+	OpcodeDload2          Opcode = 0x28
+	OpcodeDload3          Opcode = 0x29
+	OpcodeAload0          Opcode = 0x2A
+	OpcodeAload1          Opcode = 0x2B
+	OpcodeAload2          Opcode = 0x2C
+	OpcodeAload3          Opcode = 0x2D
+	OpcodeIaload          Opcode = 0x2E
+	OpcodeLaload          Opcode = 0x2F
+	OpcodeFaload          Opcode = 0x30
+	OpcodeDaload          Opcode = 0x31
+	OpcodeAaload          Opcode = 0x32
+	OpcodeBaload          Opcode = 0x33
+	OpcodeCaload          Opcode = 0x34
+	OpcodeSaload          Opcode = 0x35
+	OpcodeIstore          Opcode = 0x36
+	OpcodeLstore          Opcode = 0x37
+	OpcodeFstore          Opcode = 0x38
+	OpcodeDstore          Opcode = 0x39
+	OpcodeAstore          Opcode = 0x3A
+	OpcodeIstore0         Opcode = 0x3B
+	OpcodeIstore1         Opcode = 0x3C
+	OpcodeIstore2         Opcode = 0x3D
+	OpcodeIstore3         Opcode = 0x3E
+	OpcodeLstore0         Opcode = 0x3F
+	OpcodeLstore1         Opcode = 0x40
+	OpcodeLstore2         Opcode = 0x41
+	OpcodeLstore3         Opcode = 0x42
+	OpcodeFstore0         Opcode = 0x43
+	OpcodeFstore1         Opcode = 0x44
+	OpcodeFstore2         Opcode = 0x45
+	OpcodeFstore3         Opcode = 0x46
+	OpcodeDstore0         Opcode = 0x47
+	OpcodeDstore1         Opcode = 0x48
+	OpcodeDstore2         Opcode = 0x49
+	OpcodeDstore3         Opcode = 0x4A
+	OpcodeAstore0         Opcode = 0x4B
+	OpcodeAstore1         Opcode = 0x4C
+	OpcodeAstore2         Opcode = 0x4D
+	OpcodeAstore3         Opcode = 0x4E
+	OpcodeIastore         Opcode = 0x4F
+	OpcodeLastore         Opcode = 0x50
+	OpcodeFastore         Opcode = 0x51
+	OpcodeDastore         Opcode = 0x52
+	OpcodeAastore         Opcode = 0x53
+	OpcodeBastore         Opcode = 0x54
+	OpcodeCastore         Opcode = 0x55
+	OpcodeSastore         Opcode = 0x56
+	OpcodePop             Opcode = 0x57
+	OpcodePop2            Opcode = 0x58
+	OpcodeDup             Opcode = 0x59
+	OpcodeDupX1           Opcode = 0x5A
+	OpcodeDupX2           Opcode = 0x5B
+	OpcodeDup2            Opcode = 0x5C
+	OpcodeDup2X1          Opcode = 0x5D
+	OpcodeDup2X2          Opcode = 0x5E
+	OpcodeSwap            Opcode = 0x5F
+	OpcodeIadd            Opcode = 0x60
+	OpcodeLadd            Opcode = 0x61
+	OpcodeFadd            Opcode = 0x62
+	OpcodeDadd            Opcode = 0x63
+	OpcodeIsub            Opcode = 0x64
+	OpcodeLsub            Opcode = 0x65
+	OpcodeFsub            Opcode = 0x66
+	OpcodeDsub            Opcode = 0x67
+	OpcodeImul            Opcode = 0x68
+	OpcodeLmul            Opcode = 0x69
+	OpcodeFmul            Opcode = 0x6A
+	OpcodeDmul            Opcode = 0x6B
+	OpcodeIdiv            Opcode = 0x6C
+	OpcodeLdiv            Opcode = 0x6D
+	OpcodeFdiv            Opcode = 0x6E
+	OpcodeDdiv            Opcode = 0x6F
+	OpcodeIrem            Opcode = 0x70
+	OpcodeLrem            Opcode = 0x71
+	OpcodeFrem            Opcode = 0x72
+	OpcodeDrem            Opcode = 0x73
+	OpcodeIneg            Opcode = 0x74
+	OpcodeLneg            Opcode = 0x75
+	OpcodeFneg            Opcode = 0x76
+	OpcodeDneg            Opcode = 0x77
+	OpcodeIshl            Opcode = 0x78
+	OpcodeLshl            Opcode = 0x79
+	OpcodeIshr            Opcode = 0x7A
+	OpcodeLshr            Opcode = 0x7B
+	OpcodeIushr           Opcode = 0x7C
+	OpcodeLushr           Opcode = 0x7D
+	OpcodeIand            Opcode = 0x7E
+	OpcodeLand            Opcode = 0x7F
+	OpcodeIor             Opcode = 0x80
+	OpcodeLor             Opcode = 0x81
+	OpcodeIxor            Opcode = 0x82
+	OpcodeLxor            Opcode = 0x83
+	OpcodeIinc            Opcode = 0x84
+	OpcodeI2l             Opcode = 0x85
+	OpcodeI2f             Opcode = 0x86
+	OpcodeI2d             Opcode = 0x87
+	OpcodeL2i             Opcode = 0x88
+	OpcodeL2f             Opcode = 0x89
+	OpcodeL2d             Opcode = 0x8A
+	OpcodeF2i             Opcode = 0x8B
+	OpcodeF2l             Opcode = 0x8C
+	OpcodeF2d             Opcode = 0x8D
+	OpcodeD2i             Opcode = 0x8E
+	OpcodeD2l             Opcode = 0x8F
+	OpcodeD2f             Opcode = 0x90
+	OpcodeI2b             Opcode = 0x91
+	OpcodeI2c             Opcode = 0x92
+	OpcodeI2s             Opcode = 0x93
+	OpcodeLcmp            Opcode = 0x94
+	OpcodeFcmpl           Opcode = 0x95
+	OpcodeFcmpg           Opcode = 0x96
+	OpcodeDcmpl           Opcode = 0x97
+	OpcodeDcmpg           Opcode = 0x98
+	OpcodeIfeq            Opcode = 0x99
+	OpcodeIfne            Opcode = 0x9A
+	OpcodeIflt            Opcode = 0x9B
+	OpcodeIfge            Opcode = 0x9C
+	OpcodeIfgt            Opcode = 0x9D
+	OpcodeIfle            Opcode = 0x9E
+	OpcodeIfIcmpeq        Opcode = 0x9F
+	OpcodeIfIcmpne        Opcode = 0xA0
+	OpcodeIfIcmplt        Opcode = 0xA1
+	OpcodeIfIcmpge        Opcode = 0xA2
+	OpcodeIfIcmpgt        Opcode = 0xA3
+	OpcodeIfIcmple        Opcode = 0xA4
+	OpcodeIfAcmpeq        Opcode = 0xA5
+	OpcodeIfAcmpne        Opcode = 0xA6
+	OpcodeGoto            Opcode = 0xA7
+	OpcodeJsr             Opcode = 0xA8
+	OpcodeRet             Opcode = 0xA9
+	OpcodeTableswitch     Opcode = 0xAA
+	OpcodeLookupswitch    Opcode = 0xAB
+	OpcodeIreturn         Opcode = 0xAC
+	OpcodeLreturn         Opcode = 0xAD
+	OpcodeFreturn         Opcode = 0xAE
+	OpcodeDreturn         Opcode = 0xAF
+	OpcodeAreturn         Opcode = 0xB0
+	OpcodeReturn          Opcode = 0xB1
+	OpcodeGetstatic       Opcode = 0xB2
+	OpcodePutstatic       Opcode = 0xB3
+	OpcodeGetfield        Opcode = 0xB4
+	OpcodePutfield        Opcode = 0xB5
+	OpcodeInvokevirtual   Opcode = 0xB6
+	OpcodeInvokespecial   Opcode = 0xB7
+	OpcodeInvokestatic    Opcode = 0xB8
+	OpcodeInvokeinterface Opcode = 0xB9
+	OpcodeInvokedynamic   Opcode = 0xBA
+	OpcodeNew             Opcode = 0xBB
+	OpcodeNewarray        Opcode = 0xBC
+	OpcodeAnewarray       Opcode = 0xBD
+	OpcodeArraylength     Opcode = 0xBE
+	OpcodeAthrow          Opcode = 0xBF
+	OpcodeCheckcast       Opcode = 0xC0
+	OpcodeInstanceof      Opcode = 0xC1
+	OpcodeMonitorenter    Opcode = 0xC2
+	OpcodeMonitorexit     Opcode = 0xC3
+	OpcodeWide            Opcode = 0xC4
+	OpcodeMultianewarray  Opcode = 0xC5
+	OpcodeIfnull          Opcode = 0xC6
+	OpcodeIfnonnull       Opcode = 0xC7
+	OpcodeGotoW           Opcode = 0xC8
+	OpcodeJsrW            Opcode = 0xC9
+	OpcodeBreakpoint      Opcode = 0xCA
+	OpcodeImpdep1         Opcode = 0xFE
+	OpcodeImpdep2         Opcode = 0xFF
+)
+
+var opcodeInfos = [256]opcodeInfo{
+	OpcodeNop:             {"nop", OperandNone},
+	OpcodeAconstNull:      {"aconst_null", OperandNone},
+	OpcodeIconstM1:        {"iconst_m1", OperandNone},
+	OpcodeIconst0:         {"iconst_0", OperandNone},
+	OpcodeIconst1:         {"iconst_1", OperandNone},
+	OpcodeIconst2:         {"iconst_2", OperandNone},
+	OpcodeIconst3:         {"iconst_3", OperandNone},
+	OpcodeIconst4:         {"iconst_4", OperandNone},
+	OpcodeIconst5:         {"iconst_5", OperandNone},
+	OpcodeLconst0:         {"lconst_0", OperandNone},
+	OpcodeLconst1:         {"lconst_1", OperandNone},
+	OpcodeFconst0:         {"fconst_0", OperandNone},
+	OpcodeFconst1:         {"fconst_1", OperandNone},
+	OpcodeFconst2:         {"fconst_2", OperandNone},
+	OpcodeDconst0:         {"dconst_0", OperandNone},
+	OpcodeDconst1:         {"dconst_1", OperandNone},
+	OpcodeBipush:          {"bipush", OperandByte},
+	OpcodeSipush:          {"sipush", OperandShort},
+	OpcodeLdc:             {"ldc", OperandConstant8},
+	OpcodeLdcW:            {"ldc_w", OperandConstant16},
+	OpcodeLdc2W:           {"ldc2_w", OperandConstant16},
+	OpcodeIload:           {"iload", OperandLocal},
+	OpcodeLload:           {"lload", OperandLocal},
+	OpcodeFload:           {"fload", OperandLocal},
+	OpcodeDload:           {"dload", OperandLocal},
+	OpcodeAload:           {"aload", OperandLocal},
+	OpcodeIload0:          {"iload_0", OperandNone},
+	OpcodeIload1:          {"iload_1", OperandNone},
+	OpcodeIload2:          {"iload_2", OperandNone},
+	OpcodeIload3:          {"iload_3", OperandNone},
+	OpcodeLload0:          {"lload_0", OperandNone},
+	OpcodeLload1:          {"lload_1", OperandNone},
+	OpcodeLload2:          {"lload_2", OperandNone},
+	OpcodeLload3:          {"lload_3", OperandNone},
+	OpcodeFload0:          {"fload_0", OperandNone},
+	OpcodeFload1:          {"fload_1", OperandNone},
+	OpcodeFload2:          {"fload_2", OperandNone},
+	OpcodeFload3:          {"fload_3", OperandNone},
+	OpcodeDload0:          {"dload_0", OperandNone},
+	OpcodeDload1:          {"dload_1", OperandNone},
+	OpcodeDload2:          {"dload_2", OperandNone},
+	OpcodeDload3:          {"dload_3", OperandNone},
+	OpcodeAload0:          {"aload_0", OperandNone},
+	OpcodeAload1:          {"aload_1", OperandNone},
+	OpcodeAload2:          {"aload_2", OperandNone},
+	OpcodeAload3:          {"aload_3", OperandNone},
+	OpcodeIaload:          {"iaload", OperandNone},
+	OpcodeLaload:          {"laload", OperandNone},
+	OpcodeFaload:          {"faload", OperandNone},
+	OpcodeDaload:          {"daload", OperandNone},
+	OpcodeAaload:          {"aaload", OperandNone},
+	OpcodeBaload:          {"baload", OperandNone},
+	OpcodeCaload:          {"caload", OperandNone},
+	OpcodeSaload:          {"saload", OperandNone},
+	OpcodeIstore:          {"istore", OperandLocal},
+	OpcodeLstore:          {"lstore", OperandLocal},
+	OpcodeFstore:          {"fstore", OperandLocal},
+	OpcodeDstore:          {"dstore", OperandLocal},
+	OpcodeAstore:          {"astore", OperandLocal},
+	OpcodeIstore0:         {"istore_0", OperandNone},
+	OpcodeIstore1:         {"istore_1", OperandNone},
+	OpcodeIstore2:         {"istore_2", OperandNone},
+	OpcodeIstore3:         {"istore_3", OperandNone},
+	OpcodeLstore0:         {"lstore_0", OperandNone},
+	OpcodeLstore1:         {"lstore_1", OperandNone},
+	OpcodeLstore2:         {"lstore_2", OperandNone},
+	OpcodeLstore3:         {"lstore_3", OperandNone},
+	OpcodeFstore0:         {"fstore_0", OperandNone},
+	OpcodeFstore1:         {"fstore_1", OperandNone},
+	OpcodeFstore2:         {"fstore_2", OperandNone},
+	OpcodeFstore3:         {"fstore_3", OperandNone},
+	OpcodeDstore0:         {"dstore_0", OperandNone},
+	OpcodeDstore1:         {"dstore_1", OperandNone},
+	OpcodeDstore2:         {"dstore_2", OperandNone},
+	OpcodeDstore3:         {"dstore_3", OperandNone},
+	OpcodeAstore0:         {"astore_0", OperandNone},
+	OpcodeAstore1:         {"astore_1", OperandNone},
+	OpcodeAstore2:         {"astore_2", OperandNone},
+	OpcodeAstore3:         {"astore_3", OperandNone},
+	OpcodeIastore:         {"iastore", OperandNone},
+	OpcodeLastore:         {"lastore", OperandNone},
+	OpcodeFastore:         {"fastore", OperandNone},
+	OpcodeDastore:         {"dastore", OperandNone},
+	OpcodeAastore:         {"aastore", OperandNone},
+	OpcodeBastore:         {"bastore", OperandNone},
+	OpcodeCastore:         {"castore", OperandNone},
+	OpcodeSastore:         {"sastore", OperandNone},
+	OpcodePop:             {"pop", OperandNone},
+	OpcodePop2:            {"pop2", OperandNone},
+	OpcodeDup:             {"dup", OperandNone},
+	OpcodeDupX1:           {"dup_x1", OperandNone},
+	OpcodeDupX2:           {"dup_x2", OperandNone},
+	OpcodeDup2:            {"dup2", OperandNone},
+	OpcodeDup2X1:          {"dup2_x1", OperandNone},
+	OpcodeDup2X2:          {"dup2_x2", OperandNone},
+	OpcodeSwap:            {"swap", OperandNone},
+	OpcodeIadd:            {"iadd", OperandNone},
+	OpcodeLadd:            {"ladd", OperandNone},
+	OpcodeFadd:            {"fadd", OperandNone},
+	OpcodeDadd:            {"dadd", OperandNone},
+	OpcodeIsub:            {"isub", OperandNone},
+	OpcodeLsub:            {"lsub", OperandNone},
+	OpcodeFsub:            {"fsub", OperandNone},
+	OpcodeDsub:            {"dsub", OperandNone},
+	OpcodeImul:            {"imul", OperandNone},
+	OpcodeLmul:            {"lmul", OperandNone},
+	OpcodeFmul:            {"fmul", OperandNone},
+	OpcodeDmul:            {"dmul", OperandNone},
+	OpcodeIdiv:            {"idiv", OperandNone},
+	OpcodeLdiv:            {"ldiv", OperandNone},
+	OpcodeFdiv:            {"fdiv", OperandNone},
+	OpcodeDdiv:            {"ddiv", OperandNone},
+	OpcodeIrem:            {"irem", OperandNone},
+	OpcodeLrem:            {"lrem", OperandNone},
+	OpcodeFrem:            {"frem", OperandNone},
+	OpcodeDrem:            {"drem", OperandNone},
+	OpcodeIneg:            {"ineg", OperandNone},
+	OpcodeLneg:            {"lneg", OperandNone},
+	OpcodeFneg:            {"fneg", OperandNone},
+	OpcodeDneg:            {"dneg", OperandNone},
+	OpcodeIshl:            {"ishl", OperandNone},
+	OpcodeLshl:            {"lshl", OperandNone},
+	OpcodeIshr:            {"ishr", OperandNone},
+	OpcodeLshr:            {"lshr", OperandNone},
+	OpcodeIushr:           {"iushr", OperandNone},
+	OpcodeLushr:           {"lushr", OperandNone},
+	OpcodeIand:            {"iand", OperandNone},
+	OpcodeLand:            {"land", OperandNone},
+	OpcodeIor:             {"ior", OperandNone},
+	OpcodeLor:             {"lor", OperandNone},
+	OpcodeIxor:            {"ixor", OperandNone},
+	OpcodeLxor:            {"lxor", OperandNone},
+	OpcodeIinc:            {"iinc", OperandIinc},
+	OpcodeI2l:             {"i2l", OperandNone},
+	OpcodeI2f:             {"i2f", OperandNone},
+	OpcodeI2d:             {"i2d", OperandNone},
+	OpcodeL2i:             {"l2i", OperandNone},
+	OpcodeL2f:             {"l2f", OperandNone},
+	OpcodeL2d:             {"l2d", OperandNone},
+	OpcodeF2i:             {"f2i", OperandNone},
+	OpcodeF2l:             {"f2l", OperandNone},
+	OpcodeF2d:             {"f2d", OperandNone},
+	OpcodeD2i:             {"d2i", OperandNone},
+	OpcodeD2l:             {"d2l", OperandNone},
+	OpcodeD2f:             {"d2f", OperandNone},
+	OpcodeI2b:             {"i2b", OperandNone},
+	OpcodeI2c:             {"i2c", OperandNone},
+	OpcodeI2s:             {"i2s", OperandNone},
+	OpcodeLcmp:            {"lcmp", OperandNone},
+	OpcodeFcmpl:           {"fcmpl", OperandNone},
+	OpcodeFcmpg:           {"fcmpg", OperandNone},
+	OpcodeDcmpl:           {"dcmpl", OperandNone},
+	OpcodeDcmpg:           {"dcmpg", OperandNone},
+	OpcodeIfeq:            {"ifeq", OperandBranch16},
+	OpcodeIfne:            {"ifne", OperandBranch16},
+	OpcodeIflt:            {"iflt", OperandBranch16},
+	OpcodeIfge:            {"ifge", OperandBranch16},
+	OpcodeIfgt:            {"ifgt", OperandBranch16},
+	OpcodeIfle:            {"ifle", OperandBranch16},
+	OpcodeIfIcmpeq:        {"if_icmpeq", OperandBranch16},
+	OpcodeIfIcmpne:        {"if_icmpne", OperandBranch16},
+	OpcodeIfIcmplt:        {"if_icmplt", OperandBranch16},
+	OpcodeIfIcmpge:        {"if_icmpge", OperandBranch16},
+	OpcodeIfIcmpgt:        {"if_icmpgt", OperandBranch16},
+	OpcodeIfIcmple:        {"if_icmple", OperandBranch16},
+	OpcodeIfAcmpeq:        {"if_acmpeq", OperandBranch16},
+	OpcodeIfAcmpne:        {"if_acmpne", OperandBranch16},
+	OpcodeGoto:            {"goto", OperandBranch16},
+	OpcodeJsr:             {"jsr", OperandBranch16},
+	OpcodeRet:             {"ret", OperandLocal},
+	OpcodeTableswitch:     {"tableswitch", OperandTableSwitch},
+	OpcodeLookupswitch:    {"lookupswitch", OperandLookupSwitch},
+	OpcodeIreturn:         {"ireturn", OperandNone},
+	OpcodeLreturn:         {"lreturn", OperandNone},
+	OpcodeFreturn:         {"freturn", OperandNone},
+	OpcodeDreturn:         {"dreturn", OperandNone},
+	OpcodeAreturn:         {"areturn", OperandNone},
+	OpcodeReturn:          {"return", OperandNone},
+	OpcodeGetstatic:       {"getstatic", OperandConstant16},
+	OpcodePutstatic:       {"putstatic", OperandConstant16},
+	OpcodeGetfield:        {"getfield", OperandConstant16},
+	OpcodePutfield:        {"putfield", OperandConstant16},
+	OpcodeInvokevirtual:   {"invokevirtual", OperandConstant16},
+	OpcodeInvokespecial:   {"invokespecial", OperandConstant16},
+	OpcodeInvokestatic:    {"invokestatic", OperandConstant16},
+	OpcodeInvokeinterface: {"invokeinterface", OperandInvokeInterface},
+	OpcodeInvokedynamic:   {"invokedynamic", OperandInvokeDynamic},
+	OpcodeNew:             {"new", OperandConstant16},
+	OpcodeNewarray:        {"newarray", OperandNewArray},
+	OpcodeAnewarray:       {"anewarray", OperandConstant16},
+	OpcodeArraylength:     {"arraylength", OperandNone},
+	OpcodeAthrow:          {"athrow", OperandNone},
+	OpcodeCheckcast:       {"checkcast", OperandConstant16},
+	OpcodeInstanceof:      {"instanceof", OperandConstant16},
+	OpcodeMonitorenter:    {"monitorenter", OperandNone},
+	OpcodeMonitorexit:     {"monitorexit", OperandNone},
+	OpcodeWide:            {"wide", OperandWide},
+	OpcodeMultianewarray:  {"multianewarray", OperandMultiANewArray},
+	OpcodeIfnull:          {"ifnull", OperandBranch16},
+	OpcodeIfnonnull:       {"ifnonnull", OperandBranch16},
+	OpcodeGotoW:           {"goto_w", OperandBranch32},
+	OpcodeJsrW:            {"jsr_w", OperandBranch32},
+	OpcodeBreakpoint:      {"breakpoint", OperandNone},
+	OpcodeImpdep1:         {"impdep1", OperandNone},
+	OpcodeImpdep2:         {"impdep2", OperandNone},
+}
+
+func (o Opcode) String() string {
+	if name := opcodeInfos[o].name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("unknown(0x%02X)", uint8(o))
+}
+
+func (o Opcode) Valid() bool {
+	return opcodeInfos[o].name != ""
+}
+
+// OperandKind returns how the operands of the opcode are encoded.
+func (o Opcode) OperandKind() OperandKind {
+	return opcodeInfos[o].operand
+}
+
+// OpcodeByName looks up an opcode by its mnemonic like invokevirtual.
+func OpcodeByName(name string) (Opcode, bool) {
+	for i, info := range opcodeInfos {
+		if info.name == name && name != "" {
+			return Opcode(i), true
+		}
+	}
+	return 0, false
+}
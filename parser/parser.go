@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"math"
@@ -17,152 +18,544 @@ type (
 		Interfaces   []uint16
 		Fields       []FieldInfo
 		Methods      []MethodInfo
-		Attributes   []AttributeInfo
+		Attributes   Attributes
 	}
 )
 
+// ParseOptions configures ReadWithOptions.
+type ParseOptions struct {
+	// Strict rejects class files using constants not allowed in their version,
+	// like a CONSTANT_Dynamic in a Java 8 class file or a CONSTANT_Module outside module-info,
+	// and class files with malformed attributes. Otherwise these constants are read as usual,
+	// the malformed attributes are kept as *AttributeInfo, and both are reported to Warn.
+	Strict bool
+	// Warn is called with the problems tolerated when Strict is not set.
+	Warn func(err *ParseError)
+	// SkipMembers skips the fields and the methods, leaving Fields and Methods nil.
+	SkipMembers bool
+	// SkipAttributes skips the attributes of the class, the fields and the methods,
+	// leaving their Attributes nil. With SkipMembers, reading stops after the interfaces.
+	SkipAttributes bool
+	// SkipCode skips the Code attributes of the methods.
+	// The skipped structures are not allocated, and Write does not write them back.
+	SkipCode bool
+	// Limits bounds the resources used by untrusted class files.
+	Limits Limits
+}
+
+// Limits bounds the sizes in a class file, which is rejected with a *LimitError
+// before allocating anything for a structure exceeding them. Zero is no limit.
+// Element values in annotations are nested up to MaxElementValueDepth regardless of them.
+type Limits struct {
+	// MaxAttributeSize is the largest length of an attribute, including those skipped.
+	MaxAttributeSize int64
+	// MaxConstantPoolEntries is the largest number of constant pool entries, counting Long and Double twice.
+	MaxConstantPoolEntries int
+	// MaxTotalBytes is the largest size of the class file.
+	MaxTotalBytes int64
+}
+
+// Read reads a class file, tolerating constants not allowed in its version.
 func Read(reader io.Reader) (*ClassFile, error) {
-	r := NewReader(reader)
-	magic, err := r.Read32()
+	return ReadWithOptions(reader, ParseOptions{})
+}
+
+// ReadHeader reads a class file up to the interfaces, which is all of the class hierarchy.
+// Fields, Methods and Attributes are nil, and the rest of the class file is not read.
+func ReadHeader(reader io.Reader) (*ClassFile, error) {
+	return ReadWithOptions(reader, ParseOptions{SkipMembers: true, SkipAttributes: true})
+}
+
+// ReadWithOptions reads a class file with options, like Read and ReadHeader do.
+func ReadWithOptions(reader io.Reader, opts ParseOptions) (*ClassFile, error) {
+	return read(NewReader(reader), opts)
+}
+
+// ReadBytes reads the class file data without copying it. The Utf8 constants and the bytes
+// of the attributes share the memory of data, which must not be modified while they are used.
+func ReadBytes(data []byte) (*ClassFile, error) {
+	return ReadBytesWithOptions(data, ParseOptions{})
+}
+
+// ReadBytesWithOptions is ReadBytes with options.
+func ReadBytesWithOptions(data []byte, opts ParseOptions) (*ClassFile, error) {
+	if data == nil {
+		// A nil data would read from a nil bufio.Reader.
+		data = []byte{}
+	}
+	return read(NewBytesReader(data), opts)
+}
+
+// ReadReaderAt reads the class file of size bytes in r, like a memory-mapped file.
+// It is read into a single buffer, which is shared like the data of ReadBytes.
+// Errors, including those of r, are returned as a *ParseError like Read does.
+func ReadReaderAt(r io.ReaderAt, size int64, opts ParseOptions) (*ClassFile, error) {
+	if size < 0 || size > math.MaxInt32 {
+		return nil, &ParseError{0, "magic", fmt.Errorf("invalid class file size %d", size)}
+	}
+	if max := opts.Limits.MaxTotalBytes; max > 0 && size > max {
+		return nil, &ParseError{0, "magic", &LimitError{LimitTotalBytes, size, max}}
+	}
+	data := make([]byte, size)
+	if n, err := r.ReadAt(data, 0); n < len(data) {
+		// The bytes read are parsed up to the error, which fails like Read at its offset,
+		// or after them if they hold a whole class file.
+		if _, parseErr := ReadWithOptions(io.MultiReader(bytes.NewReader(data[:n]), errorReader{err}), opts); parseErr != nil {
+			return nil, parseErr
+		}
+		return nil, &ParseError{int64(n), "end of class file", err}
+	}
+	return ReadBytesWithOptions(data, opts)
+}
+
+// errorReader fails every read with err.
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func read(r *Reader, opts ParseOptions) (*ClassFile, error) {
+	p := newClassParser(r, opts)
+	c := p.classFile()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return c, nil
+}
+
+func newClassParser(r *Reader, opts ParseOptions) *classParser {
+	r.max = opts.Limits.MaxTotalBytes
+	return &classParser{r: r, opts: opts}
+}
+
+// classParser reads a class file. The first error is kept as a *ParseError
+// with the structure being parsed, and later reads return zero values.
+type classParser struct {
+	r    *Reader
+	opts ParseOptions
+	path string
+	err  error
+	// moduleConstants are the Module and Package constants,
+	// checked once the access flags tell whether the class file is a module.
+	moduleConstants []moduleConstant
+	// codeOffset and codePath locate the Code attribute last read,
+	// whose instructions are decoded by AcceptWithOptions.
+	codeOffset int64
+	codePath   string
+}
+
+type moduleConstant struct {
+	index  uint16
+	offset int64
+	tag    uint8
+}
+
+func (p *classParser) fail(offset int64, err error) {
+	if p.err != nil {
+		return
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	p.err = &ParseError{offset, p.path, err}
+}
+
+// invalid reports a problem tolerated unless Strict, like a structure which the class file
+// version does not allow.
+func (p *classParser) invalid(offset int64, err error) {
+	if p.err != nil {
+		return
+	}
+	if p.opts.Strict {
+		p.fail(offset, err)
+	} else if p.opts.Warn != nil {
+		p.opts.Warn(&ParseError{offset, p.path, err})
+	}
+}
+
+func (p *classParser) u1() uint8 {
+	if p.err != nil {
+		return 0
+	}
+	offset := p.r.Offset()
+	v, err := p.r.Read8()
+	if err != nil {
+		p.fail(offset, err)
+	}
+	return v
+}
+
+func (p *classParser) u2() uint16 {
+	if p.err != nil {
+		return 0
+	}
+	offset := p.r.Offset()
+	v, err := p.r.Read16()
 	if err != nil {
-		return nil, err
+		p.fail(offset, err)
+	}
+	return v
+}
+
+func (p *classParser) u4() uint32 {
+	if p.err != nil {
+		return 0
+	}
+	offset := p.r.Offset()
+	v, err := p.r.Read32()
+	if err != nil {
+		p.fail(offset, err)
+	}
+	return v
+}
+
+func (p *classParser) u8() uint64 {
+	if p.err != nil {
+		return 0
+	}
+	offset := p.r.Offset()
+	v, err := p.r.Read64()
+	if err != nil {
+		p.fail(offset, err)
+	}
+	return v
+}
+
+// bytesChunkSize bounds the memory allocated ahead of the data actually read,
+// so a corrupted length cannot allocate gigabytes.
+const bytesChunkSize = 64 * 1024
+
+func (p *classParser) bytes(n int) []byte {
+	if p.err != nil {
+		return nil
+	}
+	offset := p.r.Offset()
+	if err := p.r.check(n); err != nil {
+		p.fail(offset, err)
+		return nil
+	}
+	if p.r.data != nil {
+		b, err := p.r.slice(n)
+		if err != nil {
+			p.fail(offset, err)
+		}
+		return b
 	}
-	if magic != 0xCAFEBABE {
-		return nil, fmt.Errorf("unexpected magic: %08X", magic)
+	size := n
+	if size > bytesChunkSize {
+		size = bytesChunkSize
+	}
+	b := make([]byte, 0, size)
+	for len(b) < n {
+		chunk := n - len(b)
+		if chunk > bytesChunkSize {
+			chunk = bytesChunkSize
+		}
+		b = append(b, make([]byte, chunk)...)
+		if _, err := p.r.ReadBytes(b[len(b)-chunk:]); err != nil {
+			p.fail(offset, err)
+			return nil
+		}
+	}
+	return b
+}
+
+// skip reads n bytes without keeping them.
+func (p *classParser) skip(n int) {
+	if p.err != nil {
+		return
+	}
+	offset := p.r.Offset()
+	if err := p.r.Skip(n); err != nil {
+		p.fail(offset, err)
+	}
+}
+
+func (p *classParser) classFile() *ClassFile {
+	c := p.header()
+	if p.opts.SkipMembers && p.opts.SkipAttributes {
+		return c
+	}
+	p.members(c.ConstantPool, func(f FieldInfo) error {
+		c.Fields = append(c.Fields, f)
+		return nil
+	}, func(m MethodInfo) error {
+		c.Methods = append(c.Methods, m)
+		return nil
+	})
+	c.Attributes = p.attributes("", c.ConstantPool, false)
+	return c
+}
+
+// header reads the class file up to the interfaces.
+func (p *classParser) header() *ClassFile {
+	p.path = "magic"
+	if magic := p.u4(); p.err == nil && magic != 0xCAFEBABE {
+		p.fail(0, fmt.Errorf("unexpected magic: %08X", magic))
 	}
 	c := new(ClassFile)
-	c.MinorVersion, _ = r.Read16()
-	c.MajorVersion, _ = r.Read16()
-	constantPoolCount, _ := r.Read16()
-	for i := uint16(0); i < constantPoolCount-1; i++ {
-		constantType, _ := r.Read8()
+	p.path = "version"
+	c.MinorVersion = p.u2()
+	c.MajorVersion = p.u2()
+	c.ConstantPool = p.constantPool(c.MajorVersion)
+	p.path = "access_flags"
+	c.AccessFlags = AccessFlags(p.u2())
+	if p.err == nil && !c.AccessFlags.Module() {
+		for _, m := range p.moduleConstants {
+			p.path = fmt.Sprintf("constant_pool[%d]", m.index)
+			p.invalid(m.offset, fmt.Errorf("%s constant outside of a module", constantTagNames[m.tag]))
+		}
+	}
+	p.path = "this_class"
+	c.ThisClass = p.u2()
+	p.path = "super_class"
+	c.SuperClass = p.u2()
+	p.path = "interfaces"
+	interfacesCount := p.u2()
+	for i := uint16(0); i < interfacesCount && p.err == nil; i++ {
+		c.Interfaces = append(c.Interfaces, p.u2())
+	}
+	return c
+}
+
+// members reads the fields and the methods, passing each to field or method.
+// It stops at the first error of field or method and returns it.
+func (p *classParser) members(pool ConstantPool, field func(FieldInfo) error, method func(MethodInfo) error) error {
+	p.path = "fields_count"
+	fieldsCount := p.u2()
+	for i := uint16(0); i < fieldsCount && p.err == nil; i++ {
+		path := fmt.Sprintf("field[%d]", i)
+		p.path = path
+		if p.opts.SkipMembers {
+			p.skipMember(path)
+			continue
+		}
+		flags := p.u2()
+		nameIndex := p.u2()
+		descriptorIndex := p.u2()
+		attributes := p.attributes(path+".", pool, false)
+		if p.err != nil {
+			break
+		}
+		if err := field(FieldInfo{FieldAccessFlags(flags), nameIndex, descriptorIndex, attributes}); err != nil {
+			return err
+		}
+	}
+
+	p.path = "methods_count"
+	methodsCount := p.u2()
+	for i := uint16(0); i < methodsCount && p.err == nil; i++ {
+		path := fmt.Sprintf("method[%d]", i)
+		p.path = path
+		if p.opts.SkipMembers {
+			p.skipMember(path)
+			continue
+		}
+		flags := p.u2()
+		nameIndex := p.u2()
+		descriptorIndex := p.u2()
+		attributes := p.attributes(path+".", pool, p.opts.SkipCode)
+		if p.err != nil {
+			break
+		}
+		if err := method(MethodInfo{MethodAccessFlags(flags), nameIndex, descriptorIndex, attributes}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipMember skips a field or a method using the lengths of its attributes.
+func (p *classParser) skipMember(path string) {
+	p.skip(6)
+	p.path = path + ".attributes_count"
+	attributesCount := p.u2()
+	for i := uint16(0); i < attributesCount && p.err == nil; i++ {
+		p.path = fmt.Sprintf("%s.attribute[%d]", path, i)
+		p.skip(2)
+		p.skip(p.attributeLength())
+	}
+}
+
+// constantTagNames are the names of the constant tags used in error messages.
+var constantTagNames = map[uint8]string{
+	ConstantUtf8:               "Utf8",
+	ConstantInteger:            "Integer",
+	ConstantFloat:              "Float",
+	ConstantLong:               "Long",
+	ConstantDouble:             "Double",
+	ConstantClass:              "Class",
+	ConstantString:             "String",
+	ConstantFieldref:           "Fieldref",
+	ConstantMethodref:          "Methodref",
+	ConstantInterfaceMethodref: "InterfaceMethodref",
+	ConstantNameAndType:        "NameAndType",
+	ConstantMethodHandle:       "MethodHandle",
+	ConstantMethodType:         "MethodType",
+	ConstantDynamic:            "Dynamic",
+	ConstantInvokeDynamic:      "InvokeDynamic",
+	ConstantModule:             "Module",
+	ConstantPackage:            "Package",
+}
+
+// constantVersions are the first class file major versions allowing the constant tags added after Java 1.0.
+var constantVersions = map[uint8]uint16{
+	ConstantMethodHandle:  51,
+	ConstantMethodType:    51,
+	ConstantInvokeDynamic: 51,
+	ConstantModule:        53,
+	ConstantPackage:       53,
+	ConstantDynamic:       55,
+}
+
+func (p *classParser) constantPool(majorVersion uint16) ConstantPool {
+	p.path = "constant_pool_count"
+	offset := p.r.Offset()
+	constantPoolCount := p.u2()
+	if p.err == nil && constantPoolCount == 0 {
+		p.fail(offset, fmt.Errorf("invalid constant pool count 0"))
+	}
+	if max := p.opts.Limits.MaxConstantPoolEntries; max > 0 && int(constantPoolCount)-1 > max {
+		p.fail(offset, &LimitError{LimitConstantPoolEntries, int64(constantPoolCount) - 1, int64(max)})
+	}
+	var pool ConstantPool
+	for i := uint16(1); i < constantPoolCount && p.err == nil; i++ {
+		p.path = fmt.Sprintf("constant_pool[%d]", i)
+		offset := p.r.Offset()
+		constantType := p.u1()
 		var info ConstantInfo
 		double := false
 		switch constantType {
 		case ConstantMethodref:
-			classIndex, _ := r.Read16()
-			nameAndTypeIndex, _ := r.Read16()
-			info = ConstantMethodrefInfo{classIndex, nameAndTypeIndex}
+			info = ConstantMethodrefInfo{p.u2(), p.u2()}
 		case ConstantInterfaceMethodref:
-			classIndex, _ := r.Read16()
-			nameAndTypeIndex, _ := r.Read16()
-			info = ConstantInterfaceMethodrefInfo{classIndex, nameAndTypeIndex}
+			info = ConstantInterfaceMethodrefInfo{p.u2(), p.u2()}
 		case ConstantClass:
-			nameIndex, _ := r.Read16()
-			info = ConstantClassInfo{nameIndex}
+			info = ConstantClassInfo{p.u2()}
 		case ConstantUtf8:
-			length, _ := r.Read16()
-			bytes := make([]byte, length)
-			r.ReadBytes(bytes)
-			info = ConstantUtf8Info{bytes}
+			length := p.u2()
+			info = ConstantUtf8Info{p.bytes(int(length))}
 		case ConstantNameAndType:
-			nameIndex, _ := r.Read16()
-			descriptorIndex, _ := r.Read16()
-			info = ConstantNameAndTypeInfo{nameIndex, descriptorIndex}
+			info = ConstantNameAndTypeInfo{p.u2(), p.u2()}
 		case ConstantFieldref:
-			classIndex, _ := r.Read16()
-			nameAndTypeIndex, _ := r.Read16()
-			info = ConstantFieldrefInfo{classIndex, nameAndTypeIndex}
+			info = ConstantFieldrefInfo{p.u2(), p.u2()}
 		case ConstantString:
-			stringIndex, _ := r.Read16()
-			info = ConstantStringInfo{stringIndex}
+			info = ConstantStringInfo{p.u2()}
 		case ConstantInteger:
-			value, _ := r.Read32()
-			info = ConstantIntegerInfo{int32(value)}
+			info = ConstantIntegerInfo{int32(p.u4())}
 		case ConstantLong:
-			value, _ := r.Read64()
-			info = ConstantLongInfo{int64(value)}
+			info = ConstantLongInfo{int64(p.u8())}
 			double = true
 		case ConstantFloat:
-			bitsuint, _ := r.Read32()
-			bits := int32(bitsuint)
-			var s int32
-			if (bits >> 31) == 0 {
-				s = 1
-			} else {
-				s = -1
-			}
-			e := (bits >> 23) & 0x7ff
-			var m int32
-			if e == 0 {
-				m = (bits & 0x7fffff) << 1
-			} else {
-				m = (bits & 0x7fffff) | 0x800000
-			}
-			d := float64(s) * float64(m) * math.Pow(math.E, -1075.)
-			info = ConstantFloatInfo{float32(d)}
+			info = ConstantFloatInfo{math.Float32frombits(p.u4())}
 		case ConstantDouble:
+			info = ConstantDoubleInfo{math.Float64frombits(p.u8())}
 			double = true
-			bitsuint, _ := r.Read64()
-			bits := int64(bitsuint)
-			var s int64
-			if (bits >> 63) == 0 {
-				s = 1
-			} else {
-				s = -1
-			}
-			e := (bits >> 52) & 0x7ff
-			var m int64
-			if e == 0 {
-				m = (bits & 0xfffffffffffff) << 1
-			} else {
-				m = (bits & 0xfffffffffffff) | 0x10000000000000
-			}
-			d := float64(s) * float64(m) * math.Pow(math.E, -1075.)
-			info = ConstantDoubleInfo{d}
 		case ConstantMethodHandle:
-			kind, _ := r.Read8()
-			index, _ := r.Read16()
-			info = ConstantMethodHandleInfo{MethodHandleRef(kind), index}
+			info = ConstantMethodHandleInfo{MethodHandleRef(p.u1()), p.u2()}
 		case ConstantMethodType:
-			descriptorIndex, _ := r.Read16()
-			info = ConstantMethodTypeInfo{descriptorIndex}
+			info = ConstantMethodTypeInfo{p.u2()}
+		case ConstantDynamic:
+			info = ConstantDynamicInfo{p.u2(), p.u2()}
 		case ConstantInvokeDynamic:
-			bootstrapMethodAttrIndex, _ := r.Read16()
-			nameAndTypeIndex, _ := r.Read16()
-			info = ConstantInvokeDynamicInfo{bootstrapMethodAttrIndex, nameAndTypeIndex}
+			info = ConstantInvokeDynamicInfo{p.u2(), p.u2()}
 		case ConstantModule:
-			nameIndex, _ := r.Read16()
-			info = ConstantModuleInfo{nameIndex}
+			info = ConstantModuleInfo{p.u2()}
 		case ConstantPackage:
-			nameIndex, _ := r.Read16()
-			info = ConstantPackageInfo{nameIndex}
+			info = ConstantPackageInfo{p.u2()}
 		default:
-			return nil, fmt.Errorf("unsupported constant pool type: 0x%02X(index=%d, constantPoolCount=%d)", constantType, i, constantPoolCount)
+			p.fail(offset, fmt.Errorf("unsupported constant pool type: 0x%02X", constantType))
+		}
+		if version, ok := constantVersions[constantType]; ok && majorVersion < version {
+			p.invalid(offset, fmt.Errorf("%s constant requires class file version %d, got %d", constantTagNames[constantType], version, majorVersion))
+		}
+		if constantType == ConstantModule || constantType == ConstantPackage {
+			p.moduleConstants = append(p.moduleConstants, moduleConstant{i, offset, constantType})
 		}
-		c.ConstantPool = append(c.ConstantPool, info)
+		pool = append(pool, info)
 		if double {
-			c.ConstantPool = append(c.ConstantPool, info)
+			if i == constantPoolCount-1 {
+				p.fail(offset, fmt.Errorf("8-byte constant at the last constant pool entry"))
+			}
+			pool = append(pool, ConstantUnusableInfo{})
 			i++
 		}
 	}
-	accessFlags, _ := r.Read16()
-	c.AccessFlags = AccessFlags(accessFlags)
-	c.ThisClass, _ = r.Read16()
-	c.SuperClass, _ = r.Read16()
-	interfacesCount, _ := r.Read16()
-	for i := uint16(0); i < interfacesCount; i++ {
-		intf, _ := r.Read16()
-		c.Interfaces = append(c.Interfaces, intf)
+	return pool
+}
+
+// attributes reads an attributes table. prefix is prepended to the path of each attribute.
+// Code attributes are skipped if skipCode, and all attributes with SkipAttributes.
+func (p *classParser) attributes(prefix string, pool ConstantPool, skipCode bool) []Attribute {
+	p.path = prefix + "attributes_count"
+	attributesCount := p.u2()
+	var attributes []Attribute
+	if !p.opts.SkipAttributes {
+		attributes = make([]Attribute, 0, attributesCount)
+	}
+	for i := uint16(0); i < attributesCount && p.err == nil; i++ {
+		p.path = fmt.Sprintf("%sattribute[%d]", prefix, i)
+		offset := p.r.Offset()
+		nameIndex := p.u2()
+		length := p.attributeLength()
+		if p.opts.SkipAttributes || skipCode && isCodeAttribute(nameIndex, pool) {
+			p.skip(int(length))
+			continue
+		}
+		info := p.bytes(int(length))
+		if p.err != nil {
+			break
+		}
+		a, err := decodeAttribute(nameIndex, info, pool)
+		if err != nil {
+			p.invalid(offset, err)
+		}
+		if _, ok := a.(*CodeAttribute); ok {
+			p.codeOffset, p.codePath = offset, p.path
+		}
+		attributes = append(attributes, a)
+	}
+	return attributes
+}
+
+// attributeLength reads the length of an attribute, checking MaxAttributeSize.
+func (p *classParser) attributeLength() int {
+	offset := p.r.Offset()
+	length := int64(p.u4())
+	if max := p.opts.Limits.MaxAttributeSize; p.err == nil && max > 0 && length > max {
+		p.fail(offset, &LimitError{LimitAttributeSize, length, max})
 	}
+	return int(length)
+}
 
-	fieldsCount, _ := r.Read16()
-	for i := uint16(0); i < fieldsCount; i++ {
-		flags, _ := r.Read16()
-		nameIndex, _ := r.Read16()
-		descriptorIndex, _ := r.Read16()
-		attributes, _ := readAttribute(r)
-		c.Fields = append(c.Fields, FieldInfo{FieldAccessFlags(flags), nameIndex, descriptorIndex, attributes})
+// isCodeAttribute compares the bytes of the name, which are the same in Modified UTF-8, without decoding them.
+func isCodeAttribute(nameIndex uint16, pool ConstantPool) bool {
+	info, err := pool.lookup(nameIndex, ConstantUtf8)
+	return err == nil && string(info.(ConstantUtf8Info).Bytes) == "Code"
+}
+
+func (c *ClassFile) SourceFile() string {
+	for _, a := range c.Attributes {
+		if s, ok := a.(*SourceFileAttribute); ok {
+			return c.ConstantPool.GetUTF8(s.SourceFileIndex)
+		}
 	}
+	return ""
+}
 
-	methodsCount, _ := r.Read16()
-	for i := uint16(0); i < methodsCount; i++ {
-		flags, _ := r.Read16()
-		nameIndex, _ := r.Read16()
-		descriptorIndex, _ := r.Read16()
-		attributes, _ := readAttribute(r)
-		c.Methods = append(c.Methods, MethodInfo{MethodAccessFlags(flags), nameIndex, descriptorIndex, attributes})
+// Signature returns the generic signature of the class, or an empty string if the class has none.
+func (c *ClassFile) Signature() string {
+	return findSignature(c.Attributes, c.ConstantPool)
+}
+
+func findSignature(attributes []Attribute, pool ConstantPool) string {
+	for _, a := range attributes {
+		if s, ok := a.(*SignatureAttribute); ok {
+			return pool.GetUTF8(s.SignatureIndex)
+		}
 	}
-	c.Attributes, _ = readAttribute(r)
-	return c, nil
+	return ""
 }
@@ -0,0 +1,386 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+)
+
+// minimalClass is a class file of `class A {}` without methods.
+var minimalClass = []byte{
+	0xCA, 0xFE, 0xBA, 0xBE, // magic
+	0x00, 0x00, 0x00, 0x34, // version
+	0x00, 0x05, // constant_pool_count
+	0x01, 0x00, 0x01, 'A', // #1 Utf8 A
+	0x07, 0x00, 0x01, // #2 Class #1
+	0x01, 0x00, 0x10, 'j', 'a', 'v', 'a', '/', 'l', 'a', 'n', 'g', '/', 'O', 'b', 'j', 'e', 'c', 't', // #3 Utf8
+	0x07, 0x00, 0x03, // #4 Class #3
+	0x00, 0x21, // access_flags
+	0x00, 0x02, // this_class
+	0x00, 0x04, // super_class
+	0x00, 0x00, // interfaces_count
+	0x00, 0x01, // fields_count
+	0x00, 0x02, 0x00, 0x01, 0x00, 0x01, // field[0]
+	0x00, 0x01, // attributes_count
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0xAB, 0xCD, // field[0].attribute[0]
+	0x00, 0x00, // methods_count
+	0x00, 0x00, // attributes_count
+}
+
+func TestRead(t *testing.T) {
+	c, err := Read(bytes.NewReader(minimalClass))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.ConstantPool) != 4 || len(c.Fields) != 1 || len(c.Fields[0].Attributes) != 1 {
+		t.Errorf("Read() = %v", c)
+	}
+}
+
+func TestRead_ParseError(t *testing.T) {
+	replace := func(offset int, b ...byte) []byte {
+		data := append([]byte{}, minimalClass...)
+		copy(data[offset:], b)
+		return data
+	}
+	tests := []struct {
+		name       string
+		data       []byte
+		wantPath   string
+		wantOffset int64
+		wantCause  error
+	}{
+		{
+			name:      "empty",
+			data:      []byte{},
+			wantPath:  "magic",
+			wantCause: io.ErrUnexpectedEOF,
+		},
+		{
+			name:     "bad magic",
+			data:     replace(0, 0xCA, 0xFE, 0xD0, 0x0D),
+			wantPath: "magic",
+		},
+		{
+			name:       "truncated constant",
+			data:       minimalClass[:20],
+			wantPath:   "constant_pool[3]",
+			wantOffset: 20,
+			wantCause:  io.ErrUnexpectedEOF,
+		},
+		{
+			name:       "unknown constant tag",
+			data:       replace(14, 0x02),
+			wantPath:   "constant_pool[2]",
+			wantOffset: 14,
+		},
+		{
+			name:       "zero constant pool count",
+			data:       replace(8, 0x00, 0x00),
+			wantPath:   "constant_pool_count",
+			wantOffset: 8,
+		},
+		{
+			name:       "long at last constant pool entry",
+			data:       replace(36, 0x05),
+			wantPath:   "constant_pool[4]",
+			wantOffset: 36,
+		},
+		{
+			name:       "truncated field",
+			data:       minimalClass[:51],
+			wantPath:   "field[0]",
+			wantOffset: 51,
+			wantCause:  io.ErrUnexpectedEOF,
+		},
+		{
+			name:       "attribute length exceeds data",
+			data:       replace(59, 0xFF, 0xFF, 0xFF, 0xFF),
+			wantPath:   "field[0].attribute[0]",
+			wantOffset: 63,
+			wantCause:  io.ErrUnexpectedEOF,
+		},
+		{
+			name:       "missing class attributes",
+			data:       minimalClass[:len(minimalClass)-2],
+			wantPath:   "attributes_count",
+			wantOffset: int64(len(minimalClass) - 2),
+			wantCause:  io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(tt.data))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Read() error = %v, want *ParseError", err)
+			}
+			if parseErr.Path != tt.wantPath || parseErr.Offset != tt.wantOffset {
+				t.Errorf("Read() error at %s offset %d, want %s offset %d", parseErr.Path, parseErr.Offset, tt.wantPath, tt.wantOffset)
+			}
+			if tt.wantCause != nil && !errors.Is(err, tt.wantCause) {
+				t.Errorf("Read() error = %v, want cause %v", err, tt.wantCause)
+			}
+			if _, bytesErr := ReadBytes(tt.data); fmt.Sprint(bytesErr) != fmt.Sprint(err) {
+				t.Errorf("ReadBytes() error = %v, want %v like Read()", bytesErr, err)
+			}
+		})
+	}
+}
+
+func TestReadBytes(t *testing.T) {
+	data := skipClass(t)
+	c, err := ReadBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf, wantBuf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	Write(&wantBuf, want)
+	if !bytes.Equal(buf.Bytes(), wantBuf.Bytes()) {
+		t.Error("ReadBytes() read a different class file from Read()")
+	}
+
+	// Constants and attributes share the memory of data without letting appends overwrite it.
+	within := func(b []byte) bool {
+		return len(b) > 0 && bytes.Contains(data, b) &&
+			&b[0] == &data[bytes.Index(data, b)] && cap(b) == len(b)
+	}
+	name := c.ConstantPool[c.Fields[0].NameIndex-1].(ConstantUtf8Info).Bytes
+	if !within(name) {
+		t.Error("Utf8 constant does not share the memory of data")
+	}
+	if code := c.Methods[0].Code(); code == nil || !within(code.Code) {
+		t.Error("code does not share the memory of data")
+	}
+
+	r := bytes.NewReader(data)
+	if _, err := ReadReaderAt(r, int64(len(data)), ParseOptions{}); err != nil {
+		t.Errorf("ReadReaderAt() error = %v", err)
+	}
+	var parseErr *ParseError
+	if _, err := ReadReaderAt(r, int64(len(data))+1, ParseOptions{}); !errors.As(err, &parseErr) || parseErr.Offset != int64(len(data)) {
+		t.Errorf("ReadReaderAt(size beyond the data) error = %v, want a *ParseError at offset %d", err, len(data))
+	}
+	if _, err := ReadReaderAt(bytes.NewReader(data[:20]), int64(len(data)), ParseOptions{}); !errors.As(err, &parseErr) || parseErr.Path == "" {
+		t.Errorf("ReadReaderAt(truncated) error = %v, want a *ParseError", err)
+	}
+	if _, err := ReadReaderAt(r, -1, ParseOptions{}); !errors.As(err, &parseErr) {
+		t.Errorf("ReadReaderAt(size -1) error = %v, want a *ParseError", err)
+	}
+}
+
+func TestReadWithOptions_ConstantVersion(t *testing.T) {
+	build := func(major uint16, flags AccessFlags, constant func(b *ConstantPoolBuilder) uint16) ([]byte, uint16) {
+		b := NewClassBuilder("Foo").Version(major, 0).Access(flags)
+		index := constant(b.Pool())
+		data, err := b.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data, index
+	}
+	dynamic := func(b *ConstantPoolBuilder) uint16 { return b.Dynamic(0, "_", "I") }
+	module := func(b *ConstantPoolBuilder) uint16 { return b.Module("foo") }
+	tests := []struct {
+		name     string
+		major    uint16
+		flags    AccessFlags
+		constant func(b *ConstantPoolBuilder) uint16
+		wantErr  string
+	}{
+		{"Dynamic in Java 11", 55, AccessPublic, dynamic, ""},
+		{"Dynamic in Java 8", 52, AccessPublic, dynamic, "Dynamic constant requires class file version 55, got 52"},
+		{"MethodType in Java 6", 50, AccessPublic, func(b *ConstantPoolBuilder) uint16 { return b.MethodType("()V") }, "MethodType constant requires class file version 51, got 50"},
+		{"Module in module-info", 53, AccessModule, module, ""},
+		{"Module in a class", 53, AccessPublic, module, "Module constant outside of a module"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, index := build(tt.major, tt.flags, tt.constant)
+			var warnings []*ParseError
+			if _, err := ReadWithOptions(bytes.NewReader(data), ParseOptions{Warn: func(err *ParseError) { warnings = append(warnings, err) }}); err != nil {
+				t.Fatalf("lenient ReadWithOptions() error = %v", err)
+			}
+			_, err := ReadWithOptions(bytes.NewReader(data), ParseOptions{Strict: true})
+			if tt.wantErr == "" {
+				if len(warnings) > 0 || err != nil {
+					t.Errorf("warnings = %v, strict error = %v, want none", warnings, err)
+				}
+				return
+			}
+			wantPath := fmt.Sprintf("constant_pool[%d]", index)
+			if len(warnings) != 1 || warnings[0].Path != wantPath || warnings[0].Err.Error() != tt.wantErr {
+				t.Errorf("warnings = %v, want %s: %s", warnings, wantPath, tt.wantErr)
+			}
+			if e, ok := err.(*ParseError); !ok || e.Path != wantPath || e.Err.Error() != tt.wantErr {
+				t.Errorf("strict error = %v, want %s: %s", err, wantPath, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRead_FloatBits(t *testing.T) {
+	floats := []uint32{0x00000000, 0x80000000, 0x7F800000, 0xFF800000, 0x7FC00001, 0x00000001, 0x501502F9}
+	doubles := []uint64{0x8000000000000000, 0x7FF0000000000000, 0x7FF8000000000123, 0x0000000000000001, 0x4202A05F20000000}
+	var pool []byte
+	for _, bits := range floats {
+		pool = append(pool, ConstantFloat, byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+	}
+	for _, bits := range doubles {
+		pool = append(pool, ConstantDouble)
+		for shift := 56; shift >= 0; shift -= 8 {
+			pool = append(pool, byte(bits>>uint(shift)))
+		}
+	}
+	count := 1 + len(floats) + 2*len(doubles)
+	data := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x34, byte(count >> 8), byte(count)}
+	data = append(data, pool...)
+	data = append(data, 0x00, 0x21, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+	c, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, bits := range floats {
+		info, ok := c.ConstantPool[i].(ConstantFloatInfo)
+		if !ok || math.Float32bits(info.Value) != bits {
+			t.Errorf("constant_pool[%d] = %v, want float bits %#08x", i+1, c.ConstantPool[i], bits)
+		}
+	}
+	for i, bits := range doubles {
+		index := len(floats) + 2*i
+		info, ok := c.ConstantPool[index].(ConstantDoubleInfo)
+		if !ok || math.Float64bits(info.Value) != bits {
+			t.Errorf("constant_pool[%d] = %v, want double bits %#016x", index+1, c.ConstantPool[index], bits)
+		}
+	}
+}
+
+// skipClass returns a class file with a field, a method with Code and Exceptions and a SourceFile.
+func skipClass(tb testing.TB) []byte {
+	b := NewClassBuilder("com/acme/Foo")
+	data, err := b.Super("java/lang/Object").
+		Implements("java/lang/Runnable").
+		AddField(FieldAccessPublic|FieldAccessStatic|FieldAccessFinal, "MAX", "I", b.ConstantValue(int32(10))).
+		AddMethod(MethodAccessPublic, "run", "()V", b.Code(0, 1, []byte{0xB1}), b.Exceptions("java/io/IOException")).
+		AddAttribute(b.SourceFile("Foo.java")).
+		Bytes()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+func TestReadWithOptions_Skip(t *testing.T) {
+	data := skipClass(t)
+	tests := []struct {
+		opts                                ParseOptions
+		fields, methods, methodAttrs, attrs int
+	}{
+		{ParseOptions{}, 1, 1, 2, 1},
+		{ParseOptions{SkipMembers: true}, 0, 0, 0, 1},
+		{ParseOptions{SkipAttributes: true}, 1, 1, 0, 0},
+		{ParseOptions{SkipCode: true}, 1, 1, 1, 1},
+		{ParseOptions{SkipMembers: true, SkipAttributes: true}, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		c, err := ReadWithOptions(bytes.NewReader(data), tt.opts)
+		if err != nil {
+			t.Errorf("ReadWithOptions(%+v) error = %v", tt.opts, err)
+			continue
+		}
+		methodAttrs := 0
+		if len(c.Methods) > 0 {
+			methodAttrs = len(c.Methods[0].Attributes)
+			if tt.opts.SkipCode && c.Methods[0].Code() != nil {
+				t.Errorf("ReadWithOptions(%+v) read the Code attribute", tt.opts)
+			}
+		}
+		if len(c.Fields) != tt.fields || len(c.Methods) != tt.methods || methodAttrs != tt.methodAttrs || len(c.Attributes) != tt.attrs {
+			t.Errorf("ReadWithOptions(%+v) = %d fields, %d methods with %d attributes, %d attributes, want %d, %d with %d, %d",
+				tt.opts, len(c.Fields), len(c.Methods), methodAttrs, len(c.Attributes), tt.fields, tt.methods, tt.methodAttrs, tt.attrs)
+		}
+		if name := NewClass(c).Name(); name != "com/acme/Foo" || len(c.Interfaces) != 1 {
+			t.Errorf("ReadWithOptions(%+v) read class %s with interfaces %v", tt.opts, name, c.Interfaces)
+		}
+	}
+
+	// The header is read without the rest of the class file.
+	truncated := data[:len(data)-20]
+	if _, err := ReadHeader(bytes.NewReader(truncated)); err != nil {
+		t.Errorf("ReadHeader(truncated) error = %v", err)
+	}
+	if _, err := ReadWithOptions(bytes.NewReader(truncated), ParseOptions{SkipMembers: true}); err == nil {
+		t.Error("ReadWithOptions(truncated, SkipMembers) error = nil")
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	data := skipClass(b)
+	for _, bm := range []struct {
+		name string
+		opts ParseOptions
+	}{
+		{"all", ParseOptions{}},
+		{"SkipCode", ParseOptions{SkipCode: true}},
+		{"SkipMembers", ParseOptions{SkipMembers: true}},
+		{"header", ParseOptions{SkipMembers: true, SkipAttributes: true}},
+	} {
+		b.Run(bm.name+"/reader", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ReadWithOptions(bytes.NewReader(data), bm.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bm.name+"/bytes", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ReadBytesWithOptions(data, bm.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestReadWithOptions_Limits(t *testing.T) {
+	data := skipClass(t)
+	tests := []struct {
+		limits Limits
+		want   LimitKind
+	}{
+		{Limits{MaxAttributeSize: 4}, LimitAttributeSize},
+		{Limits{MaxConstantPoolEntries: 5}, LimitConstantPoolEntries},
+		{Limits{MaxTotalBytes: int64(len(data)) - 1}, LimitTotalBytes},
+		{Limits{MaxTotalBytes: 100}, LimitTotalBytes},
+	}
+	for _, tt := range tests {
+		opts := ParseOptions{Limits: tt.limits}
+		for name, read := range map[string]func() (*ClassFile, error){
+			"ReadWithOptions":      func() (*ClassFile, error) { return ReadWithOptions(bytes.NewReader(data), opts) },
+			"ReadBytesWithOptions": func() (*ClassFile, error) { return ReadBytesWithOptions(data, opts) },
+			"ReadReaderAt":         func() (*ClassFile, error) { return ReadReaderAt(bytes.NewReader(data), int64(len(data)), opts) },
+		} {
+			_, err := read()
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != tt.want {
+				t.Errorf("%s(%+v) error = %v, want %s limit", name, tt.limits, err, limitNames[tt.want])
+			}
+		}
+	}
+	limits := Limits{MaxAttributeSize: 100, MaxConstantPoolEntries: 100, MaxTotalBytes: int64(len(data))}
+	if _, err := ReadBytesWithOptions(data, ParseOptions{Limits: limits}); err != nil {
+		t.Errorf("ReadBytesWithOptions(%+v) error = %v", limits, err)
+	}
+}
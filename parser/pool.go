@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// ConstantPoolBuilder builds a constant pool, reusing existing entries for equal constants.
+// Long and Double constants take two slots like in a class file read by Read.
+type ConstantPoolBuilder struct {
+	pool    ConstantPool
+	indexes map[constantKey]uint16
+	err     error
+}
+
+type constantKey struct {
+	tag  uint8
+	s    string
+	a, b uint16
+	n    uint64
+}
+
+func NewConstantPoolBuilder() *ConstantPoolBuilder {
+	return &ConstantPoolBuilder{indexes: map[constantKey]uint16{}}
+}
+
+// ConstantPool returns the constant pool built so far.
+func (b *ConstantPoolBuilder) ConstantPool() ConstantPool {
+	return b.pool
+}
+
+// Err returns the first error, which happens when the pool overflows.
+func (b *ConstantPoolBuilder) Err() error {
+	return b.err
+}
+
+func (b *ConstantPoolBuilder) add(key constantKey, info ConstantInfo) uint16 {
+	if index, ok := b.indexes[key]; ok {
+		return index
+	}
+	return b.append(key, info, true)
+}
+
+// append adds a new entry for info, reusable by equal constants if reuse is set and none was added before.
+func (b *ConstantPoolBuilder) append(key constantKey, info ConstantInfo, reuse bool) uint16 {
+	slots := 1
+	if key.tag == ConstantLong || key.tag == ConstantDouble {
+		slots = 2
+	}
+	// constant_pool_count is a u2 and counts the unused entry 0.
+	if len(b.pool)+slots >= math.MaxUint16 {
+		if b.err == nil {
+			b.err = fmt.Errorf("constant pool overflow")
+		}
+		return 0
+	}
+	b.pool = append(b.pool, info)
+	if slots == 2 {
+		b.pool = append(b.pool, ConstantUnusableInfo{})
+	}
+	index := uint16(len(b.pool) - slots + 1)
+	if _, ok := b.indexes[key]; reuse && !ok {
+		b.indexes[key] = index
+	}
+	return index
+}
+
+// Add appends a constant as is, even if an equal one exists, and returns its index.
+// Adding the entries of a constant pool read by Read in order keeps their indexes,
+// while the other methods reuse them for equal constants.
+func (b *ConstantPoolBuilder) Add(info ConstantInfo) uint16 {
+	key := constantKey{tag: constantTag(info)}
+	reuse := true
+	switch e := info.(type) {
+	case ConstantUtf8Info:
+		// Malformed bytes do not decode to a string with the same encoding.
+		s, err := e.Value()
+		key.s, reuse = s, err == nil && bytes.Equal(EncodeModifiedUTF8(s), e.Bytes)
+	case ConstantIntegerInfo:
+		key.n = uint64(uint32(e.Value))
+	case ConstantFloatInfo:
+		key.n = uint64(math.Float32bits(e.Value))
+	case ConstantLongInfo:
+		key.n = uint64(e.Value)
+	case ConstantDoubleInfo:
+		key.n = math.Float64bits(e.Value)
+	case ConstantClassInfo:
+		key.a = e.NameIndex
+	case ConstantStringInfo:
+		key.a = e.StringIndex
+	case ConstantFieldrefInfo:
+		key.a, key.b = e.ClassIndex, e.NameAndTypeIndex
+	case ConstantMethodrefInfo:
+		key.a, key.b = e.ClassIndex, e.NameAndTypeIndex
+	case ConstantInterfaceMethodrefInfo:
+		key.a, key.b = e.ClassIndex, e.NameAndTypeIndex
+	case ConstantNameAndTypeInfo:
+		key.a, key.b = e.NameIndex, e.DescriptorIndex
+	case ConstantMethodHandleInfo:
+		key.a, key.b = uint16(e.ReferenceKind), e.ReferenceIndex
+	case ConstantMethodTypeInfo:
+		key.a = e.DescriptorIndex
+	case ConstantDynamicInfo:
+		key.a, key.b = e.BootstrapMethodAttrIndex, e.NameAndTypeIndex
+	case ConstantInvokeDynamicInfo:
+		key.a, key.b = e.BootstrapMethodAttrIndex, e.NameAndTypeIndex
+	case ConstantModuleInfo:
+		key.a = e.NameIndex
+	case ConstantPackageInfo:
+		key.a = e.NameIndex
+	default:
+		if b.err == nil {
+			b.err = fmt.Errorf("unknown constant %T", info)
+		}
+		return 0
+	}
+	return b.append(key, info, reuse)
+}
+
+// Utf8 adds the Modified UTF-8 encoding of s.
+func (b *ConstantPoolBuilder) Utf8(s string) uint16 {
+	return b.add(constantKey{tag: ConstantUtf8, s: s}, ConstantUtf8Info{EncodeModifiedUTF8(s)})
+}
+
+func (b *ConstantPoolBuilder) Integer(v int32) uint16 {
+	return b.add(constantKey{tag: ConstantInteger, n: uint64(uint32(v))}, ConstantIntegerInfo{v})
+}
+
+func (b *ConstantPoolBuilder) Float(v float32) uint16 {
+	return b.add(constantKey{tag: ConstantFloat, n: uint64(math.Float32bits(v))}, ConstantFloatInfo{v})
+}
+
+func (b *ConstantPoolBuilder) Long(v int64) uint16 {
+	return b.add(constantKey{tag: ConstantLong, n: uint64(v)}, ConstantLongInfo{v})
+}
+
+func (b *ConstantPoolBuilder) Double(v float64) uint16 {
+	return b.add(constantKey{tag: ConstantDouble, n: math.Float64bits(v)}, ConstantDoubleInfo{v})
+}
+
+// Class adds a class by its internal name like java/lang/Object.
+func (b *ConstantPoolBuilder) Class(name string) uint16 {
+	nameIndex := b.Utf8(name)
+	return b.add(constantKey{tag: ConstantClass, a: nameIndex}, ConstantClassInfo{nameIndex})
+}
+
+func (b *ConstantPoolBuilder) String(s string) uint16 {
+	stringIndex := b.Utf8(s)
+	return b.add(constantKey{tag: ConstantString, a: stringIndex}, ConstantStringInfo{stringIndex})
+}
+
+func (b *ConstantPoolBuilder) NameAndType(name, descriptor string) uint16 {
+	nameIndex, descriptorIndex := b.Utf8(name), b.Utf8(descriptor)
+	return b.add(constantKey{tag: ConstantNameAndType, a: nameIndex, b: descriptorIndex}, ConstantNameAndTypeInfo{nameIndex, descriptorIndex})
+}
+
+func (b *ConstantPoolBuilder) Fieldref(class, name, descriptor string) uint16 {
+	classIndex, nameAndTypeIndex := b.Class(class), b.NameAndType(name, descriptor)
+	return b.add(constantKey{tag: ConstantFieldref, a: classIndex, b: nameAndTypeIndex}, ConstantFieldrefInfo{classIndex, nameAndTypeIndex})
+}
+
+func (b *ConstantPoolBuilder) Methodref(class, name, descriptor string) uint16 {
+	classIndex, nameAndTypeIndex := b.Class(class), b.NameAndType(name, descriptor)
+	return b.add(constantKey{tag: ConstantMethodref, a: classIndex, b: nameAndTypeIndex}, ConstantMethodrefInfo{classIndex, nameAndTypeIndex})
+}
+
+func (b *ConstantPoolBuilder) InterfaceMethodref(class, name, descriptor string) uint16 {
+	classIndex, nameAndTypeIndex := b.Class(class), b.NameAndType(name, descriptor)
+	return b.add(constantKey{tag: ConstantInterfaceMethodref, a: classIndex, b: nameAndTypeIndex}, ConstantInterfaceMethodrefInfo{classIndex, nameAndTypeIndex})
+}
+
+// MethodHandle adds a method handle. referenceIndex is the index of a Fieldref, Methodref or InterfaceMethodref.
+func (b *ConstantPoolBuilder) MethodHandle(kind MethodHandleRef, referenceIndex uint16) uint16 {
+	return b.add(constantKey{tag: ConstantMethodHandle, a: uint16(kind), b: referenceIndex}, ConstantMethodHandleInfo{kind, referenceIndex})
+}
+
+func (b *ConstantPoolBuilder) MethodType(descriptor string) uint16 {
+	descriptorIndex := b.Utf8(descriptor)
+	return b.add(constantKey{tag: ConstantMethodType, a: descriptorIndex}, ConstantMethodTypeInfo{descriptorIndex})
+}
+
+// Dynamic adds a dynamically-computed constant. bootstrapMethod is an index into the BootstrapMethods attribute.
+func (b *ConstantPoolBuilder) Dynamic(bootstrapMethod uint16, name, descriptor string) uint16 {
+	nameAndTypeIndex := b.NameAndType(name, descriptor)
+	return b.add(constantKey{tag: ConstantDynamic, a: bootstrapMethod, b: nameAndTypeIndex}, ConstantDynamicInfo{bootstrapMethod, nameAndTypeIndex})
+}
+
+// InvokeDynamic adds a call site. bootstrapMethod is an index into the BootstrapMethods attribute.
+func (b *ConstantPoolBuilder) InvokeDynamic(bootstrapMethod uint16, name, descriptor string) uint16 {
+	nameAndTypeIndex := b.NameAndType(name, descriptor)
+	return b.add(constantKey{tag: ConstantInvokeDynamic, a: bootstrapMethod, b: nameAndTypeIndex}, ConstantInvokeDynamicInfo{bootstrapMethod, nameAndTypeIndex})
+}
+
+func (b *ConstantPoolBuilder) Module(name string) uint16 {
+	nameIndex := b.Utf8(name)
+	return b.add(constantKey{tag: ConstantModule, a: nameIndex}, ConstantModuleInfo{nameIndex})
+}
+
+func (b *ConstantPoolBuilder) Package(name string) uint16 {
+	nameIndex := b.Utf8(name)
+	return b.add(constantKey{tag: ConstantPackage, a: nameIndex}, ConstantPackageInfo{nameIndex})
+}
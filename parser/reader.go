@@ -7,14 +7,52 @@ import (
 
 type Reader struct {
 	reader *bufio.Reader
+	// data is read instead of reader by a Reader of NewBytesReader.
+	data   []byte
+	offset int64
+	// max is the number of bytes allowed to be read, or 0 for no limit.
+	max int64
 }
 
 func NewReader(r io.Reader) *Reader {
-	return &Reader{bufio.NewReader(r)}
+	return &Reader{reader: bufio.NewReader(r)}
+}
+
+// NewBytesReader returns a Reader of data, which reads without buffering or copying.
+func NewBytesReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// Offset returns the number of bytes read so far.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// check returns a *LimitError if reading n more bytes exceeds the limit of the Reader.
+func (r *Reader) check(n int) error {
+	if r.max > 0 && r.offset+int64(n) > r.max {
+		return &LimitError{LimitTotalBytes, r.offset + int64(n), r.max}
+	}
+	return nil
 }
 
 func (r *Reader) Read8() (uint8, error) {
-	return r.reader.ReadByte()
+	if err := r.check(1); err != nil {
+		return 0, err
+	}
+	if r.data != nil {
+		if r.offset >= int64(len(r.data)) {
+			return 0, io.EOF
+		}
+		r.offset++
+		return r.data[r.offset-1], nil
+	}
+	b, err := r.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.offset++
+	return b, nil
 }
 
 func (r *Reader) Read16() (uint16, error) {
@@ -54,52 +92,59 @@ func (r *Reader) Read64() (uint64, error) {
 }
 
 func (r *Reader) ReadBytes(bytes []byte) (int, error) {
-	read := 0
-	for {
-		n, err := r.reader.Read(bytes[read:])
+	if err := r.check(len(bytes)); err != nil {
+		return 0, err
+	}
+	if r.data != nil {
+		b, err := r.slice(len(bytes))
 		if err != nil {
 			return 0, err
 		}
+		return copy(bytes, b), nil
+	}
+	n, err := io.ReadFull(r.reader, bytes)
+	r.offset += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
 
-		read += n
-		if read >= len(bytes) {
-			return read, nil
+// slice returns the next n bytes of the data of a Reader of NewBytesReader. They share its memory
+// with their capacity limited to n, so appending to them does not overwrite the data.
+func (r *Reader) slice(n int) ([]byte, error) {
+	remaining := int64(len(r.data)) - r.offset
+	if int64(n) > remaining {
+		r.offset += remaining
+		if remaining == 0 {
+			return nil, io.EOF
 		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.offset : r.offset+int64(n) : r.offset+int64(n)]
+	r.offset += int64(n)
+	return b, nil
+}
+
+// Skip reads n bytes without keeping them.
+func (r *Reader) Skip(n int) error {
+	if err := r.check(n); err != nil {
+		return err
 	}
+	if r.data != nil {
+		_, err := r.slice(n)
+		return err
+	}
+	discarded, err := r.reader.Discard(n)
+	r.offset += int64(discarded)
+	return err
 }
 
+// ReadModifiedUTF8 reads length bytes of Modified UTF-8, like the bytes of a Utf8 constant, and decodes them.
 func (r *Reader) ReadModifiedUTF8(length uint16) (string, error) {
-	var index uint16
-	runes := make([]rune, length)
-	for {
-		n1, err := r.Read8()
-		if err != nil {
-			return "", err
-		}
-		var c rune
-		if n1 <= 0x7F {
-			c = rune(n1)
-		} else {
-			n2, err := r.Read8()
-			if err != nil {
-				return "", err
-			}
-			w := (uint16(n1) << 8) | uint16(n2)
-			if w <= 0x7FF {
-				c = rune(((uint16(n1) & 0x1F) << 6) | (uint16(n2) & 0x3F))
-			} else {
-				n3, err := r.Read8()
-				if err != nil {
-					return "", err
-				}
-				c = rune(((uint32(n1) & 0x0F) << 12) | ((uint32(n2) & 0x3F) << 6) | (uint32(n3) & 0x3F))
-			}
-		}
-		runes[index] = c
-		index++
-		if index == length {
-			break
-		}
+	b := make([]byte, length)
+	if _, err := r.ReadBytes(b); err != nil {
+		return "", err
 	}
-	return string(runes), nil
+	return DecodeModifiedUTF8(b)
 }
@@ -0,0 +1,171 @@
+package parser
+
+import "fmt"
+
+type (
+	StackMapTableAttribute struct {
+		AttributeHeader
+		Entries []StackMapFrame
+	}
+
+	// StackMapFrame keeps the frame type as encoded, so the compressed form of the
+	// frame (same, chop, append, ...) can be told apart and written back unchanged.
+	// OffsetDelta is also set for frame types which encode it in the frame type.
+	StackMapFrame struct {
+		FrameType   uint8
+		OffsetDelta uint16
+		Locals      []VerificationTypeInfo
+		Stack       []VerificationTypeInfo
+	}
+
+	VerificationTypeInfo struct {
+		Tag VerificationType
+		// Index is the constant pool index of an Object type or the
+		// code offset of the new instruction of an Uninitialized type.
+		Index uint16
+	}
+
+	VerificationType uint8
+)
+
+const (
+	VerificationTop               VerificationType = 0
+	VerificationInteger           VerificationType = 1
+	VerificationFloat             VerificationType = 2
+	VerificationDouble            VerificationType = 3
+	VerificationLong              VerificationType = 4
+	VerificationNull              VerificationType = 5
+	VerificationUninitializedThis VerificationType = 6
+	VerificationObject            VerificationType = 7
+	VerificationUninitialized     VerificationType = 8
+
+	StackMapSameFrame                         = 0
+	StackMapSameLocals1StackItemFrame         = 64
+	StackMapSameLocals1StackItemFrameExtended = 247
+	StackMapChopFrame                         = 248
+	StackMapSameFrameExtended                 = 251
+	StackMapAppendFrame                       = 252
+	StackMapFullFrame                         = 255
+)
+
+func init() {
+	RegisterAttribute("StackMapTable", decodeStackMapTable)
+}
+
+func decodeStackMapTable(nameIndex uint16, info []byte, pool ConstantPool) (Attribute, error) {
+	r := newAttributeReader(info)
+	a := &StackMapTableAttribute{AttributeHeader: AttributeHeader{nameIndex}}
+	count := r.u2()
+	if !r.check(int(count)) {
+		return nil, r.err
+	}
+	a.Entries = make([]StackMapFrame, count)
+	for i := range a.Entries {
+		f := &a.Entries[i]
+		f.FrameType = r.u1()
+		switch {
+		case f.FrameType < StackMapSameLocals1StackItemFrame:
+			f.OffsetDelta = uint16(f.FrameType)
+		case f.FrameType < 128:
+			f.OffsetDelta = uint16(f.FrameType - StackMapSameLocals1StackItemFrame)
+			f.Stack = r.verificationTypes(1)
+		case f.FrameType < StackMapSameLocals1StackItemFrameExtended:
+			r.fail("reserved stack map frame type %d", f.FrameType)
+		case f.FrameType == StackMapSameLocals1StackItemFrameExtended:
+			f.OffsetDelta = r.u2()
+			f.Stack = r.verificationTypes(1)
+		case f.FrameType < StackMapAppendFrame:
+			f.OffsetDelta = r.u2()
+		case f.FrameType < StackMapFullFrame:
+			f.OffsetDelta = r.u2()
+			f.Locals = r.verificationTypes(int(f.FrameType) - StackMapSameFrameExtended)
+		default:
+			f.OffsetDelta = r.u2()
+			f.Locals = r.verificationTypes(int(r.u2()))
+			f.Stack = r.verificationTypes(int(r.u2()))
+		}
+	}
+	return a, r.finish()
+}
+
+func (r *attributeReader) verificationTypes(n int) []VerificationTypeInfo {
+	if !r.check(n) {
+		return nil
+	}
+	types := make([]VerificationTypeInfo, n)
+	for i := range types {
+		types[i].Tag = VerificationType(r.u1())
+		switch types[i].Tag {
+		case VerificationObject, VerificationUninitialized:
+			types[i].Index = r.u2()
+		default:
+			if types[i].Tag > VerificationUninitialized {
+				r.fail("unknown verification type %d", types[i].Tag)
+			}
+		}
+	}
+	return types
+}
+
+func (t VerificationType) String() string {
+	switch t {
+	case VerificationTop:
+		return "top"
+	case VerificationInteger:
+		return "int"
+	case VerificationFloat:
+		return "float"
+	case VerificationDouble:
+		return "double"
+	case VerificationLong:
+		return "long"
+	case VerificationNull:
+		return "null"
+	case VerificationUninitializedThis:
+		return "uninitialized_this"
+	case VerificationObject:
+		return "object"
+	case VerificationUninitialized:
+		return "uninitialized"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
+func (a StackMapTableAttribute) MarshalBinary() ([]byte, error) {
+	w := &attributeWriter{}
+	w.count(len(a.Entries), "stack map frames")
+	for _, f := range a.Entries {
+		w.u1(f.FrameType)
+		switch {
+		case f.FrameType < StackMapSameLocals1StackItemFrame:
+		case f.FrameType < 128:
+			w.verificationTypes(f.Stack)
+		case f.FrameType < StackMapSameLocals1StackItemFrameExtended:
+			w.fail("reserved stack map frame type %d", f.FrameType)
+		case f.FrameType == StackMapSameLocals1StackItemFrameExtended:
+			w.u2(f.OffsetDelta)
+			w.verificationTypes(f.Stack)
+		case f.FrameType < StackMapAppendFrame:
+			w.u2(f.OffsetDelta)
+		case f.FrameType < StackMapFullFrame:
+			w.u2(f.OffsetDelta)
+			w.verificationTypes(f.Locals)
+		default:
+			w.u2(f.OffsetDelta)
+			w.count(len(f.Locals), "locals")
+			w.verificationTypes(f.Locals)
+			w.count(len(f.Stack), "stack items")
+			w.verificationTypes(f.Stack)
+		}
+	}
+	return w.finish()
+}
+
+func (w *attributeWriter) verificationTypes(types []VerificationTypeInfo) {
+	for _, t := range types {
+		w.u1(uint8(t.Tag))
+		if t.Tag == VerificationObject || t.Tag == VerificationUninitialized {
+			w.u2(t.Index)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"errors"
+	"io"
+)
+
+// ClassVisitor receives the parts of a class file in order: VisitHeader, VisitField for each field
+// followed by VisitAttribute for each of its attributes, VisitMethod for each method followed
+// by VisitAttribute for each of its attributes, VisitAttribute for each attribute of the class,
+// and VisitEnd. A Code attribute is followed by VisitInstruction for each of its instructions
+// and VisitAttribute for each of its attributes.
+// An error returned by a method stops the visit, which returns the error.
+type ClassVisitor interface {
+	// VisitHeader is called with the class file up to the interfaces, without fields,
+	// methods and attributes. The constant pool resolves the indexes of the later parts.
+	VisitHeader(c *ClassFile) error
+	// VisitField and VisitMethod are called with the member and its attributes, which are
+	// visited next. Returning SkipMember leaves out the member and its attributes.
+	VisitField(f FieldInfo) error
+	VisitMethod(m MethodInfo) error
+	// VisitInstruction is called with the instructions decoded from the Code attribute
+	// last visited. Instructions are only read: a visitor changes the code by passing on
+	// another Code attribute. Code which cannot be decoded is visited without instructions.
+	VisitInstruction(inst Instruction) error
+	// VisitAttribute is called with an attribute of owner, which is the class, or the field,
+	// the method or the Code attribute last visited.
+	VisitAttribute(owner AttributeOwner, a Attribute) error
+	VisitEnd() error
+}
+
+// AttributeOwner is the part of a class file an attribute belongs to.
+type AttributeOwner int
+
+const (
+	OwnerClass AttributeOwner = iota
+	OwnerField
+	OwnerMethod
+	OwnerCode
+)
+
+// SkipMember is returned by VisitField or VisitMethod to leave out the member.
+// It is not returned by the visit.
+var SkipMember = errors.New("skip this member")
+
+// Accept reads a class file and passes each part to visitor as soon as it is read,
+// without keeping the fields and the methods. A class file failing to parse stops
+// the visit with a *ParseError, after the parts before the failure are visited.
+func Accept(reader io.Reader, visitor ClassVisitor) error {
+	return AcceptWithOptions(reader, visitor, ParseOptions{})
+}
+
+// AcceptWithOptions is Accept with options. The skipped parts are not visited.
+// Code which cannot be decoded is reported like a malformed attribute.
+func AcceptWithOptions(reader io.Reader, visitor ClassVisitor, opts ParseOptions) error {
+	p := newClassParser(NewReader(reader), opts)
+	c := p.header()
+	if p.err != nil {
+		return p.err
+	}
+	if err := visitor.VisitHeader(c); err != nil {
+		return err
+	}
+	if opts.SkipMembers && opts.SkipAttributes {
+		return visitor.VisitEnd()
+	}
+	invalid := func(err error) error {
+		p.path = p.codePath
+		p.invalid(p.codeOffset, err)
+		return p.err
+	}
+	err := p.members(c.ConstantPool, func(f FieldInfo) error {
+		return visitField(visitor, f)
+	}, func(m MethodInfo) error {
+		return visitMethod(visitor, m, c.ConstantPool, invalid)
+	})
+	if err != nil {
+		return err
+	}
+	attributes := p.attributes("", c.ConstantPool, false)
+	if p.err != nil {
+		return p.err
+	}
+	if err := visitAttributes(visitor, OwnerClass, attributes); err != nil {
+		return err
+	}
+	return visitor.VisitEnd()
+}
+
+// Accept passes the parts of the class file to visitor like Accept reading it.
+// Code which cannot be decoded is visited without instructions.
+func (c *ClassFile) Accept(visitor ClassVisitor) error {
+	header := *c
+	header.Fields, header.Methods, header.Attributes = nil, nil, nil
+	if err := visitor.VisitHeader(&header); err != nil {
+		return err
+	}
+	for _, f := range c.Fields {
+		if err := visitField(visitor, f); err != nil {
+			return err
+		}
+	}
+	ignore := func(err error) error {
+		return nil
+	}
+	for _, m := range c.Methods {
+		if err := visitMethod(visitor, m, c.ConstantPool, ignore); err != nil {
+			return err
+		}
+	}
+	if err := visitAttributes(visitor, OwnerClass, c.Attributes); err != nil {
+		return err
+	}
+	return visitor.VisitEnd()
+}
+
+func visitField(visitor ClassVisitor, f FieldInfo) error {
+	if err := visitor.VisitField(f); err != nil {
+		if err == SkipMember {
+			return nil
+		}
+		return err
+	}
+	return visitAttributes(visitor, OwnerField, f.Attributes)
+}
+
+// visitMethod visits a method with its attributes and code. A decoding error of the code
+// is passed to invalid, which returns it if the visit should stop.
+func visitMethod(visitor ClassVisitor, m MethodInfo, pool ConstantPool, invalid func(error) error) error {
+	if err := visitor.VisitMethod(m); err != nil {
+		if err == SkipMember {
+			return nil
+		}
+		return err
+	}
+	for _, a := range m.Attributes {
+		if err := visitor.VisitAttribute(OwnerMethod, a); err != nil {
+			return err
+		}
+		code, ok := a.(*CodeAttribute)
+		if !ok {
+			continue
+		}
+		instructions, err := DecodeInstructions(code.Code, pool)
+		if err != nil {
+			if err := invalid(err); err != nil {
+				return err
+			}
+		}
+		for _, inst := range instructions {
+			if err := visitor.VisitInstruction(inst); err != nil {
+				return err
+			}
+		}
+		if err := visitAttributes(visitor, OwnerCode, code.Attributes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func visitAttributes(visitor ClassVisitor, owner AttributeOwner, attributes []Attribute) error {
+	for _, a := range attributes {
+		if err := visitor.VisitAttribute(owner, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassAdapter passes every call to Next, or ignores it if Next is nil.
+// Embed it in a visitor to change some parts of the class file and pass on the rest,
+// building a pipeline of visitors.
+type ClassAdapter struct {
+	Next ClassVisitor
+}
+
+func (a ClassAdapter) VisitHeader(c *ClassFile) error {
+	if a.Next == nil {
+		return nil
+	}
+	return a.Next.VisitHeader(c)
+}
+
+func (a ClassAdapter) VisitField(f FieldInfo) error {
+	if a.Next == nil {
+		return nil
+	}
+	return a.Next.VisitField(f)
+}
+
+func (a ClassAdapter) VisitMethod(m MethodInfo) error {
+	if a.Next == nil {
+		return nil
+	}
+	return a.Next.VisitMethod(m)
+}
+
+func (a ClassAdapter) VisitInstruction(inst Instruction) error {
+	if a.Next == nil {
+		return nil
+	}
+	return a.Next.VisitInstruction(inst)
+}
+
+func (a ClassAdapter) VisitAttribute(owner AttributeOwner, attribute Attribute) error {
+	if a.Next == nil {
+		return nil
+	}
+	return a.Next.VisitAttribute(owner, attribute)
+}
+
+func (a ClassAdapter) VisitEnd() error {
+	if a.Next == nil {
+		return nil
+	}
+	return a.Next.VisitEnd()
+}
+
+// ClassFileCollector is a visitor building the ClassFile of the parts it visits,
+// like the end of a pipeline whose result is written by Write. The attributes of the
+// fields, methods and Code attributes are the ones visited. Instructions are ignored.
+type ClassFileCollector struct {
+	ClassFile *ClassFile
+	// code is the Code attribute last visited.
+	code *CodeAttribute
+}
+
+func (c *ClassFileCollector) VisitHeader(header *ClassFile) error {
+	classFile := *header
+	c.ClassFile = &classFile
+	return nil
+}
+
+func (c *ClassFileCollector) VisitField(f FieldInfo) error {
+	f.Attributes = make([]Attribute, 0, len(f.Attributes))
+	c.ClassFile.Fields = append(c.ClassFile.Fields, f)
+	return nil
+}
+
+func (c *ClassFileCollector) VisitMethod(m MethodInfo) error {
+	m.Attributes = make([]Attribute, 0, len(m.Attributes))
+	c.ClassFile.Methods = append(c.ClassFile.Methods, m)
+	c.code = nil
+	return nil
+}
+
+func (c *ClassFileCollector) VisitInstruction(inst Instruction) error {
+	return nil
+}
+
+func (c *ClassFileCollector) VisitAttribute(owner AttributeOwner, a Attribute) error {
+	class := c.ClassFile
+	switch owner {
+	case OwnerClass:
+		class.Attributes = append(class.Attributes, a)
+	case OwnerField:
+		if n := len(class.Fields); n > 0 {
+			class.Fields[n-1].Attributes = append(class.Fields[n-1].Attributes, a)
+		}
+	case OwnerMethod:
+		n := len(class.Methods)
+		if n == 0 {
+			return nil
+		}
+		if code, ok := a.(*CodeAttribute); ok {
+			// The attributes of the code are collected into a copy.
+			collected := *code
+			collected.Attributes = make([]Attribute, 0, len(code.Attributes))
+			c.code = &collected
+			a = c.code
+		}
+		class.Methods[n-1].Attributes = append(class.Methods[n-1].Attributes, a)
+	case OwnerCode:
+		if c.code != nil {
+			c.code.Attributes = append(c.code.Attributes, a)
+		}
+	}
+	return nil
+}
+
+func (c *ClassFileCollector) VisitEnd() error {
+	return nil
+}
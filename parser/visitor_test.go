@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recorder records the calls of a visit.
+type recorder struct {
+	pool  ConstantPool
+	calls []string
+}
+
+func (r *recorder) VisitHeader(c *ClassFile) error {
+	r.pool = c.ConstantPool
+	r.calls = append(r.calls, fmt.Sprintf("header %s %d %d %d", c.ConstantPool.GetClass(c.ThisClass), len(c.Fields), len(c.Methods), len(c.Attributes)))
+	return nil
+}
+
+func (r *recorder) VisitField(f FieldInfo) error {
+	r.calls = append(r.calls, "field "+r.pool.GetUTF8(f.NameIndex))
+	return nil
+}
+
+func (r *recorder) VisitMethod(m MethodInfo) error {
+	r.calls = append(r.calls, "method "+r.pool.GetUTF8(m.NameIndex))
+	return nil
+}
+
+func (r *recorder) VisitInstruction(inst Instruction) error {
+	r.calls = append(r.calls, fmt.Sprintf("instruction %d %s", inst.Offset, inst.Opcode))
+	return nil
+}
+
+func (r *recorder) VisitAttribute(owner AttributeOwner, a Attribute) error {
+	owners := []string{"class", "field", "method", "code"}
+	r.calls = append(r.calls, "attribute "+owners[owner]+" "+r.pool.AttributeName(a))
+	return nil
+}
+
+func (r *recorder) VisitEnd() error {
+	r.calls = append(r.calls, "end")
+	return nil
+}
+
+// fieldRemover drops the field name and passes on the rest.
+type fieldRemover struct {
+	ClassAdapter
+	pool ConstantPool
+	name string
+}
+
+func (r *fieldRemover) VisitHeader(c *ClassFile) error {
+	r.pool = c.ConstantPool
+	return r.ClassAdapter.VisitHeader(c)
+}
+
+func (r *fieldRemover) VisitField(f FieldInfo) error {
+	if r.pool.GetUTF8(f.NameIndex) == r.name {
+		return SkipMember
+	}
+	return r.ClassAdapter.VisitField(f)
+}
+
+func TestAccept(t *testing.T) {
+	data := skipClass(t)
+	want := []string{
+		"header com/acme/Foo 0 0 0",
+		"field MAX",
+		"attribute field ConstantValue",
+		"method run",
+		"attribute method Code",
+		"instruction 0 return",
+		"attribute method Exceptions",
+		"attribute class SourceFile",
+		"end",
+	}
+	r := &recorder{}
+	if err := Accept(bytes.NewReader(data), r); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("Accept() calls = %q, want %q", r.calls, want)
+	}
+
+	c, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = &recorder{}
+	if err := c.Accept(r); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("ClassFile.Accept() calls = %q, want %q", r.calls, want)
+	}
+
+	r = &recorder{}
+	if err := AcceptWithOptions(bytes.NewReader(data), r, ParseOptions{SkipMembers: true}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{want[0], want[7], want[8]}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("AcceptWithOptions(SkipMembers) calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestAccept_Pipeline(t *testing.T) {
+	data := skipClass(t)
+	collector := &ClassFileCollector{}
+	if err := Accept(bytes.NewReader(data), &fieldRemover{ClassAdapter: ClassAdapter{collector}, name: "MAX"}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, collector.ClassFile); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Fields) != 0 || len(c.Methods) != 1 || c.SourceFile() != "Foo.java" {
+		t.Errorf("transformed class has %d fields, %d methods and source file %q, want 0, 1 and Foo.java", len(c.Fields), len(c.Methods), c.SourceFile())
+	}
+
+	// Without a change, the pipeline writes the class file back as it is.
+	collector = &ClassFileCollector{}
+	if err := Accept(bytes.NewReader(data), ClassAdapter{collector}); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := Write(&buf, collector.ClassFile); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("ClassFileCollector wrote a different class file")
+	}
+}
+
+func TestAccept_UndecodableCode(t *testing.T) {
+	// sipush without its operand.
+	b := NewClassBuilder("com/acme/Foo")
+	data, err := b.Super("java/lang/Object").
+		AddMethod(MethodAccessPublic, "run", "()V", b.Code(1, 1, []byte{0x11})).
+		Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"header com/acme/Foo 0 0 0", "method run", "attribute method Code", "end"}
+	var warnings []*ParseError
+	r := &recorder{}
+	if err := AcceptWithOptions(bytes.NewReader(data), r, ParseOptions{Warn: func(err *ParseError) {
+		warnings = append(warnings, err)
+	}}); err != nil {
+		t.Fatalf("AcceptWithOptions() error = %v", err)
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("AcceptWithOptions() calls = %q, want %q", r.calls, want)
+	}
+	if len(warnings) != 1 || warnings[0].Path != "method[0].attribute[0]" {
+		t.Errorf("Warn called with %v, want the Code attribute", warnings)
+	}
+	var parseErr *ParseError
+	if err := AcceptWithOptions(bytes.NewReader(data), &recorder{}, ParseOptions{Strict: true}); !errors.As(err, &parseErr) {
+		t.Errorf("AcceptWithOptions(Strict) error = %v, want *ParseError", err)
+	}
+
+	collector := &ClassFileCollector{}
+	if err := Accept(bytes.NewReader(data), collector); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, collector.ClassFile); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("ClassFileCollector wrote a different class file")
+	}
+}
+
+// attributeRemover drops the attributes of owner named name and passes on the rest.
+type attributeRemover struct {
+	ClassAdapter
+	pool  ConstantPool
+	owner AttributeOwner
+	name  string
+}
+
+func (r *attributeRemover) VisitHeader(c *ClassFile) error {
+	r.pool = c.ConstantPool
+	return r.ClassAdapter.VisitHeader(c)
+}
+
+func (r *attributeRemover) VisitAttribute(owner AttributeOwner, a Attribute) error {
+	if owner == r.owner && r.pool.AttributeName(a) == r.name {
+		return nil
+	}
+	return r.ClassAdapter.VisitAttribute(owner, a)
+}
+
+func TestAccept_MemberAttributes(t *testing.T) {
+	b := NewClassBuilder("com/acme/Foo")
+	lines := &LineNumberTableAttribute{AttributeHeader{b.Pool().Utf8("LineNumberTable")}, []LineNumberTableEntry{{0, 1}}}
+	data, err := b.Super("java/lang/Object").
+		AddField(FieldAccessStatic|FieldAccessFinal, "MAX", "I", b.ConstantValue(int32(10))).
+		AddMethod(MethodAccessPublic, "run", "()V", b.Code(0, 1, []byte{0xB1}, lines), b.Exceptions("java/io/IOException")).
+		Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		owner AttributeOwner
+		name  string
+		check func(c *ClassFile) bool
+	}{
+		{OwnerField, "ConstantValue", func(c *ClassFile) bool { return len(c.Fields[0].Attributes) == 0 }},
+		{OwnerMethod, "Exceptions", func(c *ClassFile) bool { return len(c.Methods[0].Attributes) == 1 && c.Methods[0].Code() != nil }},
+		{OwnerCode, "LineNumberTable", func(c *ClassFile) bool { return len(c.Methods[0].Code().Attributes) == 0 }},
+	}
+	for _, tt := range tests {
+		collector := &ClassFileCollector{}
+		if err := Accept(bytes.NewReader(data), &attributeRemover{ClassAdapter: ClassAdapter{collector}, owner: tt.owner, name: tt.name}); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Write(&buf, collector.ClassFile); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tt.check(c) {
+			t.Errorf("attribute %s of owner %d was not removed", tt.name, tt.owner)
+		}
+	}
+	// The class file read still has the attributes the collector copied.
+	c, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &ClassFileCollector{}
+	if err := c.Accept(&attributeRemover{ClassAdapter: ClassAdapter{collector}, owner: OwnerCode, name: "LineNumberTable"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Methods[0].Code().Attributes) != 1 || len(collector.ClassFile.Methods[0].Code().Attributes) != 0 {
+		t.Error("ClassFileCollector changed the Code attribute of the class file visited")
+	}
+}
+
+func TestAccept_Error(t *testing.T) {
+	data := skipClass(t)
+	stop := errors.New("stop")
+	r := &recorder{}
+	err := Accept(bytes.NewReader(data), &stopper{ClassAdapter{r}, stop})
+	if err != stop {
+		t.Errorf("Accept() error = %v, want %v", err, stop)
+	}
+	if want := []string{"header com/acme/Foo 0 0 0"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("Accept() calls = %q after the error, want %q", r.calls, want)
+	}
+
+	var parseErr *ParseError
+	if err := Accept(bytes.NewReader(data[:len(data)-1]), &recorder{}); !errors.As(err, &parseErr) {
+		t.Errorf("Accept(truncated) error = %v, want *ParseError", err)
+	}
+}
+
+// stopper fails at the first field.
+type stopper struct {
+	ClassAdapter
+	err error
+}
+
+func (s *stopper) VisitField(f FieldInfo) error {
+	return s.err
+}
@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Write serializes the class file. Reading a class file and writing it back
+// produces the same bytes, as long as every attribute can be encoded.
+// Typed attributes of registered decoders must implement encoding.BinaryMarshaler.
+func Write(w io.Writer, c *ClassFile) error {
+	b := &attributeWriter{}
+	b.u4(0xCAFEBABE)
+	b.u2(c.MinorVersion)
+	b.u2(c.MajorVersion)
+	b.constantPool(c.ConstantPool)
+	b.u2(uint16(c.AccessFlags))
+	b.u2(c.ThisClass)
+	b.u2(c.SuperClass)
+	b.u2s(c.Interfaces)
+	b.count(len(c.Fields), "fields")
+	for _, f := range c.Fields {
+		b.u2(uint16(f.AccessFlags))
+		b.u2(f.NameIndex)
+		b.u2(f.DescriptorIndex)
+		b.attributes(f.Attributes)
+	}
+	b.count(len(c.Methods), "methods")
+	for _, m := range c.Methods {
+		b.u2(uint16(m.AccessFlags))
+		b.u2(m.NameIndex)
+		b.u2(m.DescriptorIndex)
+		b.attributes(m.Attributes)
+	}
+	b.attributes(c.Attributes)
+	data, err := b.finish()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// attributeWriter is the inverse of attributeReader. It is used for the whole class file as well.
+// The first error is kept and returned by finish.
+type attributeWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (w *attributeWriter) fail(format string, args ...interface{}) {
+	if w.err == nil {
+		w.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (w *attributeWriter) u1(v uint8) {
+	w.buf.WriteByte(v)
+}
+
+func (w *attributeWriter) u2(v uint16) {
+	w.buf.Write([]byte{byte(v >> 8), byte(v)})
+}
+
+func (w *attributeWriter) u4(v uint32) {
+	w.buf.Write([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+func (w *attributeWriter) u8(v uint64) {
+	w.u4(uint32(v >> 32))
+	w.u4(uint32(v))
+}
+
+func (w *attributeWriter) bytes(b []byte) {
+	w.buf.Write(b)
+}
+
+// count writes a u2 count of a table.
+func (w *attributeWriter) count(n int, name string) {
+	if n > math.MaxUint16 {
+		w.fail("too many %s: %d", name, n)
+	}
+	w.u2(uint16(n))
+}
+
+// count1 writes a u1 count of a table.
+func (w *attributeWriter) count1(n int, name string) {
+	if n > math.MaxUint8 {
+		w.fail("too many %s: %d", name, n)
+	}
+	w.u1(uint8(n))
+}
+
+// u2s writes a u2 length followed by the values.
+func (w *attributeWriter) u2s(values []uint16) {
+	w.count(len(values), "entries")
+	for _, v := range values {
+		w.u2(v)
+	}
+}
+
+func (w *attributeWriter) attributes(attributes []Attribute) {
+	w.count(len(attributes), "attributes")
+	for _, a := range attributes {
+		info, err := encodeAttribute(a)
+		if err != nil {
+			w.fail("%v", err)
+			return
+		}
+		if uint64(len(info)) > math.MaxUint32 {
+			w.fail("attribute too long: %d bytes", len(info))
+			return
+		}
+		w.u2(a.AttributeNameIndex())
+		w.u4(uint32(len(info)))
+		w.bytes(info)
+	}
+}
+
+func (w *attributeWriter) constantPool(pool ConstantPool) {
+	w.count(len(pool)+1, "constant pool entries")
+	for i := 0; i < len(pool); i++ {
+		switch info := pool[i].(type) {
+		case ConstantUtf8Info:
+			w.u1(ConstantUtf8)
+			w.count(len(info.Bytes), "bytes in Utf8 constant")
+			w.bytes(info.Bytes)
+		case ConstantIntegerInfo:
+			w.u1(ConstantInteger)
+			w.u4(uint32(info.Value))
+		case ConstantFloatInfo:
+			w.u1(ConstantFloat)
+			w.u4(math.Float32bits(info.Value))
+		case ConstantLongInfo:
+			w.unusable(pool, i+1)
+			w.u1(ConstantLong)
+			w.u8(uint64(info.Value))
+			i++
+		case ConstantDoubleInfo:
+			w.unusable(pool, i+1)
+			w.u1(ConstantDouble)
+			w.u8(math.Float64bits(info.Value))
+			i++
+		case ConstantClassInfo:
+			w.u1(ConstantClass)
+			w.u2(info.NameIndex)
+		case ConstantStringInfo:
+			w.u1(ConstantString)
+			w.u2(info.StringIndex)
+		case ConstantFieldrefInfo:
+			w.u1(ConstantFieldref)
+			w.u2(info.ClassIndex)
+			w.u2(info.NameAndTypeIndex)
+		case ConstantMethodrefInfo:
+			w.u1(ConstantMethodref)
+			w.u2(info.ClassIndex)
+			w.u2(info.NameAndTypeIndex)
+		case ConstantInterfaceMethodrefInfo:
+			w.u1(ConstantInterfaceMethodref)
+			w.u2(info.ClassIndex)
+			w.u2(info.NameAndTypeIndex)
+		case ConstantNameAndTypeInfo:
+			w.u1(ConstantNameAndType)
+			w.u2(info.NameIndex)
+			w.u2(info.DescriptorIndex)
+		case ConstantMethodHandleInfo:
+			w.u1(ConstantMethodHandle)
+			w.u1(uint8(info.ReferenceKind))
+			w.u2(info.ReferenceIndex)
+		case ConstantMethodTypeInfo:
+			w.u1(ConstantMethodType)
+			w.u2(info.DescriptorIndex)
+		case ConstantDynamicInfo:
+			w.u1(ConstantDynamic)
+			w.u2(info.BootstrapMethodAttrIndex)
+			w.u2(info.NameAndTypeIndex)
+		case ConstantInvokeDynamicInfo:
+			w.u1(ConstantInvokeDynamic)
+			w.u2(info.BootstrapMethodAttrIndex)
+			w.u2(info.NameAndTypeIndex)
+		case ConstantModuleInfo:
+			w.u1(ConstantModule)
+			w.u2(info.NameIndex)
+		case ConstantPackageInfo:
+			w.u1(ConstantPackage)
+			w.u2(info.NameIndex)
+		default:
+			w.fail("cannot write constant pool entry #%d of type %T", i+1, info)
+		}
+	}
+}
+
+// unusable checks that the constant after a Long or Double constant at i-1 is ConstantUnusableInfo.
+func (w *attributeWriter) unusable(pool ConstantPool, i int) {
+	if i >= len(pool) {
+		w.fail("constant pool ends before the second slot of entry #%d", i)
+	} else if _, ok := pool[i].(ConstantUnusableInfo); !ok {
+		w.fail("constant pool entry #%d is %T, want ConstantUnusableInfo after a Long or Double constant", i+1, pool[i])
+	}
+}
+
+func (w *attributeWriter) finish() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return w.buf.Bytes(), nil
+}
+
+func encodeAttribute(a Attribute) ([]byte, error) {
+	m, ok := a.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("attribute %T cannot be encoded", a)
+	}
+	return m.MarshalBinary()
+}
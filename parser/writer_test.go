@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite_RoundTrip(t *testing.T) {
+	var b bytes.Buffer
+	c, err := Read(bytes.NewReader(minimalClass))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), minimalClass) {
+		t.Errorf("Write() = %x, want %x", b.Bytes(), minimalClass)
+	}
+}
+
+func TestWrite_Constants(t *testing.T) {
+	nan := math.Float32frombits(0x7FC00001)
+	c := &ClassFile{
+		MajorVersion: 52,
+		ConstantPool: ConstantPool{
+			ConstantUtf8Info{[]byte("A")},
+			ConstantClassInfo{1},
+			ConstantLongInfo{-2},
+			ConstantUnusableInfo{},
+			ConstantDoubleInfo{math.Inf(-1)},
+			ConstantUnusableInfo{},
+			ConstantFloatInfo{nan},
+			ConstantUtf8Info{[]byte("Code")},
+			ConstantMethodHandleInfo{MethodHandleRefInvokeStatic, 2},
+		},
+		ThisClass: 2,
+		Methods: []MethodInfo{
+			{MethodAccessStatic, 1, 1, []Attribute{
+				&CodeAttribute{AttributeHeader{8}, 2, 0, []byte{0x14, 0x00, 0x03, 0xB1}, []ExceptionTableEntry{{0, 3, 3, 0}}, []Attribute{}},
+			}},
+		},
+		Attributes: []Attribute{&AttributeInfo{1, []byte{1, 2, 3}}},
+	}
+	var first bytes.Buffer
+	if err := Write(&first, c); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.ConstantPool) != len(c.ConstantPool) {
+		t.Fatalf("constant pool has %d entries, want %d", len(read.ConstantPool), len(c.ConstantPool))
+	}
+	if f := read.ConstantPool[6].(ConstantFloatInfo).Value; math.Float32bits(f) != 0x7FC00001 {
+		t.Errorf("NaN payload = %08x, want 7fc00001", math.Float32bits(f))
+	}
+	var second bytes.Buffer
+	if err := Write(&second, read); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("second Write() = %x, want %x", second.Bytes(), first.Bytes())
+	}
+}
+
+func TestWrite_UnencodableAttribute(t *testing.T) {
+	c := &ClassFile{Attributes: []Attribute{&vendorAttribute{}}}
+	if err := Write(&bytes.Buffer{}, c); err == nil {
+		t.Error("Write() succeeded with an attribute without MarshalBinary")
+	}
+}
+
+func TestWrite_MissingUnusableSlot(t *testing.T) {
+	pools := []ConstantPool{
+		{ConstantLongInfo{1}, ConstantUtf8Info{[]byte("A")}},
+		{ConstantDoubleInfo{1}},
+		{ConstantUnusableInfo{}},
+	}
+	for _, pool := range pools {
+		if err := Write(&bytes.Buffer{}, &ClassFile{ConstantPool: pool}); err == nil {
+			t.Errorf("Write(%v) succeeded", pool)
+		}
+	}
+}
+
+func TestAttribute_MarshalBinary(t *testing.T) {
+	names := []string{
+		"ConstantValue", "Exceptions", "InnerClasses", "EnclosingMethod", "Synthetic", "Signature",
+		"SourceDebugExtension", "BootstrapMethods", "NestMembers", "Record", "MethodParameters",
+		"Code", "LineNumberTable", "LocalVariableTable", "StackMapTable", "Module",
+		"RuntimeVisibleAnnotations", "RuntimeInvisibleParameterAnnotations",
+		"RuntimeVisibleTypeAnnotations", "AnnotationDefault",
+	}
+	pool := make(ConstantPool, len(names))
+	index := map[string]uint16{}
+	for i, name := range names {
+		pool[i] = ConstantUtf8Info{[]byte(name)}
+		index[name] = uint16(i + 1)
+	}
+	tests := []struct {
+		name string
+		info []byte
+	}{
+		{"ConstantValue", []byte{0x00, 0x05}},
+		{"Exceptions", []byte{0x00, 0x02, 0x00, 0x01, 0x00, 0x02}},
+		{"InnerClasses", []byte{0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x09}},
+		{"EnclosingMethod", []byte{0x00, 0x01, 0x00, 0x00}},
+		{"Synthetic", []byte{}},
+		{"Signature", []byte{0x00, 0x03}},
+		{"SourceDebugExtension", []byte("SMAP\n")},
+		{"BootstrapMethods", []byte{0x00, 0x01, 0x00, 0x04, 0x00, 0x02, 0x00, 0x05, 0x00, 0x06}},
+		{"NestMembers", []byte{0x00, 0x01, 0x00, 0x07}},
+		{"Record", []byte{0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00, 0x00, 0x02, 0x00, 0x03}},
+		{"MethodParameters", []byte{0x02, 0x00, 0x01, 0x00, 0x10, 0x00, 0x00, 0x80, 0x00}},
+		{"Code", []byte{
+			0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0xB1,
+			0x00, 0x00,
+			0x00, 0x01, 0x00, 0x0D, 0x00, 0x00, 0x00, 0x06, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03,
+		}},
+		{"LocalVariableTable", []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00}},
+		{"StackMapTable", []byte{
+			0x00, 0x06,
+			0x03,
+			0x41, 0x07, 0x00, 0x02,
+			0xF7, 0x00, 0x10, 0x08, 0x00, 0x04,
+			0xF9, 0x00, 0x02,
+			0xFD, 0x00, 0x03, 0x01, 0x04,
+			0xFF, 0x00, 0x04, 0x00, 0x01, 0x06, 0x00, 0x01, 0x00,
+		}},
+		{"Module", []byte{
+			0x00, 0x01, 0x00, 0x20, 0x00, 0x00,
+			0x00, 0x01, 0x00, 0x02, 0x80, 0x00, 0x00, 0x00,
+			0x00, 0x01, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x04,
+			0x00, 0x00,
+			0x00, 0x01, 0x00, 0x05,
+			0x00, 0x01, 0x00, 0x05, 0x00, 0x01, 0x00, 0x06,
+		}},
+		{"RuntimeVisibleAnnotations", []byte{
+			0x00, 0x01, 0x00, 0x01, 0x00, 0x03,
+			0x00, 0x02, 's', 0x00, 0x03,
+			0x00, 0x03, '[', 0x00, 0x02, 'e', 0x00, 0x04, 0x00, 0x05, 'c', 0x00, 0x06,
+			0x00, 0x04, '@', 0x00, 0x07, 0x00, 0x00,
+		}},
+		{"RuntimeInvisibleParameterAnnotations", []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00}},
+		{"RuntimeVisibleTypeAnnotations", []byte{
+			0x00, 0x03,
+			0x13, 0x00, 0x00, 0x01, 0x00, 0x00,
+			0x40, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x00, 0x01, 0x01, 0x03, 0x00, 0x00, 0x01, 0x00, 0x00,
+			0x47, 0x00, 0x08, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00,
+		}},
+		{"AnnotationDefault", []byte{'I', 0x00, 0x05}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := decodeAttribute(index[tt.name], tt.info, pool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := a.(*AttributeInfo); ok {
+				t.Fatalf("%s was not decoded", tt.name)
+			}
+			got, err := encodeAttribute(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.info) {
+				t.Errorf("MarshalBinary() = %x, want %x", got, tt.info)
+			}
+			if again, err := decodeAttribute(index[tt.name], got, pool); err != nil || !reflect.DeepEqual(again, a) {
+				t.Errorf("decoding the encoded attribute = %#v, %v, want %#v", again, err, a)
+			}
+		})
+	}
+}
+
+func TestWrite_RoundTripJavac(t *testing.T) {
+	// The class files in testdata are compiled by javac for Java 6 to 17.
+	paths, err := filepath.Glob(filepath.Join("..", "testdata", "*.class"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no class files in testdata: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err := Write(&b, c); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b.Bytes(), data) {
+				t.Errorf("Write() differs from the class file at offset %d", mismatch(b.Bytes(), data))
+			}
+		})
+	}
+}
+
+func TestWrite_RoundTripJars(t *testing.T) {
+	// GO_JAVAP_JARS is a list of jars like the ones of a JDK, separated like PATH.
+	jars := filepath.SplitList(os.Getenv("GO_JAVAP_JARS"))
+	if len(jars) == 0 {
+		t.Skip("GO_JAVAP_JARS is not set")
+	}
+	for _, jar := range jars {
+		z, err := zip.OpenReader(jar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer z.Close()
+		for _, f := range z.File {
+			if !strings.HasSuffix(f.Name, ".class") {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := Read(bytes.NewReader(data))
+			if err != nil {
+				t.Errorf("%s!/%s: %v", jar, f.Name, err)
+				continue
+			}
+			var b bytes.Buffer
+			if err := Write(&b, c); err != nil || !bytes.Equal(b.Bytes(), data) {
+				t.Errorf("%s!/%s: Write() differs at offset %d, error = %v", jar, f.Name, mismatch(b.Bytes(), data), err)
+			}
+		}
+	}
+}
+
+// mismatch returns the offset of the first byte which differs between a and b.
+func mismatch(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
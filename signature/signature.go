@@ -0,0 +1,520 @@
+// Package signature parses and renders generic signatures (JVMS 4.7.9.1).
+package signature
+
+import (
+	"fmt"
+	"strings"
+
+	"go-javap/descriptor"
+)
+
+type (
+	// Type is a Java type signature.
+	Type interface {
+		// Java renders the type as Java source.
+		// If short is true, package names are omitted as if the classes were imported.
+		Java(short bool) string
+		// Signature encodes the type back to its signature form.
+		Signature() string
+	}
+
+	BaseType struct {
+		Kind descriptor.Kind
+	}
+
+	ClassType struct {
+		// Package is the package specifier like java/util/, empty for the default package.
+		Package string
+		// Classes is the outermost class followed by its inner classes.
+		Classes []SimpleClassType
+	}
+
+	SimpleClassType struct {
+		Name          string
+		TypeArguments []TypeArgument
+	}
+
+	TypeArgument struct {
+		Wildcard Wildcard
+		// Type is nil for the unbounded wildcard.
+		Type Type
+	}
+
+	Wildcard byte
+
+	TypeVariable struct {
+		Name string
+	}
+
+	ArrayType struct {
+		Elem Type
+	}
+
+	TypeParameter struct {
+		Name string
+		// ClassBound is nil when the parameter is only bounded by interfaces.
+		ClassBound      Type
+		InterfaceBounds []Type
+	}
+
+	ClassSignature struct {
+		TypeParameters []TypeParameter
+		Superclass     *ClassType
+		Interfaces     []*ClassType
+	}
+
+	MethodSignature struct {
+		TypeParameters []TypeParameter
+		Parameters     []Type
+		// Result is a BaseType of descriptor.Void for void methods.
+		Result Type
+		Throws []Type
+	}
+)
+
+const (
+	WildcardNone    Wildcard = 0
+	WildcardAny     Wildcard = '*'
+	WildcardExtends Wildcard = '+'
+	WildcardSuper   Wildcard = '-'
+)
+
+// ParseClass parses a class signature like <T:Ljava/lang/Object;>Ljava/util/AbstractList<TT;>;.
+func ParseClass(s string) (*ClassSignature, error) {
+	p := parser{s: s}
+	c := &ClassSignature{TypeParameters: p.typeParameters()}
+	c.Superclass = p.classType()
+	for p.err == nil && p.pos < len(s) {
+		c.Interfaces = append(c.Interfaces, p.classType())
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return c, nil
+}
+
+// ParseMethod parses a method signature like <T:Ljava/lang/Object;>(Ljava/util/List<TT;>;)TT;.
+func ParseMethod(s string) (*MethodSignature, error) {
+	p := parser{s: s}
+	m := &MethodSignature{TypeParameters: p.typeParameters(), Parameters: make([]Type, 0)}
+	if !p.consume('(') {
+		p.fail("expected '('")
+	}
+	for p.err == nil && !p.consume(')') {
+		m.Parameters = append(m.Parameters, p.javaType())
+	}
+	if p.err == nil {
+		if p.consume(byte(descriptor.Void)) {
+			m.Result = BaseType{descriptor.Void}
+		} else {
+			m.Result = p.javaType()
+		}
+	}
+	for p.err == nil && p.consume('^') {
+		switch p.peek() {
+		case 'L':
+			m.Throws = append(m.Throws, p.classType())
+		case 'T':
+			m.Throws = append(m.Throws, p.typeVariable())
+		default:
+			p.fail("expected class type or type variable")
+		}
+	}
+	p.end()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return m, nil
+}
+
+// ParseField parses a field signature, which is a reference type signature like Ljava/util/List<TT;>;.
+func ParseField(s string) (Type, error) {
+	p := parser{s: s}
+	t := p.referenceType()
+	p.end()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return t, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid signature %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.err != nil || p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.err == nil && p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(c byte) {
+	if !p.consume(c) {
+		if p.pos == len(p.s) {
+			p.fail("expected %q but got end of signature", c)
+		} else {
+			p.fail("expected %q", c)
+		}
+	}
+}
+
+func (p *parser) end() {
+	if p.err == nil && p.pos != len(p.s) {
+		p.fail("unexpected trailing characters")
+	}
+}
+
+func (p *parser) identifier() string {
+	if p.err != nil {
+		return ""
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(".;[/<>:", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.fail("expected identifier")
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) typeParameters() []TypeParameter {
+	if !p.consume('<') {
+		return nil
+	}
+	params := make([]TypeParameter, 0)
+	for p.err == nil && !p.consume('>') {
+		param := TypeParameter{Name: p.identifier()}
+		p.expect(':')
+		switch p.peek() {
+		case 'L', 'T', '[':
+			param.ClassBound = p.referenceType()
+		}
+		for p.consume(':') {
+			param.InterfaceBounds = append(param.InterfaceBounds, p.referenceType())
+		}
+		params = append(params, param)
+	}
+	if p.err == nil && len(params) == 0 {
+		p.fail("empty type parameters")
+	}
+	return params
+}
+
+func (p *parser) referenceType() Type {
+	switch p.peek() {
+	case 'L':
+		return p.classType()
+	case 'T':
+		return p.typeVariable()
+	case '[':
+		return p.arrayType()
+	}
+	p.fail("expected reference type")
+	return nil
+}
+
+func (p *parser) javaType() Type {
+	switch c := descriptor.Kind(p.peek()); c {
+	case descriptor.Byte, descriptor.Char, descriptor.Double, descriptor.Float,
+		descriptor.Int, descriptor.Long, descriptor.Short, descriptor.Boolean:
+		p.pos++
+		return BaseType{c}
+	}
+	return p.referenceType()
+}
+
+func (p *parser) classType() *ClassType {
+	t := &ClassType{}
+	p.expect('L')
+	name := p.identifier()
+	for p.consume('/') {
+		t.Package += name + "/"
+		name = p.identifier()
+	}
+	t.Classes = []SimpleClassType{{name, p.typeArguments()}}
+	for p.consume('.') {
+		t.Classes = append(t.Classes, SimpleClassType{p.identifier(), p.typeArguments()})
+	}
+	p.expect(';')
+	return t
+}
+
+func (p *parser) typeArguments() []TypeArgument {
+	if !p.consume('<') {
+		return nil
+	}
+	args := make([]TypeArgument, 0)
+	for p.err == nil && !p.consume('>') {
+		var arg TypeArgument
+		switch w := Wildcard(p.peek()); w {
+		case WildcardAny:
+			p.pos++
+			arg.Wildcard = w
+		case WildcardExtends, WildcardSuper:
+			p.pos++
+			arg.Wildcard = w
+			arg.Type = p.referenceType()
+		default:
+			arg.Type = p.referenceType()
+		}
+		args = append(args, arg)
+	}
+	if p.err == nil && len(args) == 0 {
+		p.fail("empty type arguments")
+	}
+	return args
+}
+
+func (p *parser) typeVariable() TypeVariable {
+	p.expect('T')
+	t := TypeVariable{p.identifier()}
+	p.expect(';')
+	return t
+}
+
+func (p *parser) arrayType() ArrayType {
+	p.expect('[')
+	return ArrayType{p.javaType()}
+}
+
+func (t BaseType) Java(short bool) string {
+	return descriptor.Type{Kind: t.Kind}.Java(short)
+}
+
+func (t BaseType) Signature() string {
+	return string(t.Kind)
+}
+
+// Name returns the internal binary name of the class like java/util/Map$Entry.
+func (t *ClassType) Name() string {
+	names := make([]string, len(t.Classes))
+	for i, c := range t.Classes {
+		names[i] = c.Name
+	}
+	return t.Package + strings.Join(names, "$")
+}
+
+// IsObject reports whether the type is java.lang.Object.
+func (t *ClassType) IsObject() bool {
+	return t.Name() == "java/lang/Object" && len(t.Classes[0].TypeArguments) == 0
+}
+
+func (t *ClassType) Java(short bool) string {
+	classes := make([]string, len(t.Classes))
+	for i, c := range t.Classes {
+		classes[i] = c.Name + typeArgumentsJava(c.TypeArguments, short)
+	}
+	s := strings.Join(classes, ".")
+	if short {
+		return s
+	}
+	return descriptor.JavaName(t.Package) + s
+}
+
+func (t *ClassType) Signature() string {
+	var b strings.Builder
+	b.WriteString("L")
+	b.WriteString(t.Package)
+	for i, c := range t.Classes {
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(c.Name)
+		if len(c.TypeArguments) > 0 {
+			b.WriteString("<")
+			for _, a := range c.TypeArguments {
+				if a.Wildcard != WildcardNone {
+					b.WriteByte(byte(a.Wildcard))
+				}
+				if a.Type != nil {
+					b.WriteString(a.Type.Signature())
+				}
+			}
+			b.WriteString(">")
+		}
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+func (a TypeArgument) Java(short bool) string {
+	switch a.Wildcard {
+	case WildcardAny:
+		return "?"
+	case WildcardExtends:
+		return "? extends " + a.Type.Java(short)
+	case WildcardSuper:
+		return "? super " + a.Type.Java(short)
+	}
+	return a.Type.Java(short)
+}
+
+func typeArgumentsJava(args []TypeArgument, short bool) string {
+	if len(args) == 0 {
+		return ""
+	}
+	s := make([]string, len(args))
+	for i, a := range args {
+		s[i] = a.Java(short)
+	}
+	return "<" + strings.Join(s, ", ") + ">"
+}
+
+func (t TypeVariable) Java(short bool) string {
+	return t.Name
+}
+
+func (t TypeVariable) Signature() string {
+	return "T" + t.Name + ";"
+}
+
+func (t ArrayType) Java(short bool) string {
+	return t.Elem.Java(short) + "[]"
+}
+
+func (t ArrayType) Signature() string {
+	return "[" + t.Elem.Signature()
+}
+
+// Java renders the type parameter. A bound of java.lang.Object is omitted like javap does.
+func (t TypeParameter) Java(short bool) string {
+	bounds := make([]string, 0, len(t.InterfaceBounds)+1)
+	if c, ok := t.ClassBound.(*ClassType); t.ClassBound != nil && !(ok && c.IsObject()) {
+		bounds = append(bounds, t.ClassBound.Java(short))
+	}
+	for _, b := range t.InterfaceBounds {
+		bounds = append(bounds, b.Java(short))
+	}
+	if len(bounds) == 0 {
+		return t.Name
+	}
+	return t.Name + " extends " + strings.Join(bounds, " & ")
+}
+
+func (t TypeParameter) Signature() string {
+	s := t.Name + ":"
+	if t.ClassBound != nil {
+		s += t.ClassBound.Signature()
+	}
+	for _, b := range t.InterfaceBounds {
+		s += ":" + b.Signature()
+	}
+	return s
+}
+
+// TypeParametersJava renders type parameters like <K, V extends java.lang.Comparable<V>>.
+// It returns an empty string if there are none.
+func TypeParametersJava(params []TypeParameter, short bool) string {
+	if len(params) == 0 {
+		return ""
+	}
+	s := make([]string, len(params))
+	for i, p := range params {
+		s[i] = p.Java(short)
+	}
+	return "<" + strings.Join(s, ", ") + ">"
+}
+
+func typeParametersSignature(params []TypeParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<")
+	for _, p := range params {
+		b.WriteString(p.Signature())
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// Java renders the class signature as the generic part of a class declaration,
+// like <T> extends java.util.AbstractList<T> implements java.util.List<T>.
+// A superclass of java.lang.Object is omitted.
+func (c *ClassSignature) Java(short bool) string {
+	s := TypeParametersJava(c.TypeParameters, short)
+	if c.Superclass != nil && !c.Superclass.IsObject() {
+		s += " extends " + c.Superclass.Java(short)
+	}
+	if len(c.Interfaces) > 0 {
+		interfaces := make([]string, len(c.Interfaces))
+		for i, intf := range c.Interfaces {
+			interfaces[i] = intf.Java(short)
+		}
+		s += " implements " + strings.Join(interfaces, ", ")
+	}
+	return strings.TrimPrefix(s, " ")
+}
+
+func (c *ClassSignature) Signature() string {
+	s := typeParametersSignature(c.TypeParameters) + c.Superclass.Signature()
+	for _, intf := range c.Interfaces {
+		s += intf.Signature()
+	}
+	return s
+}
+
+// JavaParameters renders the parameter types as Java source.
+func (m *MethodSignature) JavaParameters(short bool) []string {
+	params := make([]string, len(m.Parameters))
+	for i, p := range m.Parameters {
+		params[i] = p.Java(short)
+	}
+	return params
+}
+
+// JavaThrows renders the thrown types as Java source.
+func (m *MethodSignature) JavaThrows(short bool) []string {
+	throws := make([]string, len(m.Throws))
+	for i, t := range m.Throws {
+		throws[i] = t.Java(short)
+	}
+	return throws
+}
+
+// Java renders the method as a Java source declaration like <T> T m(java.util.List<T>) throws E.
+func (m *MethodSignature) Java(name string, short bool) string {
+	s := ""
+	if len(m.TypeParameters) > 0 {
+		s = TypeParametersJava(m.TypeParameters, short) + " "
+	}
+	s += m.Result.Java(short) + " " + name + "(" + strings.Join(m.JavaParameters(short), ", ") + ")"
+	if len(m.Throws) > 0 {
+		s += " throws " + strings.Join(m.JavaThrows(short), ", ")
+	}
+	return s
+}
+
+func (m *MethodSignature) Signature() string {
+	var b strings.Builder
+	b.WriteString(typeParametersSignature(m.TypeParameters))
+	b.WriteString("(")
+	for _, p := range m.Parameters {
+		b.WriteString(p.Signature())
+	}
+	b.WriteString(")")
+	b.WriteString(m.Result.Signature())
+	for _, t := range m.Throws {
+		b.WriteString("^")
+		b.WriteString(t.Signature())
+	}
+	return b.String()
+}
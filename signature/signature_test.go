@@ -0,0 +1,200 @@
+package signature
+
+import (
+	"testing"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		java    string
+		short   string
+		wantErr bool
+	}{
+		{
+			name:  "type variable",
+			s:     "TT;",
+			java:  "T",
+			short: "T",
+		},
+		{
+			name:  "wildcards",
+			s:     "Ljava/util/Map<TK;+Ljava/util/List<TV;>;>;",
+			java:  "java.util.Map<K, ? extends java.util.List<V>>",
+			short: "Map<K, ? extends List<V>>",
+		},
+		{
+			name:  "unbounded and super wildcards",
+			s:     "Ljava/util/Map<*-Ljava/lang/Number;>;",
+			java:  "java.util.Map<?, ? super java.lang.Number>",
+			short: "Map<?, ? super Number>",
+		},
+		{
+			name:  "inner class type arguments",
+			s:     "Lcom/acme/Outer<TT;>.Inner<Ljava/lang/String;>;",
+			java:  "com.acme.Outer<T>.Inner<java.lang.String>",
+			short: "Outer<T>.Inner<String>",
+		},
+		{
+			name:  "arrays",
+			s:     "[[Ljava/util/List<[I>;",
+			java:  "java.util.List<int[]>[][]",
+			short: "List<int[]>[][]",
+		},
+		{
+			name:  "array of type variable",
+			s:     "[TT;",
+			java:  "T[]",
+			short: "T[]",
+		},
+		{
+			name:  "default package",
+			s:     "LFoo<TT;>;",
+			java:  "Foo<T>",
+			short: "Foo<T>",
+		},
+		{name: "empty", s: "", wantErr: true},
+		{name: "base type", s: "I", wantErr: true},
+		{name: "unterminated class", s: "Ljava/util/List", wantErr: true},
+		{name: "unterminated type arguments", s: "Ljava/util/List<TT;", wantErr: true},
+		{name: "empty type arguments", s: "Ljava/util/List<>;", wantErr: true},
+		{name: "primitive type argument", s: "Ljava/util/List<I>;", wantErr: true},
+		{name: "unterminated type variable", s: "TT", wantErr: true},
+		{name: "empty type variable", s: "T;", wantErr: true},
+		{name: "empty package segment", s: "Ljava//List;", wantErr: true},
+		{name: "empty inner class", s: "Ljava/util/Map.;", wantErr: true},
+		{name: "wildcard without bound", s: "Ljava/util/List<+>;", wantErr: true},
+		{name: "trailing characters", s: "TT;TU;", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseField(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseField(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if s := got.Signature(); s != tt.s {
+				t.Errorf("Signature() = %q, want %q", s, tt.s)
+			}
+			if j := got.Java(false); j != tt.java {
+				t.Errorf("Java(false) = %q, want %q", j, tt.java)
+			}
+			if j := got.Java(true); j != tt.short {
+				t.Errorf("Java(true) = %q, want %q", j, tt.short)
+			}
+		})
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		short   string
+		wantErr bool
+	}{
+		{
+			name:  "type parameter with object bound",
+			s:     "<T:Ljava/lang/Object;>Ljava/util/AbstractList<TT;>;Ljava/util/RandomAccess;",
+			short: "<T> extends AbstractList<T> implements RandomAccess",
+		},
+		{
+			name:  "interface bounds",
+			s:     "<K::Ljava/lang/Comparable<TK;>;V:Ljava/lang/Number;:Ljava/io/Serializable;>Ljava/lang/Object;Ljava/util/Map<TK;TV;>;",
+			short: "<K extends Comparable<K>, V extends Number & Serializable> implements Map<K, V>",
+		},
+		{
+			name:  "type variable bound",
+			s:     "<T:Ljava/lang/Object;U:TT;>Ljava/lang/Object;",
+			short: "<T, U extends T>",
+		},
+		{
+			name:  "no type parameters",
+			s:     "Ljava/lang/Object;Ljava/lang/Comparable<Lcom/acme/Foo;>;",
+			short: "implements Comparable<Foo>",
+		},
+		{name: "empty", s: "", wantErr: true},
+		{name: "missing superclass", s: "<T:Ljava/lang/Object;>", wantErr: true},
+		{name: "empty type parameters", s: "<>Ljava/lang/Object;", wantErr: true},
+		{name: "missing bound separator", s: "<TLjava/lang/Object;>Ljava/lang/Object;", wantErr: true},
+		{name: "type variable superclass", s: "TT;", wantErr: true},
+		{name: "trailing characters", s: "Ljava/lang/Object;X", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseClass(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseClass(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if s := got.Signature(); s != tt.s {
+				t.Errorf("Signature() = %q, want %q", s, tt.s)
+			}
+			if j := got.Java(true); j != tt.short {
+				t.Errorf("Java(true) = %q, want %q", j, tt.short)
+			}
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		java    string
+		short   string
+		wantErr bool
+	}{
+		{
+			name:  "generic method",
+			s:     "<T:Ljava/lang/Object;>(Ljava/util/List<TT;>;I)TT;",
+			java:  "<T> T m(java.util.List<T>, int)",
+			short: "<T> T m(List<T>, int)",
+		},
+		{
+			name:  "void with throws",
+			s:     "<E:Ljava/lang/Exception;>([TE;)V^TE;^Ljava/io/IOException;",
+			java:  "<E extends java.lang.Exception> void m(E[]) throws E, java.io.IOException",
+			short: "<E extends Exception> void m(E[]) throws E, IOException",
+		},
+		{
+			name:  "no type parameters",
+			s:     "()Ljava/util/Map<Ljava/lang/String;*>;",
+			java:  "java.util.Map<java.lang.String, ?> m()",
+			short: "Map<String, ?> m()",
+		},
+		{name: "empty", s: "", wantErr: true},
+		{name: "missing parameters", s: "<T:Ljava/lang/Object;>TT;", wantErr: true},
+		{name: "unterminated parameters", s: "(I", wantErr: true},
+		{name: "missing result", s: "()", wantErr: true},
+		{name: "void parameter", s: "(V)V", wantErr: true},
+		{name: "throws base type", s: "()V^I", wantErr: true},
+		{name: "throws nothing", s: "()V^", wantErr: true},
+		{name: "trailing characters", s: "()VV", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMethod(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMethod(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if s := got.Signature(); s != tt.s {
+				t.Errorf("Signature() = %q, want %q", s, tt.s)
+			}
+			if j := got.Java("m", false); j != tt.java {
+				t.Errorf("Java(false) = %q, want %q", j, tt.java)
+			}
+			if j := got.Java("m", true); j != tt.short {
+				t.Errorf("Java(true) = %q, want %q", j, tt.short)
+			}
+		})
+	}
+}